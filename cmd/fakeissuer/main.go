@@ -0,0 +1,86 @@
+// Command fakeissuer simulates a corporate card issuer by posting signed
+// transaction webhooks to a locally running server.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+var merchants = []struct {
+	name     string
+	category string
+}{
+	{"Uber", "travel"},
+	{"Delta Air Lines", "travel"},
+	{"Hilton Hotels", "travel"},
+	{"Pret A Manger", "meals"},
+	{"Chicken Republic", "meals"},
+	{"Staples", "supplies"},
+	{"WeWork", "office"},
+	{"Unknown Merchant", ""},
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	url := flag.String("url", fmt.Sprintf("http://localhost:%s/api/cards/transactions", port), "webhook endpoint")
+	secret := flag.String("secret", os.Getenv("CARD_WEBHOOK_SECRET"), "shared HMAC secret")
+	card := flag.String("card", "", "card token registered via POST /api/cards")
+	count := flag.Int("count", 1, "number of transactions to send")
+	currency := flag.String("currency", "USD", "transaction currency")
+	flag.Parse()
+
+	if *card == "" || *secret == "" {
+		log.Fatal("both -card and -secret (or CARD_WEBHOOK_SECRET) are required")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for i := 0; i < *count; i++ {
+		m := merchants[rand.Intn(len(merchants))]
+		event := dto.CardTransactionEvent{
+			ID:           fmt.Sprintf("txn_%d_%d", time.Now().UnixNano(), i),
+			CardToken:    *card,
+			Amount:       float64(rand.Intn(50000)+100) / 100,
+			Currency:     *currency,
+			Merchant:     m.name,
+			Category:     m.category,
+			TransactedAt: time.Now().Add(-time.Duration(rand.Intn(72)) * time.Hour).UTC(),
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			log.Fatalf("failed to encode event: %v", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, *url, bytes.NewReader(body))
+		if err != nil {
+			log.Fatalf("failed to build request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Card-Signature", "sha256="+utils.SignHMAC(*secret, body))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Fatalf("failed to send %s: %v", event.ID, err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		log.Printf("%s %.2f %s at %s -> %s %s", event.ID, event.Amount, event.Currency, event.Merchant, resp.Status, respBody)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
 	"github.com/onunkwor/flypro-assestment-v2/internal/routes"
 )
 
@@ -20,9 +21,19 @@ func init() {
 func main() {
 	router := gin.Default()
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestContextMiddleware())
+	routes.UseIdempotency(router)
+	broker := routes.StartOutbox()
 	routes.RegisterUserRoutes(router)
-	routes.RegisterExpenseRoutes(router)
-	routes.RegisterReportRoutes(router)
+	routes.RegisterExpenseRoutes(router, broker)
+	routes.RegisterReportRoutes(router, broker)
+	routes.RegisterCardRoutes(router, broker)
+	routes.RegisterAuditRoutes(router)
+	routes.RegisterNotificationRoutes(router, broker)
+	routes.RegisterWebhookRoutes(router, broker)
+	routes.RegisterJobRoutes(router)
+	routes.RegisterMetricsRoutes(router)
+	routes.RegisterHealthRoutes(router)
 	port, err := config.Getenv("PORT")
 	if err != nil {
 		log.Fatal("Failed to get PORT:", err)
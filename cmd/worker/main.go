@@ -0,0 +1,47 @@
+// Command worker runs the background job queue: scheduled maintenance and
+// any work enqueued by the API server. Run as many as needed; they share the
+// queue safely.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/routes"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+func main() {
+	config.LoadEnv()
+	if err := config.ConnectDatabase(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	config.ConnectRedis()
+
+	workers, err := strconv.Atoi(config.GetenvDefault("JOB_WORKERS", "4"))
+	if err != nil || workers <= 0 {
+		log.Fatal("JOB_WORKERS must be a positive number")
+	}
+	interval, err := time.ParseDuration(config.GetenvDefault("JOB_POLL_INTERVAL", "1s"))
+	if err != nil || interval <= 0 {
+		interval = time.Second
+	}
+
+	jobService := services.NewJobService(repository.NewJobRepository(config.DB), workers)
+	if err := routes.RegisterJobHandlers(jobService); err != nil {
+		log.Fatalf("failed to register jobs: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Printf("⚙️ Worker running with %d workers", workers)
+	jobService.Run(ctx, interval)
+	log.Println("worker stopped")
+}
@@ -15,6 +15,13 @@ func LoadEnv() {
 
 }
 
+func GetenvDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
 func Getenv(key string) (string, error) {
 	value := os.Getenv(key)
 	if value == "" {
@@ -2,26 +2,131 @@ package config
 
 import (
 	"context"
+	"expvar"
 	"log"
+	"sync/atomic"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var Redis *redis.Client
+const (
+	redisHealthInterval = 5 * time.Second
+	cacheEpochKey       = "cache:epoch"
+)
+
+var (
+	Redis   *redis.Client
+	redisUp atomic.Bool
+	// redisChecked is set after the first health check, so the initial
+	// connection is not mistaken for a recovery.
+	redisChecked atomic.Bool
+	cacheEpoch   atomic.Int64
+)
 
+func init() {
+	expvar.Publish("redis", expvar.Func(func() any { return RedisStatus() }))
+}
+
+// ConnectRedis sets up the Redis client. Redis only backs caches and is not
+// required to start: without REDIS_ADDR caching is disabled, and while Redis
+// is unreachable a background monitor keeps pinging it so the client
+// reconnects as soon as it is back. RedisHealthy reports the last result.
 func ConnectRedis() {
-	Addr, err := Getenv("REDIS_ADDR")
-	if err != nil {
-		log.Fatalf("environment variable REDIS_ADDR not set: %v", err)
+	addr := GetenvDefault("REDIS_ADDR", "")
+	if addr == "" {
+		log.Println("⚠️ REDIS_ADDR not set, running without Redis")
+		return
 	}
 	Redis = redis.NewClient(&redis.Options{
-		Addr: Addr,
-		DB:   0,
+		Addr:         addr,
+		DB:           0,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
 	})
 
-	_, err = Redis.Ping(context.Background()).Result()
+	if pingRedis() {
+		log.Println("✅ Successfully connected to Redis")
+	} else {
+		log.Printf("⚠️ Redis at %s is unavailable, serving without the cache until it recovers", addr)
+	}
+	go monitorRedis(redisHealthInterval)
+}
+
+// RedisHealthy reports whether the last health check reached Redis.
+func RedisHealthy() bool {
+	return Redis != nil && redisUp.Load()
+}
+
+// RedisStatus is "up", "down" or "disabled" when Redis is not configured.
+func RedisStatus() string {
+	switch {
+	case Redis == nil:
+		return "disabled"
+	case redisUp.Load():
+		return "up"
+	default:
+		return "down"
+	}
+}
+
+// CacheEpoch is the generation cache keys are namespaced under. It is
+// bumped whenever Redis recovers, because deletes and counter bumps sent
+// while it was down were dropped and the entries they should have retired
+// may still be there.
+func CacheEpoch() int64 {
+	return cacheEpoch.Load()
+}
+
+// MarkRedisDown bypasses Redis until the next health check succeeds, which
+// then starts a new cache epoch. The cache calls it when an invalidation
+// fails before the health check has noticed an outage.
+func MarkRedisDown() {
+	if redisUp.Swap(false) {
+		log.Println("⚠️ Redis command failed, bypassing the cache until the next health check")
+	}
+}
+
+// pingRedis checks Redis and loads the cache epoch, which other processes
+// may have bumped. Coming back from an outage it bumps the epoch itself
+// before reporting Redis up, so no request reads an entry that missed its
+// invalidation.
+func pingRedis() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	recovering := redisChecked.Swap(true) && !redisUp.Load()
+
+	var epoch int64
+	err := Redis.Ping(ctx).Err()
+	if err == nil && recovering {
+		epoch, err = Redis.Incr(ctx, cacheEpochKey).Result()
+	} else if err == nil {
+		epoch, err = Redis.Get(ctx, cacheEpochKey).Int64()
+		if err == redis.Nil {
+			err = nil
+		}
+	}
 	if err != nil {
-		log.Fatalf("failed to connect to redis: %v", err)
+		redisUp.Store(false)
+		return false
+	}
+	cacheEpoch.Store(epoch)
+	redisUp.Store(true)
+	return true
+}
+
+func monitorRedis(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		was := redisUp.Load()
+		if up := pingRedis(); up != was {
+			if up {
+				log.Printf("✅ Redis is reachable again, cache re-enabled under epoch %d", CacheEpoch())
+			} else {
+				log.Println("⚠️ Redis is unreachable, bypassing the cache")
+			}
+		}
 	}
-	log.Println("✅ Successfully connected to Redis")
 }
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+type RegisterCardRequest struct {
+	UserID    uint   `json:"user_id" binding:"required"`
+	CardToken string `json:"card_token" binding:"required,max=64"`
+	Last4     string `json:"last4" binding:"omitempty,len=4,numeric"`
+}
+
+// CardTransactionEvent is the payload the card issuer posts for each charge.
+type CardTransactionEvent struct {
+	ID           string    `json:"id" binding:"required,max=100"`
+	CardToken    string    `json:"card_token" binding:"required"`
+	Amount       float64   `json:"amount" binding:"required,gt=0"`
+	Currency     string    `json:"currency" binding:"required,len=3"`
+	Merchant     string    `json:"merchant" binding:"max=255"`
+	Category     string    `json:"category"`
+	TransactedAt time.Time `json:"transacted_at" binding:"required"`
+}
+
+type MatchCardTransactionRequest struct {
+	UserID    uint `json:"user_id" binding:"required"`
+	ExpenseID uint `json:"expense_id" binding:"required"`
+}
+
+func (r *RegisterCardRequest) Sanitize() {
+	r.CardToken = utils.SanitizeString(r.CardToken)
+}
+
+func (r *CardTransactionEvent) Sanitize() {
+	r.Merchant = utils.SanitizeString(r.Merchant)
+	r.Category = utils.NormalizeCategory(r.Category)
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/onunkwor/flypro-assestment-v2/internal/utils"
+
+type CreateCommentRequest struct {
+	AuthorID uint   `json:"author_id" binding:"required"`
+	Body     string `json:"body" binding:"required,max=2000"`
+}
+
+func (r *CreateCommentRequest) Sanitize() {
+	r.Body = utils.SanitizeString(r.Body)
+}
@@ -1,11 +1,21 @@
 package dto
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+)
+
 type CreateExpenseRequest struct {
 	UserId      uint    `json:"user_id" binding:"required"`
 	Amount      float64 `json:"amount" binding:"required,gt=0"`
 	Currency    string  `json:"currency" binding:"required,len=3,oneof=USD EUR GBP NGN"`
 	Category    string  `json:"category" binding:"required,oneof=travel meals office supplies"`
 	Description string  `json:"description" binding:"max=500"`
+	Receipt     string  `json:"receipt" binding:"omitempty,max=255"`
 }
 
 type UpdateExpenseRequest struct {
@@ -14,4 +24,69 @@ type UpdateExpenseRequest struct {
 	Currency    string  `json:"currency" binding:"required,len=3,oneof=USD EUR GBP NGN"`
 	Category    string  `json:"category" binding:"required,oneof=travel meals office supplies"`
 	Description string  `json:"description" binding:"max=500"`
+	Receipt     string  `json:"receipt" binding:"omitempty,max=255"`
+}
+
+var ErrInvalidMergePatch = errors.New("invalid merge patch")
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to the request and
+// returns the names of the fields it set, so just those can be validated
+// against the request's tags. A null member resets its field: optional
+// fields are cleared and required ones then fail validation. The owner
+// cannot be patched.
+func (r *UpdateExpenseRequest) ApplyMergePatch(patch []byte) ([]string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return nil, fmt.Errorf("%w: the patch must be a JSON object", ErrInvalidMergePatch)
+	}
+	targets := map[string]struct {
+		field string
+		value interface{}
+	}{
+		"amount":      {"Amount", &r.Amount},
+		"currency":    {"Currency", &r.Currency},
+		"category":    {"Category", &r.Category},
+		"description": {"Description", &r.Description},
+		"receipt":     {"Receipt", &r.Receipt},
+	}
+	fields := make([]string, 0, len(members))
+	for name, raw := range members {
+		target, ok := targets[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s cannot be patched", ErrInvalidMergePatch, name)
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			switch v := target.value.(type) {
+			case *float64:
+				*v = 0
+			case *string:
+				*v = ""
+			}
+		} else if err := json.Unmarshal(raw, target.value); err != nil {
+			return nil, fmt.Errorf("%w: %s has the wrong type", ErrInvalidMergePatch, name)
+		}
+		fields = append(fields, target.field)
+	}
+	return fields, nil
+}
+
+const (
+	ImportRowValid    = "valid"
+	ImportRowImported = "imported"
+	ImportRowFailed   = "failed"
+	ImportRowSkipped  = "skipped"
+)
+
+type ImportRowResult struct {
+	Line    int               `json:"line"`
+	Status  string            `json:"status"`
+	Errors  map[string]string `json:"errors,omitempty"`
+	Expense *models.Expense   `json:"expense,omitempty"`
+}
+
+type DuplicateWarning struct {
+	DuplicateOf uint     `json:"duplicate_of"`
+	Link        string   `json:"link"`
+	Reasons     []string `json:"reasons"`
+	Score       float64  `json:"score"`
 }
@@ -0,0 +1,72 @@
+package dto_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		patch          string
+		expectedFields []string
+		expectedErr    error
+		invalid        bool
+	}{
+		{name: "SetsMembers", patch: `{"amount": 12.5, "currency": "EUR"}`, expectedFields: []string{"Amount", "Currency"}},
+		{name: "NullClearsOptionalField", patch: `{"description": null}`, expectedFields: []string{"Description"}},
+		{name: "NullOnAmountFailsValidation", patch: `{"amount": null}`, expectedFields: []string{"Amount"}, invalid: true},
+		{name: "NullOnCategoryFailsValidation", patch: `{"category": null}`, expectedFields: []string{"Category"}, invalid: true},
+		{name: "EmptyObject", patch: `{}`, expectedFields: []string{}},
+		{name: "UnknownMember", patch: `{"merchant": "Acme"}`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "OwnerCannotBePatched", patch: `{"user_id": 2}`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "WrongTypeForAmount", patch: `{"amount": "12"}`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "WrongTypeForCurrency", patch: `{"currency": 1}`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "Array", patch: `[{"amount": 1}]`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "String", patch: `"amount"`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "Null", patch: `null`, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "Empty", patch: ``, expectedErr: dto.ErrInvalidMergePatch},
+		{name: "Malformed", patch: `{"amount": 1`, expectedErr: dto.ErrInvalidMergePatch},
+	}
+	validate := binding.Validator.Engine().(*validator.Validate)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := dto.UpdateExpenseRequest{UserId: 1, Amount: 10, Currency: "USD", Category: "meals", Description: "Lunch"}
+			fields, err := request.ApplyMergePatch([]byte(tt.patch))
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			slices.Sort(fields)
+			if !slices.Equal(fields, tt.expectedFields) {
+				t.Errorf("expected fields %v, got %v", tt.expectedFields, fields)
+			}
+			if request.UserId != 1 {
+				t.Errorf("expected the owner to be kept, got %d", request.UserId)
+			}
+			if len(fields) == 0 {
+				return
+			}
+			if err := validate.StructPartial(&request, fields...); (err != nil) != tt.invalid {
+				t.Errorf("expected invalid %v, got %v", tt.invalid, err)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchValues(t *testing.T) {
+	request := dto.UpdateExpenseRequest{Amount: 10, Currency: "USD", Description: "Lunch", Receipt: "a.pdf"}
+	if _, err := request.ApplyMergePatch([]byte(`{"amount": 12.5, "description": null, "receipt": "b.pdf"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Amount != 12.5 || request.Currency != "USD" || request.Description != "" || request.Receipt != "b.pdf" {
+		t.Errorf("unexpected request after patch: %+v", request)
+	}
+}
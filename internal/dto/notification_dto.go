@@ -0,0 +1,8 @@
+package dto
+
+type UpdateNotificationPreferenceRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Event  string `json:"event" binding:"required"`
+	Email  *bool  `json:"email" binding:"required"`
+	InApp  *bool  `json:"in_app" binding:"required"`
+}
@@ -1,10 +1,15 @@
 package dto
 
-import "github.com/onunkwor/flypro-assestment-v2/internal/utils"
+import (
+	"strings"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
 
 type CreateReportRequest struct {
-	Title  string `json:"title" binding:"required"`
-	UserID uint   `json:"user_id" binding:"required"`
+	Title    string `json:"title" binding:"required"`
+	UserID   uint   `json:"user_id" binding:"required"`
+	Currency string `json:"currency" binding:"omitempty,len=3,alpha"`
 }
 
 type AddExpenseToReportRequest struct {
@@ -13,4 +18,14 @@ type AddExpenseToReportRequest struct {
 
 func (r *CreateReportRequest) Sanitize() {
 	r.Title = utils.SanitizeString(r.Title)
+	r.Currency = strings.ToUpper(r.Currency)
+}
+
+type ReviewReportRequest struct {
+	ApproverID uint   `json:"approver_id" binding:"required"`
+	Reason     string `json:"reason" binding:"max=500"`
+}
+
+func (r *ReviewReportRequest) Sanitize() {
+	r.Reason = utils.SanitizeString(r.Reason)
 }
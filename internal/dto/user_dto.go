@@ -1,13 +1,38 @@
 package dto
 
-import "github.com/onunkwor/flypro-assestment-v2/internal/utils"
+import (
+	"strings"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
 
 type CreateUserRequest struct {
-	Email string `json:"email" binding:"required,email"`
-	Name  string `json:"name" binding:"required"`
+	Email             string `json:"email" binding:"required,email"`
+	Name              string `json:"name" binding:"required"`
+	ReportingCurrency string `json:"reporting_currency" binding:"omitempty,len=3,alpha"`
 }
 
 func (r *CreateUserRequest) Sanitize() {
 	r.Email = utils.SanitizeString(r.Email)
 	r.Name = utils.SanitizeString(r.Name)
+	r.ReportingCurrency = strings.ToUpper(r.ReportingCurrency)
+}
+
+// UpdateUserRequest changes only the fields present in the body.
+type UpdateUserRequest struct {
+	Email             *string `json:"email" binding:"omitempty,email"`
+	Name              *string `json:"name" binding:"omitempty,min=1,max=100"`
+	ReportingCurrency *string `json:"reporting_currency" binding:"omitempty,len=3,alpha"`
+}
+
+func (r *UpdateUserRequest) Sanitize() {
+	if r.Email != nil {
+		*r.Email = utils.SanitizeString(*r.Email)
+	}
+	if r.Name != nil {
+		*r.Name = utils.SanitizeString(*r.Name)
+	}
+	if r.ReportingCurrency != nil {
+		*r.ReportingCurrency = strings.ToUpper(*r.ReportingCurrency)
+	}
 }
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=255"`
+}
+
+func (r *CreateWebhookRequest) Sanitize() {
+	r.URL = utils.SanitizeString(r.URL)
+	for i, e := range r.EventTypes {
+		r.EventTypes[i] = utils.SanitizeString(e)
+	}
+}
+
+// WebhookCreatedResponse is the only place the signing secret is returned.
+type WebhookCreatedResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,132 @@
+// Package events carries domain events from the services that produce them
+// to the subsystems that react to them, such as notifications.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	ExpenseCreated        = "expense.created"
+	ExpenseUpdated        = "expense.updated"
+	ExpenseDeleted        = "expense.deleted"
+	ExpenseRestored       = "expense.restored"
+	ReportSubmitted       = "report.submitted"
+	ReportApproved        = "report.approved"
+	ReportRejected        = "report.rejected"
+	ReportPolicyViolation = "report.policy_violation"
+)
+
+// Event is the envelope every subscriber receives. Data holds the JSON
+// encoded payload, e.g. a ReportPayload for report events.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the event payload into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+type ReportPayload struct {
+	ReportID uint     `json:"report_id"`
+	UserID   uint     `json:"user_id"`
+	Title    string   `json:"title"`
+	Status   string   `json:"status"`
+	Total    float64  `json:"total"`
+	ActorID  uint     `json:"actor_id,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Issues   []string `json:"issues,omitempty"`
+}
+
+type ExpensePayload struct {
+	ExpenseID   uint    `json:"expense_id"`
+	UserID      uint    `json:"user_id"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	AmountUSD   float64 `json:"amount_usd"`
+	Category    string  `json:"category"`
+	Description string  `json:"description"`
+	Status      string  `json:"status"`
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, payload interface{}) error
+}
+
+type Handler func(ctx context.Context, event Event) error
+
+// Broker is a Publisher that subscribers can register with.
+type Broker interface {
+	Publisher
+	Subscribe(eventType string, h Handler)
+}
+
+// New builds an event with a fresh ID and the payload encoded as JSON.
+func New(eventType string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         hex.EncodeToString(id[:]),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}, nil
+}
+
+// Bus delivers events to in-process subscribers. It has no persistence of
+// its own; the outbox dispatcher feeds it events that are already committed.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers h for eventType. The type "*" receives every event.
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+func (b *Bus) Publish(ctx context.Context, eventType string, payload interface{}) error {
+	event, err := New(eventType, payload)
+	if err != nil {
+		return err
+	}
+	return b.Deliver(ctx, event)
+}
+
+// Deliver runs every subscriber of the event in turn and returns their
+// joined errors. All subscribers run even when an earlier one fails.
+func (b *Bus) Deliver(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[event.Type]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
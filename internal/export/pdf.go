@@ -0,0 +1,297 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+)
+
+const (
+	thumbnailMaxPx = 400
+	thumbnailWidth = 55.0
+	thumbnailsRow  = 3
+
+	// The document is laid out in memory before it is written, so receipts
+	// are bounded: at most maxThumbnails previews per report, and receipts
+	// larger than maxReceiptBytes or maxReceiptPixels are only listed by name.
+	maxThumbnails    = 60
+	maxReceiptBytes  = 10 << 20
+	maxReceiptPixels = 40_000_000
+)
+
+type ReceiptOpener interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+type StatusEntry struct {
+	Status string
+	At     time.Time
+	Actor  string
+	Reason string
+}
+
+type ReportDocument struct {
+	Report  *models.ExpenseReport
+	History []StatusEntry
+}
+
+var pdfColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Date", 22, "L"},
+	{"Category", 24, "L"},
+	{"Description", 56, "L"},
+	{"Amount", 24, "R"},
+	{"Cur", 14, "C"},
+	{"Rate", 20, "R"},
+	{"USD", 30, "R"},
+}
+
+// RenderReportPDF writes a printable version of the report to w. Receipts
+// that cannot be opened or decoded as images are listed by name instead of
+// failing the whole document.
+func RenderReportPDF(w io.Writer, doc ReportDocument, receipts ReceiptOpener) error {
+	report := doc.Report
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 8, fmt.Sprintf("Report #%d - page %d/{nb}", report.ID, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr("Expense Report: "+report.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	writeField(pdf, "Report ID", fmt.Sprint(report.ID))
+	writeField(pdf, "Status", report.Status)
+	writeField(pdf, "Created", report.CreatedAt.Format(time.RFC1123))
+	if report.User != nil {
+		writeField(pdf, "Submitted by", tr(fmt.Sprintf("%s <%s>", report.User.Name, report.User.Email)))
+	} else {
+		writeField(pdf, "User ID", fmt.Sprint(report.UserID))
+	}
+	pdf.Ln(4)
+
+	sectionTitle(pdf, "Status history")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, h := range doc.History {
+		line := fmt.Sprintf("%s  %s", h.At.Format("2006-01-02 15:04"), h.Status)
+		if h.Actor != "" {
+			line += " by " + h.Actor
+		}
+		if h.Reason != "" {
+			line += " - " + h.Reason
+		}
+		pdf.MultiCell(0, 5, tr(line), "", "L", false)
+	}
+	pdf.Ln(4)
+
+	sectionTitle(pdf, "Expenses")
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range pdfColumns {
+		pdf.CellFormat(col.width, 7, col.title, "1", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	var totalUSD float64
+	byCurrency := map[string]float64{}
+	for _, e := range report.Expenses {
+		desc := e.Description
+		if lines := pdf.SplitText(tr(desc), pdfColumns[2].width-2); len(lines) > 1 {
+			desc = lines[0] + "..."
+		} else {
+			desc = tr(desc)
+		}
+		cells := []string{
+			e.CreatedAt.Format("2006-01-02"),
+			tr(e.Category),
+			desc,
+			fmt.Sprintf("%.2f", e.Amount),
+			e.Currency,
+			fmt.Sprintf("%.4f", e.ExchangeRate),
+			fmt.Sprintf("%.2f", e.AmountUSD),
+		}
+		for i, col := range pdfColumns {
+			pdf.CellFormat(col.width, 6, cells[i], "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+		totalUSD += e.AmountUSD
+		byCurrency[e.Currency] += e.Amount
+	}
+	pdf.Ln(3)
+
+	sectionTitle(pdf, "Totals")
+	pdf.SetFont("Helvetica", "", 10)
+	currencies := make([]string, 0, len(byCurrency))
+	for c := range byCurrency {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	for _, c := range currencies {
+		writeField(pdf, "Original "+c, fmt.Sprintf("%.2f", byCurrency[c]))
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	writeField(pdf, "Total USD", fmt.Sprintf("%.2f", totalUSD))
+	if report.Total != totalUSD {
+		pdf.SetFont("Helvetica", "", 10)
+		writeField(pdf, "Recorded total", fmt.Sprintf("%.2f", report.Total))
+	}
+	if report.Currency != "" && report.Currency != "USD" && report.ExchangeRate != 0 {
+		pdf.SetFont("Helvetica", "B", 10)
+		writeField(pdf, "Total "+report.Currency, fmt.Sprintf("%.2f", report.ReportingTotal))
+		pdf.SetFont("Helvetica", "", 10)
+		rateNote := "live rate"
+		if report.RatesFrozen() {
+			rateNote = "frozen " + report.RatesFrozenAt.Format("2006-01-02 15:04 MST")
+		}
+		writeField(pdf, "USD rate", fmt.Sprintf("%.6f (%s)", report.ExchangeRate, rateNote))
+	}
+
+	writeReceipts(pdf, report.Expenses, receipts)
+
+	return pdf.Output(w)
+}
+
+func writeReceipts(pdf *fpdf.Fpdf, expenses []models.Expense, receipts ReceiptOpener) {
+	var withReceipt []models.Expense
+	for _, e := range expenses {
+		if e.Receipt != "" {
+			withReceipt = append(withReceipt, e)
+		}
+	}
+	if len(withReceipt) == 0 {
+		return
+	}
+
+	pdf.AddPage()
+	sectionTitle(pdf, "Receipts")
+	left, _, _, _ := pdf.GetMargins()
+	x, y := left, pdf.GetY()
+	rowHeight := 0.0
+	col := 0
+	embedded := 0
+	for _, e := range withReceipt {
+		label := fmt.Sprintf("Expense #%d", e.ID)
+		var name string
+		var info *fpdf.ImageInfoType
+		note := " (preview limit reached)"
+		if embedded < maxThumbnails {
+			name, info = registerThumbnail(pdf, receipts, e)
+			note = " (not previewable)"
+		}
+		if info == nil {
+			pdf.SetFont("Helvetica", "", 9)
+			pdf.SetXY(x, y)
+			pdf.MultiCell(thumbnailWidth, 5, label+": "+e.Receipt+note, "", "L", false)
+		} else {
+			embedded++
+			height := thumbnailWidth * info.Height() / info.Width()
+			_, pageHeight := pdf.GetPageSize()
+			if y+height+8 > pageHeight-15 {
+				pdf.AddPage()
+				x, y, col, rowHeight = left, pdf.GetY(), 0, 0
+			}
+			pdf.ImageOptions(name, x, y, thumbnailWidth, 0, false, fpdf.ImageOptions{ImageType: "JPG"}, 0, "")
+			pdf.SetFont("Helvetica", "", 8)
+			pdf.SetXY(x, y+height+1)
+			pdf.CellFormat(thumbnailWidth, 4, label, "", 0, "C", false, 0, "")
+			if height+6 > rowHeight {
+				rowHeight = height + 6
+			}
+		}
+		if rowHeight < 12 {
+			rowHeight = 12
+		}
+		col++
+		x += thumbnailWidth + 8
+		if col == thumbnailsRow {
+			x, y, col = left, y+rowHeight, 0
+			rowHeight = 0
+		}
+	}
+}
+
+// registerThumbnail decodes the receipt and re-encodes a downscaled JPEG so
+// any format the standard library can read ends up embeddable. Only the
+// small thumbnail is kept; oversized files and images are skipped before
+// they are decoded.
+func registerThumbnail(pdf *fpdf.Fpdf, receipts ReceiptOpener, e models.Expense) (string, *fpdf.ImageInfoType) {
+	if receipts == nil {
+		return "", nil
+	}
+	rc, err := receipts.Open(e.Receipt)
+	if err != nil {
+		return "", nil
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxReceiptBytes+1))
+	if err != nil || len(data) > maxReceiptBytes {
+		return "", nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxReceiptPixels {
+		return "", nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, downscale(img, thumbnailMaxPx), &jpeg.Options{Quality: 75}); err != nil {
+		return "", nil
+	}
+	name := fmt.Sprintf("receipt-%d", e.ID)
+	info := pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "JPG"}, &buf)
+	if pdf.Err() {
+		pdf.ClearError()
+		return "", nil
+	}
+	return name, info
+}
+
+func downscale(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	scale := float64(maxSide) / float64(max(w, h))
+	nw, nh := max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+	out := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			out.Set(x, y, img.At(b.Min.X+int(float64(x)/scale), b.Min.Y+int(float64(y)/scale)))
+		}
+	}
+	return out
+}
+
+func sectionTitle(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	pdf.Ln(1)
+}
+
+func writeField(pdf *fpdf.Fpdf, label, value string) {
+	pdf.CellFormat(40, 6, label+":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, value, "", 1, "L", false, 0, "")
+}
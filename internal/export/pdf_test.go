@@ -0,0 +1,137 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/export"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
+)
+
+type memReceipts map[string][]byte
+
+func (m memReceipts) Open(name string) (io.ReadCloser, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, storage.ErrReceiptNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func pngImage(t *testing.T, w, h int, shade uint8) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), shade, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// hugePNGHeader is a PNG that claims w x h pixels but carries no image data,
+// enough for image.DecodeConfig to see its size.
+func hugePNGHeader(w, h uint32) []byte {
+	var ihdr bytes.Buffer
+	ihdr.WriteString("IHDR")
+	binary.Write(&ihdr, binary.BigEndian, w)
+	binary.Write(&ihdr, binary.BigEndian, h)
+	ihdr.Write([]byte{8, 2, 0, 0, 0})
+	var out bytes.Buffer
+	out.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&out, binary.BigEndian, uint32(ihdr.Len()-4))
+	out.Write(ihdr.Bytes())
+	binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(ihdr.Bytes()))
+	return out.Bytes()
+}
+
+func renderPDF(t *testing.T, report *models.ExpenseReport, receipts export.ReceiptOpener) string {
+	t.Helper()
+	var buf bytes.Buffer
+	doc := export.ReportDocument{
+		Report: report,
+		History: []export.StatusEntry{
+			{Status: models.ReportStatusSubmitted, At: time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC), Actor: "Ada"},
+			{Status: models.ReportStatusRejected, At: time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC), Actor: "Bo", Reason: "missing receipt"},
+		},
+	}
+	if err := export.RenderReportPDF(&buf, doc, receipts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-") || !strings.HasSuffix(strings.TrimSpace(out), "%%EOF") {
+		t.Fatalf("output is not a complete PDF document (%d bytes)", len(out))
+	}
+	return out
+}
+
+func TestRenderReportPDF(t *testing.T) {
+	report := &models.ExpenseReport{
+		BaseModel: models.BaseModel{ID: 42, CreatedAt: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+		UserID:    7,
+		User:      &models.User{Name: "Zoë", Email: "zoe@example.com"},
+		Title:     "Berlin offsite",
+		Status:    models.ReportStatusRejected,
+		Currency:  "EUR", ExchangeRate: 0.9, Total: 150, ReportingTotal: 135,
+		Expenses: []models.Expense{
+			{BaseModel: models.BaseModel{ID: 1}, Category: "meals", Description: "Dinner", Amount: 50, Currency: "EUR", AmountUSD: 55, Receipt: "small.png"},
+			{BaseModel: models.BaseModel{ID: 2}, Category: "travel", Description: strings.Repeat("very long description ", 10), Amount: 95, Currency: "USD", AmountUSD: 95, Receipt: "ticket.pdf"},
+			{BaseModel: models.BaseModel{ID: 3}, Category: "travel", Amount: 1, Currency: "USD", AmountUSD: 1, Receipt: "missing.jpg"},
+			{BaseModel: models.BaseModel{ID: 4}, Category: "travel", Amount: 1, Currency: "USD", AmountUSD: 1, Receipt: "huge.png"},
+		},
+	}
+	receipts := memReceipts{
+		"small.png":  pngImage(t, 800, 600, 128),
+		"ticket.pdf": []byte("%PDF-1.4 not an image"),
+		"huge.png":   hugePNGHeader(20000, 20000),
+	}
+
+	out := renderPDF(t, report, receipts)
+	if got := strings.Count(out, "/Subtype /Image"); got != 1 {
+		t.Errorf("expected only the decodable receipt embedded, got %d images", got)
+	}
+}
+
+func TestRenderReportPDFWithoutReceiptStore(t *testing.T) {
+	report := &models.ExpenseReport{
+		BaseModel: models.BaseModel{ID: 1},
+		Title:     "No store",
+		Expenses:  []models.Expense{{BaseModel: models.BaseModel{ID: 1}, Amount: 5, Currency: "USD", AmountUSD: 5, Receipt: "a.png"}},
+	}
+	out := renderPDF(t, report, nil)
+	if strings.Contains(out, "/Subtype /Image") {
+		t.Error("expected no images without a receipt store")
+	}
+}
+
+func TestRenderReportPDFBoundsThumbnails(t *testing.T) {
+	receipts := memReceipts{}
+	report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 1}, Title: "Many receipts"}
+	for i := 1; i <= 75; i++ {
+		name := fmt.Sprintf("r%d.png", i)
+		// Distinct images, since identical ones share a single PDF object.
+		receipts[name] = pngImage(t, 20, 20, uint8(i*3))
+		report.Expenses = append(report.Expenses, models.Expense{
+			BaseModel: models.BaseModel{ID: uint(i)}, Amount: 1, Currency: "USD", AmountUSD: 1, Receipt: name,
+		})
+	}
+
+	out := renderPDF(t, report, receipts)
+	if got := strings.Count(out, "/Subtype /Image"); got != 60 {
+		t.Errorf("expected 60 thumbnails, got %d", got)
+	}
+}
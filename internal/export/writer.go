@@ -0,0 +1,88 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// RowWriter writes tabular rows one at a time so exports never hold the
+// full result set in memory.
+type RowWriter interface {
+	WriteRow(cells []Cell) error
+	Close() error
+}
+
+// Cell is a single spreadsheet value. Numeric cells are kept as numbers in
+// xlsx output so totals can be computed in the spreadsheet.
+type Cell struct {
+	Value   string
+	Numeric bool
+}
+
+func Text(s string) Cell {
+	return Cell{Value: s}
+}
+
+func Number(f float64) Cell {
+	return Cell{Value: strconv.FormatFloat(f, 'f', -1, 64), Numeric: true}
+}
+
+func NewRowWriter(format string, w io.Writer) (RowWriter, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, "Expenses")
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func ContentType(format string) string {
+	switch strings.ToLower(format) {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+var expenseHeader = []string{
+	"id", "user_id", "date", "category", "description",
+	"amount", "currency", "exchange_rate", "amount_usd", "status", "receipt",
+}
+
+func WriteExpenseHeader(w RowWriter) error {
+	cells := make([]Cell, len(expenseHeader))
+	for i, h := range expenseHeader {
+		cells[i] = Text(h)
+	}
+	return w.WriteRow(cells)
+}
+
+func WriteExpense(w RowWriter, e *models.Expense) error {
+	return w.WriteRow([]Cell{
+		Number(float64(e.ID)),
+		Number(float64(e.UserID)),
+		Text(e.CreatedAt.Format("2006-01-02")),
+		Text(e.Category),
+		Text(e.Description),
+		Number(e.Amount),
+		Text(e.Currency),
+		Number(e.ExchangeRate),
+		Number(e.AmountUSD),
+		Text(e.Status),
+		Text(e.Receipt),
+	})
+}
@@ -0,0 +1,237 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/export"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+)
+
+var tricky = []string{
+	`plain`,
+	`comma, inside`,
+	`"quoted"`,
+	"line\nbreak",
+	`<tag attr="x">&amp;</tag>`,
+	`  padded  `,
+	`ünïcödé €`,
+}
+
+func TestNewRowWriterRejectsUnknownFormat(t *testing.T) {
+	if _, err := export.NewRowWriter("pdf", io.Discard); !errors.Is(err, export.ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.NewRowWriter("CSV", &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := export.WriteExpenseHeader(w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, s := range tricky {
+		expense := &models.Expense{
+			BaseModel:   models.BaseModel{ID: uint(i + 1), CreatedAt: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+			UserID:      9,
+			Category:    "meals",
+			Description: s,
+			Amount:      12.5,
+			Currency:    "EUR",
+		}
+		if err := export.WriteExpense(w, expense); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != len(tricky)+1 {
+		t.Fatalf("expected %d records, got %d", len(tricky)+1, len(records))
+	}
+	if strings.Join(records[0], ",") != "id,user_id,date,category,description,amount,currency,exchange_rate,amount_usd,status,receipt" {
+		t.Errorf("unexpected header %v", records[0])
+	}
+	for i, s := range tricky {
+		row := records[i+1]
+		if row[4] != s {
+			t.Errorf("row %d: expected description %q, got %q", i, s, row[4])
+		}
+		if row[2] != "2026-03-04" || row[5] != "12.5" {
+			t.Errorf("row %d: unexpected date/amount %q/%q", i, row[2], row[5])
+		}
+	}
+}
+
+// flushCounter records how much reached the underlying writer before Close.
+type flushCounter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (f *flushCounter) Write(p []byte) (int, error) {
+	f.writes++
+	return f.Buffer.Write(p)
+}
+
+func TestCSVStreamsBeforeClose(t *testing.T) {
+	var out flushCounter
+	w, _ := export.NewRowWriter(export.FormatCSV, &out)
+	for i := 0; i < 1200; i++ {
+		if err := w.WriteRow([]export.Cell{export.Number(float64(i)), export.Text("x")}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if out.writes == 0 {
+		t.Fatal("expected rows to be flushed while writing, nothing reached the writer before Close")
+	}
+	if got := strings.Count(out.String(), "\n"); got != 1000 {
+		t.Errorf("expected the first 1000 rows flushed before Close, got %d", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Count(out.String(), "\n"); got != 1200 {
+		t.Errorf("expected 1200 rows after Close, got %d", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestCSVReportsWriteErrors(t *testing.T) {
+	w, _ := export.NewRowWriter(export.FormatCSV, failingWriter{})
+	w.WriteRow([]export.Cell{export.Text("a")})
+	if err := w.Close(); err == nil {
+		t.Fatal("expected the write error from Close")
+	}
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     string `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(t *testing.T, data []byte) (map[string]string, xlsxSheet) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(r)
+		r.Close()
+		parts[f.Name] = string(body)
+	}
+	var sheet xlsxSheet
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("sheet is not valid XML: %v", err)
+	}
+	return parts, sheet
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.NewRowWriter(export.FormatXLSX, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range tricky {
+		if err := w.WriteRow([]export.Cell{export.Text(s), export.Number(1234.5)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parts, sheet := readXLSX(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Expenses"`) {
+		t.Errorf("expected the Expenses sheet, got %s", parts["xl/workbook.xml"])
+	}
+	if len(sheet.Rows) != len(tricky) {
+		t.Fatalf("expected %d rows, got %d", len(tricky), len(sheet.Rows))
+	}
+	for i, s := range tricky {
+		row := sheet.Rows[i]
+		if row.Cells[0].T != "inlineStr" || row.Cells[0].Inline != s {
+			t.Errorf("row %d: expected text %q, got %+v", i, s, row.Cells[0])
+		}
+		if row.Cells[1].T != "" || row.Cells[1].V != "1234.5" {
+			t.Errorf("row %d: expected numeric 1234.5, got %+v", i, row.Cells[1])
+		}
+	}
+	if sheet.Rows[1].Cells[1].R != "B2" {
+		t.Errorf("expected cell reference B2, got %s", sheet.Rows[1].Cells[1].R)
+	}
+}
+
+func TestXLSXColumnReferencesPastZ(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := export.NewRowWriter(export.FormatXLSX, &buf)
+	cells := make([]export.Cell, 28)
+	for i := range cells {
+		cells[i] = export.Text("v")
+	}
+	w.WriteRow(cells)
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, sheet := readXLSX(t, buf.Bytes())
+	got := sheet.Rows[0].Cells
+	if got[25].R != "Z1" || got[26].R != "AA1" || got[27].R != "AB1" {
+		t.Errorf("unexpected references %s %s %s", got[25].R, got[26].R, got[27].R)
+	}
+}
+
+func TestXLSXStreamsBeforeClose(t *testing.T) {
+	var out flushCounter
+	w, _ := export.NewRowWriter(export.FormatXLSX, &out)
+	long := strings.Repeat("x", 200)
+	for i := 0; i < 2000; i++ {
+		w.WriteRow([]export.Cell{export.Text(long), export.Number(float64(i))})
+	}
+	if out.Len() == 0 {
+		t.Fatal("expected sheet rows to reach the writer before Close")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, sheet := readXLSX(t, out.Bytes())
+	if len(sheet.Rows) != 2000 {
+		t.Errorf("expected 2000 rows, got %d", len(sheet.Rows))
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+var auditEntities = map[string]bool{
+	services.AuditEntityUser:    true,
+	services.AuditEntityExpense: true,
+	services.AuditEntityReport:  true,
+}
+
+type AuditHandler interface {
+	ListEvents(c *gin.Context)
+	VerifyChain(c *gin.Context)
+}
+type auditHandler struct {
+	service services.AuditService
+}
+
+func NewAuditHandler(service services.AuditService) AuditHandler {
+	return &auditHandler{service: service}
+}
+
+func (h *auditHandler) ListEvents(c *gin.Context) {
+	entity := c.Query("entity")
+	if !auditEntities[entity] {
+		utils.BadRequestResponse(c, "entity must be one of user, expense or report")
+		return
+	}
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid entity ID")
+		return
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.service.List(c.Request.Context(), entity, uint(id), offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":   events,
+		"count":  len(events),
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+func (h *auditHandler) VerifyChain(c *gin.Context) {
+	result, err := h.service.Verify(c.Request.Context())
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+type CardHandler interface {
+	RegisterCard(c *gin.Context)
+	ReceiveTransaction(c *gin.Context)
+	ListTransactions(c *gin.Context)
+	MatchTransaction(c *gin.Context)
+	Attention(c *gin.Context)
+}
+type cardHandler struct {
+	service services.CardService
+}
+
+func NewCardHandler(service services.CardService) CardHandler {
+	return &cardHandler{service: service}
+}
+
+func (h *cardHandler) RegisterCard(c *gin.Context) {
+	var request dto.RegisterCardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+	request.Sanitize()
+	card := models.CorporateCard{
+		UserID:    request.UserID,
+		CardToken: request.CardToken,
+		Last4:     request.Last4,
+	}
+	if err := h.service.RegisterCard(c.Request.Context(), &card); err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Card registered successfully", "data": card})
+}
+
+func (h *cardHandler) ReceiveTransaction(c *gin.Context) {
+	var request dto.CardTransactionEvent
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+	request.Sanitize()
+	txn := &models.CardTransaction{
+		ExternalID:   request.ID,
+		Amount:       request.Amount,
+		Currency:     request.Currency,
+		Merchant:     request.Merchant,
+		Category:     request.Category,
+		TransactedAt: request.TransactedAt,
+	}
+	stored, err := h.service.IngestTransaction(c.Request.Context(), request.CardToken, txn)
+	if err != nil {
+		if errors.Is(err, services.ErrCardNotFound) {
+			utils.NotFoundResponse(c, "card not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction received", "data": stored})
+}
+
+func (h *cardHandler) ListTransactions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		utils.BadRequestResponse(c, "invalid user ID")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		utils.BadRequestResponse(c, "invalid offset")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		utils.BadRequestResponse(c, "invalid limit")
+		return
+	}
+
+	txns, err := h.service.ListTransactions(c.Request.Context(), uint(userID), c.Query("status"), offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":   txns,
+		"count":  len(txns),
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+func (h *cardHandler) MatchTransaction(c *gin.Context) {
+	txnID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestResponse(c, "invalid transaction ID")
+		return
+	}
+	var request dto.MatchCardTransactionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+
+	err = h.service.MatchTransaction(c.Request.Context(), uint(txnID), request.ExpenseID, request.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTransactionNotFound):
+			utils.NotFoundResponse(c, "transaction not found")
+		case errors.Is(err, repository.ErrExpenseNotFound):
+			utils.NotFoundResponse(c, "expense not found")
+		case errors.Is(err, services.ErrInvalidOwnership):
+			utils.ForbiddenResponse(c, "you do not have permission to access this resource")
+		case errors.Is(err, services.ErrTransactionAlreadySet):
+			utils.DuplicateEntryResponse(c, "transaction is already linked to an expense")
+		case errors.Is(err, services.ErrExpenseAlreadyLinked):
+			utils.DuplicateEntryResponse(c, "expense is already linked to another transaction")
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction matched successfully"})
+}
+
+// Attention lists what the cardholder still has to act on: charges with no
+// expense and card expenses without a receipt.
+func (h *cardHandler) Attention(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		utils.BadRequestResponse(c, "invalid user ID")
+		return
+	}
+	ctx := c.Request.Context()
+	unmatched, err := h.service.ListTransactions(ctx, uint(userID), models.CardTransactionUnmatched, 0, 100)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	missing, err := h.service.ListExpensesMissingReceipts(ctx, uint(userID))
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"unmatched_transactions": unmatched,
+		"missing_receipts":       missing,
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+type CommentHandler interface {
+	AddReportComment(c *gin.Context)
+	ListReportComments(c *gin.Context)
+	AddExpenseComment(c *gin.Context)
+	ListExpenseComments(c *gin.Context)
+}
+type commentHandler struct {
+	service services.CommentService
+}
+
+func NewCommentHandler(service services.CommentService) CommentHandler {
+	return &commentHandler{service: service}
+}
+
+func (h *commentHandler) AddReportComment(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || reportID == 0 {
+		utils.BadRequestResponse(c, "invalid report ID")
+		return
+	}
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	if err := h.service.AddReportComment(c.Request.Context(), uint(reportID), comment); err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Comment added successfully", "data": comment})
+}
+
+func (h *commentHandler) ListReportComments(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || reportID == 0 {
+		utils.BadRequestResponse(c, "invalid report ID")
+		return
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	comments, err := h.service.ListReportComments(c.Request.Context(), uint(reportID), offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comments, "count": len(comments), "offset": offset, "limit": limit})
+}
+
+func (h *commentHandler) AddExpenseComment(c *gin.Context) {
+	expenseID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || expenseID == 0 {
+		utils.BadRequestResponse(c, "invalid expense ID")
+		return
+	}
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	if err := h.service.AddExpenseComment(c.Request.Context(), uint(expenseID), comment); err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Comment added successfully", "data": comment})
+}
+
+func (h *commentHandler) ListExpenseComments(c *gin.Context) {
+	expenseID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || expenseID == 0 {
+		utils.BadRequestResponse(c, "invalid expense ID")
+		return
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	comments, err := h.service.ListExpenseComments(c.Request.Context(), uint(expenseID), offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comments, "count": len(comments), "offset": offset, "limit": limit})
+}
+
+func bindComment(c *gin.Context) (*models.Comment, bool) {
+	var request dto.CreateCommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return nil, false
+	}
+	request.Sanitize()
+	if request.Body == "" {
+		utils.BadRequestResponse(c, "comment body cannot be empty")
+		return nil, false
+	}
+	return &models.Comment{AuthorID: request.AuthorID, Body: request.Body}, true
+}
+
+func commentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrReportNotFound):
+		utils.NotFoundResponse(c, "report not found")
+	case errors.Is(err, repository.ErrExpenseNotFound):
+		utils.NotFoundResponse(c, "expense not found")
+	case errors.Is(err, services.ErrCommentAuthorNotFound):
+		utils.BadRequestResponse(c, "comment author not found")
+	default:
+		utils.InternalServerErrorResponse(c, err)
+	}
+}
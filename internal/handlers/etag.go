@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+// setETag sends the record's version as its entity tag.
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// ifMatchVersion reads the version a change applies to from If-Match, which
+// must hold the ETag the client last read. It responds with 428 when the
+// header is missing and 400 when it is not one of our tags.
+func ifMatchVersion(c *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		utils.PreconditionRequiredResponse(c, "If-Match header with the ETag from the last read is required")
+		return 0, false
+	}
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		tag = header
+	}
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil || version == 0 {
+		utils.BadRequestResponse(c, "If-Match must be an ETag returned by this API")
+		return 0, false
+	}
+	return uint(version), true
+}
+
+func respondVersionConflict(c *gin.Context) {
+	utils.PreconditionFailedResponse(c, "the record was changed since it was read; fetch it again and retry")
+}
@@ -1,32 +1,48 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/export"
+	"github.com/onunkwor/flypro-assestment-v2/internal/importer"
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
 	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
-	"gorm.io/gorm"
 )
 
 type ExpenseHandler interface {
 	CreateExpense(c *gin.Context)
 	GetExpenseByID(c *gin.Context)
 	UpdateExpense(c *gin.Context)
+	PatchExpense(c *gin.Context)
 	DeleteExpense(c *gin.Context)
+	RestoreExpense(c *gin.Context)
 	GetExpenses(c *gin.Context)
+	ExportExpenses(c *gin.Context)
+	ImportExpenses(c *gin.Context)
+	UploadReceipt(c *gin.Context)
 }
 type expenseHandler struct {
-	service services.ExpenseService
+	service    services.ExpenseService
+	duplicates services.DuplicateService
+	receipts   *storage.ReceiptStore
 }
 
-func NewExpenseHandler(service services.ExpenseService) ExpenseHandler {
-	return &expenseHandler{service: service}
+func NewExpenseHandler(service services.ExpenseService, duplicates services.DuplicateService, receipts *storage.ReceiptStore) ExpenseHandler {
+	return &expenseHandler{service: service, duplicates: duplicates, receipts: receipts}
 }
 
 func (h *expenseHandler) CreateExpense(c *gin.Context) {
@@ -42,12 +58,21 @@ func (h *expenseHandler) CreateExpense(c *gin.Context) {
 		Currency:    request.Currency,
 		Description: request.Description,
 		Category:    request.Category,
+		Receipt:     request.Receipt,
 	}
 	if err := h.service.CreateExpense(c.Request.Context(), exp); err != nil {
-		utils.InternalServerErrorResponse(c, err)
+		respondOwnerError(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Expense created successfully"})
+
+	response := gin.H{"message": "Expense created successfully", "data": exp}
+	flags, err := h.duplicates.CheckExpense(c.Request.Context(), exp)
+	if err != nil {
+		log.Printf("duplicate check failed for expense %d: %v", exp.ID, err)
+	} else if len(flags) > 0 {
+		response["warnings"] = duplicateWarnings(flags)
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *expenseHandler) GetExpenseByID(c *gin.Context) {
@@ -60,7 +85,7 @@ func (h *expenseHandler) GetExpenseByID(c *gin.Context) {
 
 	expense, err := h.service.GetExpenseByID(c.Request.Context(), uint(id))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, repository.ErrExpenseNotFound) {
 			utils.NotFoundResponse(c, "Expense not found")
 			return
 		}
@@ -68,6 +93,7 @@ func (h *expenseHandler) GetExpenseByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, expense.Version)
 	c.JSON(http.StatusOK, gin.H{"message": "Expense retrieved successfully", "data": expense})
 }
 
@@ -98,25 +124,114 @@ func (h *expenseHandler) UpdateExpense(c *gin.Context) {
 		return
 	}
 	userID := uint(uid)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 	expense := &models.Expense{
 		Amount:      request.Amount,
 		Currency:    request.Currency,
 		Description: request.Description,
 		Category:    request.Category,
+		Receipt:     request.Receipt,
 	}
 
-	if err := h.service.UpdateExpense(c.Request.Context(), uint(id), expense, userID); err != nil {
-		if errors.Is(err, repository.ErrExpenseNotFound) {
+	if err := h.service.UpdateExpense(c.Request.Context(), uint(id), expense, userID, version); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExpenseNotFound):
 			utils.NotFoundResponse(c, "Expense not found")
-			return
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondVersionConflict(c)
+		case errors.Is(err, services.ErrReportLocked):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, err)
 		}
-		utils.InternalServerErrorResponse(c, err)
 		return
 	}
 
+	setETag(c, expense.Version)
 	c.JSON(http.StatusOK, gin.H{"message": "Expense updated successfully"})
 }
 
+// PatchExpense applies a JSON Merge Patch: members that are sent are changed,
+// null clears optional fields, and everything else is left alone. Only the
+// patched fields are validated, with the same rules as a full update.
+func (h *expenseHandler) PatchExpense(c *gin.Context) {
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   "unsupported_media_type",
+			"message": "Content-Type must be application/merge-patch+json",
+		})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid expense ID")
+		return
+	}
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid user ID")
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.BadRequestResponse(c, "invalid request body")
+		return
+	}
+
+	var request dto.UpdateExpenseRequest
+	fields, err := request.ApplyMergePatch(body)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok && len(fields) > 0 {
+		if err := validate.StructPartial(&request, fields...); err != nil {
+			utils.ValidationErrorResponse(c, utils.FormatValidationError(err))
+			return
+		}
+	}
+	var patch services.ExpensePatch
+	for _, field := range fields {
+		switch field {
+		case "Amount":
+			patch.Amount = &request.Amount
+		case "Currency":
+			patch.Currency = &request.Currency
+		case "Category":
+			patch.Category = &request.Category
+		case "Description":
+			patch.Description = &request.Description
+		case "Receipt":
+			patch.Receipt = &request.Receipt
+		}
+	}
+
+	expense, err := h.service.PatchExpense(c.Request.Context(), uint(id), uint(userID), version, patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExpenseNotFound):
+			utils.NotFoundResponse(c, "Expense not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondVersionConflict(c)
+		case errors.Is(err, services.ErrReportLocked):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+
+	setETag(c, expense.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Expense updated successfully", "data": expense})
+}
+
 func (h *expenseHandler) DeleteExpense(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
@@ -136,25 +251,95 @@ func (h *expenseHandler) DeleteExpense(c *gin.Context) {
 		return
 	}
 	userID := uint(uid)
-	if err := h.service.DeleteExpense(c.Request.Context(), uint(id), userID); err != nil {
-		if errors.Is(err, repository.ErrExpenseNotFound) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteExpense(c.Request.Context(), uint(id), userID, version); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExpenseNotFound):
 			utils.NotFoundResponse(c, "Expense not found")
-			return
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondVersionConflict(c)
+		case errors.Is(err, services.ErrReportLocked):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, err)
 		}
-		utils.InternalServerErrorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted successfully"})
 }
 
+// RestoreExpense is an admin endpoint that undoes a soft delete.
+func (h *expenseHandler) RestoreExpense(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid expense ID")
+		return
+	}
+	expense, err := h.service.RestoreExpense(c.Request.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExpenseNotFound):
+			utils.NotFoundResponse(c, "Deleted expense not found")
+		case errors.Is(err, services.ErrReportLocked):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+	setETag(c, expense.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Expense restored successfully", "data": expense})
+}
+
 func (h *expenseHandler) GetExpenses(c *gin.Context) {
+	filters, ok := parseExpenseFilters(c)
+	if !ok {
+		return
+	}
+
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	expenses, err := h.service.GetExpenses(c.Request.Context(), filters, offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   expenses,
+		"count":  len(expenses),
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+func (h *expenseHandler) ExportExpenses(c *gin.Context) {
+	filters, ok := parseExpenseFilters(c)
+	if !ok {
+		return
+	}
+
+	streamExport(c, "expenses", func(w export.RowWriter) error {
+		return h.service.StreamExpenses(c.Request.Context(), filters, func(e *models.Expense) error {
+			return export.WriteExpense(w, e)
+		})
+	})
+}
+
+func parseExpenseFilters(c *gin.Context) (map[string]interface{}, bool) {
 	filters := make(map[string]interface{})
 	if userIDParam := c.Query("user_id"); userIDParam != "" {
 		userID, err := strconv.ParseUint(userIDParam, 10, 64)
 		if err != nil {
 			utils.BadRequestResponse(c, "Invalid user ID")
-			return
+			return nil, false
 		}
 		filters["user_id"] = uint(userID)
 	}
@@ -162,39 +347,217 @@ func (h *expenseHandler) GetExpenses(c *gin.Context) {
 	if category := c.Query("category"); category != "" {
 		filters["category"] = utils.NormalizeCategory(category)
 	}
+	return filters, true
+}
+
+const (
+	maxImportFileSize = 5 << 20
+	maxImportRows     = 5000
+)
+
+func (h *expenseHandler) ImportExpenses(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.PostForm("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		utils.BadRequestResponse(c, "Invalid user ID")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dry_run", c.Query("dry_run")))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequestResponse(c, "file is required")
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		utils.BadRequestResponse(c, "file exceeds the 5MB limit")
+		return
+	}
 
+	opts := importer.Options{
+		Currency: strings.ToUpper(c.PostForm("currency")),
+		Category: utils.NormalizeCategory(c.PostForm("category")),
+	}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			utils.BadRequestResponse(c, "mapping must be a JSON object of field to column name")
+			return
+		}
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = importer.DetectFormat(fileHeader.Filename)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	defer file.Close()
+
+	records, err := importer.Parse(format, file, opts)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if len(records) > maxImportRows {
+		utils.BadRequestResponse(c, fmt.Sprintf("file has more than %d rows", maxImportRows))
+		return
+	}
+
+	results := make([]dto.ImportRowResult, len(records))
 	var (
-		limit  = 20
-		offset = 0
-		err    error
+		expenses []*models.Expense
+		indexes  []int
 	)
+	for i, rec := range records {
+		results[i].Line = rec.Line
+		if rec.Skip != "" {
+			results[i].Status = dto.ImportRowSkipped
+			results[i].Errors = map[string]string{"row": rec.Skip}
+			continue
+		}
+		expense, rowErrs := buildImportedExpense(uint(userID), rec)
+		if rowErrs != nil {
+			results[i].Status = dto.ImportRowFailed
+			results[i].Errors = rowErrs
+			continue
+		}
+		results[i].Expense = expense
+		expenses = append(expenses, expense)
+		indexes = append(indexes, i)
+	}
 
-	if limitStr := c.Query("limit"); limitStr != "" {
-		limit, err = strconv.Atoi(limitStr)
-		if err != nil || limit <= 0 {
-			utils.BadRequestResponse(c, "Invalid limit value")
-			return
+	conversionErrs, err := h.service.ImportExpenses(c.Request.Context(), expenses, dryRun)
+	if err != nil {
+		respondOwnerError(c, err)
+		return
+	}
+
+	summary := map[string]int{}
+	for j, i := range indexes {
+		switch {
+		case conversionErrs[j] != nil:
+			results[i].Status = dto.ImportRowFailed
+			results[i].Errors = map[string]string{"Currency": conversionErrs[j].Error()}
+			results[i].Expense = nil
+		case dryRun:
+			results[i].Status = dto.ImportRowValid
+		default:
+			results[i].Status = dto.ImportRowImported
 		}
 	}
+	for _, r := range results {
+		summary[r.Status]++
+	}
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		offset, err = strconv.Atoi(offsetStr)
-		if err != nil || offset < 0 {
-			utils.BadRequestResponse(c, "Invalid offset value")
-			return
+	status := http.StatusOK
+	if !dryRun && summary[dto.ImportRowImported] > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"message": "Import processed",
+		"dry_run": dryRun,
+		"total":   len(results),
+		"summary": summary,
+		"rows":    results,
+	})
+}
+
+// buildImportedExpense validates a parsed row with the same rules as
+// CreateExpenseRequest and turns it into an expense.
+func buildImportedExpense(userID uint, rec importer.Record) (*models.Expense, map[string]string) {
+	amount, err := importer.ParseAmount(rec.Amount)
+	if err != nil {
+		return nil, map[string]string{"Amount": "Amount is not a valid number"}
+	}
+	request := dto.CreateExpenseRequest{
+		UserId:      userID,
+		Amount:      amount,
+		Currency:    strings.ToUpper(rec.Currency),
+		Category:    utils.NormalizeCategory(rec.Category),
+		Description: utils.SanitizeString(rec.Description),
+	}
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		return nil, utils.FormatValidationError(err)
+	}
+
+	expense := &models.Expense{
+		UserID:      request.UserId,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Category:    request.Category,
+		Description: request.Description,
+		Receipt:     rec.Receipt,
+	}
+	if rec.Date != "" {
+		date, err := importer.ParseDate(rec.Date)
+		if err != nil {
+			return nil, map[string]string{"Date": err.Error()}
 		}
+		expense.CreatedAt = date
 	}
+	return expense, nil
+}
 
-	expenses, err := h.service.GetExpenses(c.Request.Context(), filters, offset, limit)
+const maxReceiptFileSize = 10 << 20
+
+func (h *expenseHandler) UploadReceipt(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequestResponse(c, "file is required")
+		return
+	}
+	if fileHeader.Size > maxReceiptFileSize {
+		utils.BadRequestResponse(c, "file exceeds the 10MB limit")
+		return
+	}
+	file, err := fileHeader.Open()
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err)
 		return
 	}
+	defer file.Close()
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":   expenses,
-		"count":  len(expenses),
-		"offset": offset,
-		"limit":  limit,
+	name, hash, err := h.receipts.Save(file)
+	if err != nil {
+		if errors.Is(err, storage.ErrUnsupportedReceipt) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Receipt uploaded successfully",
+		"receipt":      name,
+		"receipt_hash": hash,
 	})
 }
+
+func duplicateWarnings(flags []models.DuplicateFlag) []dto.DuplicateWarning {
+	warnings := make([]dto.DuplicateWarning, len(flags))
+	for i, f := range flags {
+		warnings[i] = dto.DuplicateWarning{
+			DuplicateOf: f.DuplicateOfID,
+			Link:        fmt.Sprintf("/api/expenses/%d", f.DuplicateOfID),
+			Reasons:     f.Reasons,
+			Score:       f.Score,
+		}
+	}
+	return warnings
+}
+
+// respondOwnerError answers a failed create, refusing expenses for unknown
+// and deactivated users.
+func respondOwnerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.NotFoundResponse(c, "User not found")
+	case errors.Is(err, services.ErrUserInactive):
+		utils.ForbiddenResponse(c, "User is deactivated")
+	default:
+		utils.InternalServerErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetExpenseByID(t *testing.T) {
+	tests := []struct {
+		name           string
+		expense        *models.Expense
+		repoErr        error
+		expectedStatus int
+	}{
+		{name: "Found", expense: &models.Expense{BaseModel: models.BaseModel{ID: 3}, UserID: 1, Version: 2}, expectedStatus: http.StatusOK},
+		{name: "SoftDeleted", repoErr: repository.ErrExpenseNotFound, expectedStatus: http.StatusNotFound},
+		{name: "DatabaseDown", repoErr: errors.New("database is down"), expectedStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			expenseRepo := mocks.NewMockExpenseRepository(ctrl)
+			expenseRepo.EXPECT().GetExpenseByID(gomock.Any(), uint(3)).Return(tt.expense, tt.repoErr)
+
+			handler := handlers.NewExpenseHandler(services.NewExpenseService(nil, nil, expenseRepo, nil, nil, nil), nil, nil)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/api/expenses/:id", handler.GetExpenseByID)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/expenses/3", nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && w.Header().Get("ETag") != `"2"` {
+				t.Errorf("expected ETag \"2\", got %q", w.Header().Get("ETag"))
+			}
+		})
+	}
+}
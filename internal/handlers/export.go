@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/export"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+// streamExport writes the export straight to the response. Once the first
+// byte is sent the status can no longer change, so failures mid-stream are
+// only logged and the truncated body is left for the client to detect.
+func streamExport(c *gin.Context, filename string, write func(w export.RowWriter) error) {
+	format := strings.ToLower(c.DefaultQuery("format", export.FormatCSV))
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		utils.BadRequestResponse(c, "format must be one of: csv, xlsx")
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Status(http.StatusOK)
+
+	w, err := export.NewRowWriter(format, c.Writer)
+	if err != nil {
+		log.Printf("failed to start export: %v", err)
+		return
+	}
+	if err := export.WriteExpenseHeader(w); err != nil {
+		log.Printf("failed to write export header: %v", err)
+		return
+	}
+	if err := write(w); err != nil {
+		log.Printf("export aborted: %v", err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("failed to finish export: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+var jobStatuses = map[string]bool{
+	models.JobPending:   true,
+	models.JobRunning:   true,
+	models.JobSucceeded: true,
+	models.JobDead:      true,
+}
+
+type JobHandler interface {
+	ListJobs(c *gin.Context)
+	GetJob(c *gin.Context)
+	Stats(c *gin.Context)
+	RetryJob(c *gin.Context)
+}
+type jobHandler struct {
+	service services.JobService
+}
+
+func NewJobHandler(service services.JobService) JobHandler {
+	return &jobHandler{service: service}
+}
+
+func (h *jobHandler) ListJobs(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !jobStatuses[status] {
+		utils.BadRequestResponse(c, "invalid job status")
+		return
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	jobs, err := h.service.ListJobs(c.Request.Context(), status, c.Query("kind"), offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": jobs, "count": len(jobs), "offset": offset, "limit": limit})
+}
+
+func (h *jobHandler) GetJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid job ID")
+		return
+	}
+	job, err := h.service.GetJob(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			utils.NotFoundResponse(c, "job not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func (h *jobHandler) Stats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context())
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+func (h *jobHandler) RetryJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid job ID")
+		return
+	}
+	job, err := h.service.Retry(c.Request.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrJobNotFound):
+			utils.NotFoundResponse(c, "job not found")
+		case errors.Is(err, services.ErrJobNotRetryable):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Job queued for retry", "data": job})
+}
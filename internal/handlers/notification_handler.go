@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+type NotificationHandler interface {
+	ListNotifications(c *gin.Context)
+	MarkRead(c *gin.Context)
+	MarkUnread(c *gin.Context)
+	MarkAllRead(c *gin.Context)
+	GetPreferences(c *gin.Context)
+	UpdatePreference(c *gin.Context)
+}
+type notificationHandler struct {
+	service services.NotificationService
+}
+
+func NewNotificationHandler(service services.NotificationService) NotificationHandler {
+	return &notificationHandler{service: service}
+}
+
+func (h *notificationHandler) ListNotifications(c *gin.Context) {
+	userID, ok := queryUserID(c)
+	if !ok {
+		return
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	notifications, unread, err := h.service.List(c.Request.Context(), userID, unreadOnly, offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":   notifications,
+		"count":  len(notifications),
+		"unread": unread,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+func (h *notificationHandler) MarkRead(c *gin.Context) {
+	h.setRead(c, true)
+}
+
+func (h *notificationHandler) MarkUnread(c *gin.Context) {
+	h.setRead(c, false)
+}
+
+func (h *notificationHandler) setRead(c *gin.Context, read bool) {
+	userID, ok := queryUserID(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid notification ID")
+		return
+	}
+	if err := h.service.MarkRead(c.Request.Context(), userID, uint(id), read); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			utils.NotFoundResponse(c, "notification not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification updated successfully"})
+}
+
+func (h *notificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := queryUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.MarkAllRead(c.Request.Context(), userID); err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
+}
+
+func (h *notificationHandler) GetPreferences(c *gin.Context) {
+	userID, ok := queryUserID(c)
+	if !ok {
+		return
+	}
+	prefs, err := h.service.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": prefs})
+}
+
+func (h *notificationHandler) UpdatePreference(c *gin.Context) {
+	var request dto.UpdateNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+	pref := models.NotificationPreference{
+		UserID: request.UserID,
+		Event:  request.Event,
+		Email:  *request.Email,
+		InApp:  *request.InApp,
+	}
+	if err := h.service.UpdatePreference(c.Request.Context(), &pref); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownNotificationEvent):
+			utils.BadRequestResponse(c, "unknown notification event")
+		case errors.Is(err, repository.ErrUserNotFound):
+			utils.NotFoundResponse(c, "user not found")
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Preference updated successfully", "data": pref})
+}
+
+func queryUserID(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		utils.BadRequestResponse(c, "invalid user ID")
+		return 0, false
+	}
+	return uint(userID), true
+}
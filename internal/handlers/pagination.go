@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+// parsePagination reads the offset and limit query parameters, writing a
+// 400 response and returning false when either is invalid.
+func parsePagination(c *gin.Context) (offset, limit int, ok bool) {
+	var err error
+	limit = 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			utils.BadRequestResponse(c, "Invalid limit value")
+			return 0, 0, false
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			utils.BadRequestResponse(c, "Invalid offset value")
+			return 0, 0, false
+		}
+	}
+	return offset, limit, true
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/export"
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
@@ -16,15 +21,27 @@ type ReportHandler interface {
 	CreateReport(c *gin.Context)
 	AddExpenseToReport(c *gin.Context)
 	SubmitReport(c *gin.Context)
+	ApproveReport(c *gin.Context)
+	RejectReport(c *gin.Context)
+	GetReport(c *gin.Context)
 	GetReportExpenses(c *gin.Context)
+	ExportReport(c *gin.Context)
+	ReportPDF(c *gin.Context)
+	ListDuplicates(c *gin.Context)
+	DeleteReport(c *gin.Context)
+	RestoreReport(c *gin.Context)
 }
 type reportHandler struct {
 	reportService services.ReportService
+	duplicates    services.DuplicateService
+	receipts      export.ReceiptOpener
 }
 
-func NewReportHandler(reportService services.ReportService) ReportHandler {
+func NewReportHandler(reportService services.ReportService, duplicates services.DuplicateService, receipts export.ReceiptOpener) ReportHandler {
 	return &reportHandler{
 		reportService: reportService,
+		duplicates:    duplicates,
+		receipts:      receipts,
 	}
 }
 
@@ -37,11 +54,21 @@ func (h *reportHandler) CreateReport(c *gin.Context) {
 	}
 	request.Sanitize()
 	report := models.ExpenseReport{
-		UserID: request.UserID,
-		Title:  request.Title,
+		UserID:   request.UserID,
+		Title:    request.Title,
+		Currency: request.Currency,
 	}
 	if err := h.reportService.CreateReport(c.Request.Context(), &report); err != nil {
-		utils.InternalServerErrorResponse(c, err)
+		switch {
+		case errors.Is(err, services.ErrUnsupportedCurrency):
+			utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, repository.ErrUserNotFound):
+			utils.NotFoundResponse(c, "user not found")
+		case errors.Is(err, services.ErrUserInactive):
+			utils.ForbiddenResponse(c, "user is deactivated")
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
 		return
 	}
 
@@ -66,6 +93,9 @@ func (h *reportHandler) AddExpenseToReport(c *gin.Context) {
 		case repository.ErrExpenseNotFound:
 			utils.NotFoundResponse(c, "expense not found")
 			return
+		case services.ErrReportLocked:
+			utils.BadRequestResponse(c, err.Error())
+			return
 		default:
 			utils.InternalServerErrorResponse(c, err)
 			return
@@ -80,22 +110,142 @@ func (h *reportHandler) AddExpenseToReport(c *gin.Context) {
 
 func (h *reportHandler) SubmitReport(c *gin.Context) {
 	reportID := c.GetUint("reportID")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	err := h.reportService.SubmitReport(c.Request.Context(), reportID)
+	err := h.reportService.SubmitReport(c.Request.Context(), reportID, version)
 	if err != nil {
-		switch err {
-		case services.ErrInvalidReportState:
+		switch {
+		case errors.Is(err, services.ErrInvalidReportState):
 			utils.BadRequestResponse(c, "report cannot be submitted in current state")
-			return
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondVersionConflict(c)
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+
+	response := gin.H{"message": "Report submitted successfully"}
+	flags, err := h.duplicates.CheckReport(c.Request.Context(), reportID)
+	if err != nil {
+		log.Printf("duplicate check failed for report %d: %v", reportID, err)
+	} else if len(flags) > 0 {
+		response["warnings"] = duplicateWarnings(flags)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *reportHandler) ApproveReport(c *gin.Context) {
+	h.reviewReport(c, h.reportService.ApproveReport, "Report approved successfully")
+}
+
+func (h *reportHandler) RejectReport(c *gin.Context) {
+	h.reviewReport(c, h.reportService.RejectReport, "Report rejected successfully")
+}
+
+func (h *reportHandler) reviewReport(c *gin.Context, review func(ctx context.Context, reportID, approverID, version uint, reason string) error, message string) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || reportID == 0 {
+		utils.BadRequestResponse(c, "invalid report ID")
+		return
+	}
+	var request dto.ReviewReportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+	request.Sanitize()
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := review(c.Request.Context(), uint(reportID), request.ApproverID, version, request.Reason); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrReportNotFound):
+			utils.NotFoundResponse(c, "report not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondVersionConflict(c)
+		case errors.Is(err, repository.ErrUserNotFound):
+			utils.BadRequestResponse(c, "approver not found")
+		case errors.Is(err, services.ErrReportNotInReview),
+			errors.Is(err, services.ErrInvalidReportState),
+			errors.Is(err, services.ErrReasonRequired):
+			utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, services.ErrSelfReview):
+			utils.ForbiddenResponse(c, err.Error())
 		default:
 			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// GetReport returns the report with its expenses and full status history.
+func (h *reportHandler) GetReport(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || reportID == 0 {
+		utils.BadRequestResponse(c, "invalid report ID")
+		return
+	}
+	report, err := h.reportService.GetReportByID(c.Request.Context(), uint(reportID))
+	if err != nil {
+		if err == repository.ErrReportNotFound {
+			utils.NotFoundResponse(c, "report not found")
 			return
 		}
+		utils.InternalServerErrorResponse(c, err)
+		return
 	}
+	setETag(c, report.Version)
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Report submitted successfully",
-	})
+func (h *reportHandler) DeleteReport(c *gin.Context) {
+	reportID := c.GetUint("reportID")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	if err := h.reportService.DeleteReport(c.Request.Context(), reportID, version); err != nil {
+		switch {
+		case errors.Is(err, services.ErrReportLocked):
+			utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondVersionConflict(c)
+		case errors.Is(err, repository.ErrReportNotFound):
+			utils.NotFoundResponse(c, "report not found")
+		default:
+			utils.InternalServerErrorResponse(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Report deleted successfully"})
+}
+
+// RestoreReport is an admin endpoint that undoes a soft delete.
+func (h *reportHandler) RestoreReport(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || reportID == 0 {
+		utils.BadRequestResponse(c, "invalid report ID")
+		return
+	}
+	report, err := h.reportService.RestoreReport(c.Request.Context(), uint(reportID))
+	if err != nil {
+		if errors.Is(err, repository.ErrReportNotFound) {
+			utils.NotFoundResponse(c, "deleted report not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	setETag(c, report.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Report restored successfully", "data": report})
 }
 
 func (h *reportHandler) GetReportExpenses(c *gin.Context) {
@@ -130,3 +280,75 @@ func (h *reportHandler) GetReportExpenses(c *gin.Context) {
 		"limit":  limit,
 	})
 }
+
+func (h *reportHandler) ExportReport(c *gin.Context) {
+	reportID := c.GetUint("reportID")
+
+	streamExport(c, fmt.Sprintf("report-%d", reportID), func(w export.RowWriter) error {
+		return h.reportService.StreamReportExpenses(c.Request.Context(), reportID, func(e *models.Expense) error {
+			return export.WriteExpense(w, e)
+		})
+	})
+}
+
+func (h *reportHandler) ReportPDF(c *gin.Context) {
+	reportID := c.GetUint("reportID")
+
+	report, err := h.reportService.GetReportByID(c.Request.Context(), reportID)
+	if err != nil {
+		if err == repository.ErrReportNotFound {
+			utils.NotFoundResponse(c, "report not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+
+	// The PDF is written straight to the response rather than copied through
+	// another buffer. Layout errors surface before the first byte is sent.
+	doc := export.ReportDocument{Report: report, History: reportStatusHistory(report)}
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="report-%d.pdf"`, reportID))
+	if err := export.RenderReportPDF(c.Writer, doc, h.receipts); err != nil {
+		if c.Writer.Written() {
+			log.Printf("report %d pdf aborted: %v", reportID, err)
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		utils.InternalServerErrorResponse(c, err)
+	}
+}
+
+func reportStatusHistory(report *models.ExpenseReport) []export.StatusEntry {
+	history := make([]export.StatusEntry, 0, len(report.History))
+	for _, h := range report.History {
+		entry := export.StatusEntry{Status: h.ToStatus, At: h.CreatedAt, Reason: h.Reason}
+		if h.Actor != nil {
+			entry.Actor = h.Actor.Name
+		} else if h.ActorID != nil {
+			entry.Actor = fmt.Sprintf("user #%d", *h.ActorID)
+		}
+		history = append(history, entry)
+	}
+	return history
+}
+
+func (h *reportHandler) ListDuplicates(c *gin.Context) {
+	flags, err := h.duplicates.ListReportFlags(c.Request.Context(), c.GetUint("reportID"))
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  duplicateWarningsByExpense(flags),
+		"count": len(flags),
+	})
+}
+
+func duplicateWarningsByExpense(flags []models.DuplicateFlag) map[uint][]dto.DuplicateWarning {
+	out := map[uint][]dto.DuplicateWarning{}
+	for _, f := range flags {
+		out[f.ExpenseID] = append(out[f.ExpenseID], duplicateWarnings([]models.DuplicateFlag{f})...)
+	}
+	return out
+}
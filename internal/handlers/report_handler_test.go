@@ -0,0 +1,150 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReportPDFAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockUsers      func(repo *mocks.MockUserRepository)
+		expectedStatus int
+	}{
+		{name: "Owner", query: "userID=1", expectedStatus: http.StatusOK},
+		{
+			name:  "Approver",
+			query: "approverID=2",
+			mockUsers: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(2)).Return(&models.User{BaseModel: models.BaseModel{ID: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{name: "UnrelatedUser", query: "userID=3", expectedStatus: http.StatusNotFound},
+		{
+			name:  "UnknownApprover",
+			query: "approverID=4",
+			mockUsers: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(4)).Return(nil, repository.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 9}, UserID: 1, Title: "Trip", Status: models.ReportStatusSubmitted, Currency: "USD"}
+			reportRepo := mocks.NewMockReportRepository(ctrl)
+			reportRepo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(9)).Return(report, nil).AnyTimes()
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			if tt.mockUsers != nil {
+				tt.mockUsers(userRepo)
+			}
+
+			reportService := services.NewReportService(reportRepo, nil, userRepo, nil, nil, nil, nil, nil, "")
+			handler := handlers.NewReportHandler(reportService, nil, nil)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/api/reports/:id/pdf", middleware.ReportReviewerMiddleware(reportRepo, userRepo), handler.ReportPDF)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/reports/9/pdf?"+tt.query, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && w.Header().Get("Content-Type") != "application/pdf" {
+				t.Errorf("expected a PDF, got %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGetReportAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		status         string
+		expectedStatus int
+	}{
+		{name: "Owner", query: "userID=1", status: models.ReportStatusDraft, expectedStatus: http.StatusOK},
+		{name: "UnrelatedUser", query: "userID=3", status: models.ReportStatusSubmitted, expectedStatus: http.StatusNotFound},
+		{name: "ApproverOfDraft", query: "approverID=2", status: models.ReportStatusDraft, expectedStatus: http.StatusNotFound},
+		{name: "NoCaller", status: models.ReportStatusSubmitted, expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 9}, UserID: 1, Status: tt.status, Currency: "USD", Version: 4}
+			reportRepo := mocks.NewMockReportRepository(ctrl)
+			reportRepo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(9)).Return(report, nil).AnyTimes()
+			userRepo := mocks.NewMockUserRepository(ctrl)
+
+			handler := handlers.NewReportHandler(services.NewReportService(reportRepo, nil, userRepo, nil, nil, nil, nil, nil, ""), nil, nil)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/api/reports/:id", middleware.ReportReviewerMiddleware(reportRepo, userRepo), handler.GetReport)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/reports/9?"+tt.query, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && w.Header().Get("ETag") != `"4"` {
+				t.Errorf("expected ETag \"4\", got %q", w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestReportTransitionsRequireIfMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "Submit", path: "/api/reports/9/submit"},
+		{name: "Approve", path: "/api/reports/9/approve", body: `{"approver_id": 2}`},
+		{name: "Reject", path: "/api/reports/9/reject", body: `{"approver_id": 2, "reason": "missing receipts"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The mocks have no expectations: the handler must stop before
+			// reaching the service.
+			reportService := services.NewReportService(mocks.NewMockReportRepository(ctrl), nil, mocks.NewMockUserRepository(ctrl), nil, nil, nil, nil, nil, "")
+			handler := handlers.NewReportHandler(reportService, nil, nil)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			setReport := func(c *gin.Context) { c.Set("reportID", uint(9)) }
+			router.PUT("/api/reports/:id/submit", setReport, handler.SubmitReport)
+			router.PUT("/api/reports/:id/approve", handler.ApproveReport)
+			router.PUT("/api/reports/:id/reject", handler.RejectReport)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusPreconditionRequired {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusPreconditionRequired, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -14,13 +17,21 @@ import (
 type UserHandler interface {
 	CreateUser(c *gin.Context)
 	GetUserByID(c *gin.Context)
+	UpdateUser(c *gin.Context)
+	DeactivateUser(c *gin.Context)
+	ReactivateUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	ListUsers(c *gin.Context)
+	ExportUserData(c *gin.Context)
+	EraseUser(c *gin.Context)
 }
 type userHandler struct {
 	service services.UserService
+	exports services.UserExportService
 }
 
-func NewUserHandler(service services.UserService) UserHandler {
-	return &userHandler{service: service}
+func NewUserHandler(service services.UserService, exports services.UserExportService) UserHandler {
+	return &userHandler{service: service, exports: exports}
 }
 
 func (h *userHandler) CreateUser(c *gin.Context) {
@@ -32,8 +43,9 @@ func (h *userHandler) CreateUser(c *gin.Context) {
 	}
 	request.Sanitize()
 	user := models.User{
-		Email: request.Email,
-		Name:  request.Name,
+		Email:             request.Email,
+		Name:              request.Name,
+		ReportingCurrency: request.ReportingCurrency,
 	}
 	if err := h.service.CreateUser(c.Request.Context(), &user); err != nil {
 		if err == services.ErrEmailAlreadyExists {
@@ -66,3 +78,147 @@ func (h *userHandler) GetUserByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"user": response})
 }
+
+func (h *userHandler) UpdateUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var request dto.UpdateUserRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+	request.Sanitize()
+	user, err := h.service.UpdateUser(c.Request.Context(), id, services.UserUpdate{
+		Name:              request.Name,
+		Email:             request.Email,
+		ReportingCurrency: request.ReportingCurrency,
+	})
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "data": user})
+}
+
+func (h *userHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false, "User deactivated successfully")
+}
+
+func (h *userHandler) ReactivateUser(c *gin.Context) {
+	h.setActive(c, true, "User reactivated successfully")
+}
+
+func (h *userHandler) setActive(c *gin.Context, active bool, message string) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	user, err := h.service.SetActive(c.Request.Context(), id, active)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": user})
+}
+
+func (h *userHandler) DeleteUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteUser(c.Request.Context(), id); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// ExportUserData streams a ZIP of the user's profile, expenses, reports and
+// receipts. As with the other exports, a failure after the first byte is
+// only logged.
+func (h *userHandler) ExportUserData(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.zip"`, id))
+	if err := h.exports.Export(c.Request.Context(), id, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			respondUserError(c, err)
+			return
+		}
+		log.Printf("user %d export aborted: %v", id, err)
+	}
+}
+
+// EraseUser anonymizes the user's personal data. Their expenses and reports
+// are kept.
+func (h *userHandler) EraseUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.EraseUser(c.Request.Context(), id); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User data erased successfully"})
+}
+
+// ListUsers lists users, optionally matching ?search= against name and
+// email and filtering on ?active=true|false.
+func (h *userHandler) ListUsers(c *gin.Context) {
+	var active *bool
+	if activeParam := c.Query("active"); activeParam != "" {
+		value, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid active value")
+			return
+		}
+		active = &value
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	search := utils.SanitizeString(c.Query("search"))
+	users, err := h.service.ListUsers(c.Request.Context(), search, active, offset, limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":   users,
+		"count":  len(users),
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestResponse(c, "invalid user ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.NotFoundResponse(c, "user not found")
+	case errors.Is(err, services.ErrEmailAlreadyExists):
+		utils.DuplicateEntryResponse(c, "email already exists")
+	case errors.Is(err, services.ErrCachePurgeFailed):
+		utils.ServiceUnavailableResponse(c, "user data was erased, but cached copies could not be purged; retry the erasure")
+	default:
+		utils.InternalServerErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+type WebhookHandler interface {
+	CreateSubscription(c *gin.Context)
+	ListSubscriptions(c *gin.Context)
+	DeleteSubscription(c *gin.Context)
+	ListDeliveries(c *gin.Context)
+	ReplayDelivery(c *gin.Context)
+}
+type webhookHandler struct {
+	service services.WebhookService
+}
+
+func NewWebhookHandler(service services.WebhookService) WebhookHandler {
+	return &webhookHandler{service: service}
+}
+
+func (h *webhookHandler) CreateSubscription(c *gin.Context) {
+	var request dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		formatted := utils.FormatValidationError(err)
+		utils.ValidationErrorResponse(c, formatted)
+		return
+	}
+	request.Sanitize()
+	sub := models.WebhookSubscription{
+		URL:        request.URL,
+		EventTypes: request.EventTypes,
+		Secret:     request.Secret,
+	}
+	if err := h.service.CreateSubscription(c.Request.Context(), &sub); err != nil {
+		if errors.Is(err, services.ErrInvalidWebhookURL) || errors.Is(err, services.ErrInvalidWebhookEvent) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook subscription created successfully",
+		"data":    dto.WebhookCreatedResponse{WebhookSubscription: sub, Secret: sub.Secret},
+	})
+}
+
+func (h *webhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": subs, "count": len(subs)})
+}
+
+func (h *webhookHandler) DeleteSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid webhook ID")
+		return
+	}
+	if err := h.service.DeleteSubscription(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			utils.NotFoundResponse(c, "webhook subscription not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook subscription deleted successfully"})
+}
+
+func (h *webhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid webhook ID")
+		return
+	}
+	offset, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), uint(id), offset, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			utils.NotFoundResponse(c, "webhook subscription not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deliveries, "count": len(deliveries), "offset": offset, "limit": limit})
+}
+
+func (h *webhookHandler) ReplayDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequestResponse(c, "invalid delivery ID")
+		return
+	}
+	delivery, err := h.service.Replay(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+			utils.NotFoundResponse(c, "webhook delivery not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Delivery queued for replay", "data": delivery})
+}
@@ -0,0 +1,49 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrNonPublicAddress = errors.New("address is not publicly routable")
+
+// sharedAddressSpace is the carrier-grade NAT range, which IsPrivate does
+// not cover.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddr reports whether addr is a globally routable unicast address,
+// i.e. not loopback, private, link-local (cloud metadata endpoints live
+// there), shared, multicast or unspecified.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// publicOnlyDialer refuses connections to non-public addresses. The check
+// runs on the resolved IP being dialled, so it also catches redirects and
+// DNS answers that changed after a URL was validated.
+func publicOnlyDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !IsPublicAddr(addr) {
+				return fmt.Errorf("dial %s: %w", address, ErrNonPublicAddress)
+			}
+			return nil
+		},
+	}
+}
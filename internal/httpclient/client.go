@@ -0,0 +1,249 @@
+// Package httpclient wraps http.Client for calls to external services with
+// per-attempt timeouts, jittered retries, a circuit breaker per host and
+// metrics published through expvar.
+package httpclient
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type Config struct {
+	// Timeout bounds a single attempt, including reading the body.
+	Timeout time.Duration
+	// MaxRetries is how many times a failed attempt is repeated. Only
+	// requests that can be replayed are retried.
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// FailureThreshold consecutive failures to a host open its breaker for
+	// OpenTimeout, after which a single trial request is let through.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	// PublicOnly refuses to connect to loopback, private and link-local
+	// addresses. Set it for clients calling URLs supplied by users.
+	PublicOnly bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          10 * time.Second,
+		MaxRetries:       3,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       5 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+type Client struct {
+	name     string
+	cfg      Config
+	http     *http.Client
+	metrics  *Metrics
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// New builds a client whose metrics are published as expvar
+// "httpclient.<name>".
+func New(name string, cfg Config) *Client {
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+	if cfg.PublicOnly {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// A proxy would be dialled instead of the target, bypassing the check.
+		transport.Proxy = nil
+		transport.DialContext = publicOnlyDialer().DialContext
+		httpClient.Transport = transport
+	}
+	return &Client{
+		name:     name,
+		cfg:      cfg,
+		http:     httpClient,
+		metrics:  publishMetrics(name),
+		breakers: map[string]*breaker{},
+	}
+}
+
+func (c *Client) Metrics() *Metrics {
+	return c.metrics
+}
+
+// Do sends req, retrying transport errors, 5xx and 429 responses with
+// jittered exponential backoff. The last response is returned as is when
+// retries run out, so callers still see the upstream status.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	b := c.breaker(req.URL.Host)
+	replayable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if !b.allow(time.Now()) {
+			c.metrics.ShortCircuits.Add(1)
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, ErrCircuitOpen)
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		c.metrics.Requests.Add(1)
+		start := time.Now()
+		resp, err := c.http.Do(req)
+		c.metrics.LatencyMs.Add(time.Since(start).Milliseconds())
+
+		if !retryable(resp, err) {
+			b.success()
+			return resp, err
+		}
+		c.metrics.Failures.Add(1)
+		b.failure(time.Now(), c.cfg.FailureThreshold, c.cfg.OpenTimeout)
+
+		if req.Context().Err() != nil || !replayable || attempt >= c.cfg.MaxRetries {
+			return resp, err
+		}
+		wait := c.backoff(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		c.metrics.Retries.Add(1)
+		if !sleepOrDone(wait, req.Context().Done()) {
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff picks a random wait up to the exponential step ("full jitter"),
+// or honours Retry-After when the server sent one.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, c.cfg.MaxBackoff)
+		}
+	}
+	step := c.cfg.BaseBackoff << attempt
+	if step <= 0 || step > c.cfg.MaxBackoff {
+		step = c.cfg.MaxBackoff
+	}
+	if step <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(step) + 1))
+}
+
+func (c *Client) breaker(host string) *breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		b = &breaker{}
+		c.breakers[host] = b
+	}
+	return b
+}
+
+func sleepOrDone(d time.Duration, done <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// breaker is closed while requests succeed, open after too many
+// consecutive failures, and half-open once the open period has passed,
+// letting one trial request decide whether it closes again.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.trial = false
+}
+
+func (b *breaker) failure(now time.Time, threshold int, openFor time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.trial || (threshold > 0 && b.failures >= threshold) {
+		b.openUntil = now.Add(openFor)
+		b.trial = false
+	}
+}
+
+// Metrics counts outbound calls. LatencyMs is the total time spent waiting
+// on attempts; divide by Requests for the mean.
+type Metrics struct {
+	Requests      expvar.Int
+	Failures      expvar.Int
+	Retries       expvar.Int
+	ShortCircuits expvar.Int
+	LatencyMs     expvar.Int
+}
+
+var (
+	metricsMu sync.Mutex
+	published = map[string]*Metrics{}
+)
+
+// publishMetrics returns the metrics for name, registering them with expvar
+// the first time so clients sharing a name also share counters.
+func publishMetrics(name string) *Metrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if m, ok := published[name]; ok {
+		return m
+	}
+	m := &Metrics{}
+	vars := new(expvar.Map)
+	vars.Set("requests", &m.Requests)
+	vars.Set("failures", &m.Failures)
+	vars.Set("retries", &m.Retries)
+	vars.Set("short_circuits", &m.ShortCircuits)
+	vars.Set("latency_ms", &m.LatencyMs)
+	expvar.Publish("httpclient."+name, vars)
+	published[name] = m
+	return m
+}
@@ -0,0 +1,253 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		Timeout:          time.Second,
+		MaxRetries:       3,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: 10,
+		OpenTimeout:      time.Minute,
+	}
+}
+
+// statusServer answers with statuses in turn, repeating the last one.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestDoRetries(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		{name: "SucceedsFirstTime", statuses: []int{http.StatusOK}, expectedStatus: http.StatusOK, expectedCalls: 1},
+		{name: "RetriesServerErrors", statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, expectedStatus: http.StatusOK, expectedCalls: 3},
+		{name: "RetriesTooManyRequests", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, expectedStatus: http.StatusOK, expectedCalls: 2},
+		{name: "ReturnsLastResponseWhenRetriesRunOut", statuses: []int{http.StatusInternalServerError}, expectedStatus: http.StatusInternalServerError, expectedCalls: 4},
+		{name: "ClientErrorsAreFinal", statuses: []int{http.StatusBadRequest}, expectedStatus: http.StatusBadRequest, expectedCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := statusServer(t, tt.statuses...)
+			client := New("test-retries-"+tt.name, testConfig())
+			// Metrics are shared by name for the life of the process.
+			retries := client.Metrics().Retries.Value()
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus || calls.Load() != tt.expectedCalls {
+				t.Errorf("expected %d after %d calls, got %d after %d", tt.expectedStatus, tt.expectedCalls, resp.StatusCode, calls.Load())
+			}
+			if got := client.Metrics().Retries.Value() - retries; got != int64(tt.expectedCalls-1) {
+				t.Errorf("expected %d retries counted, got %d", tt.expectedCalls-1, got)
+			}
+		})
+	}
+}
+
+func TestDoReplaysBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+	client := New("test-replay", testConfig())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Errorf("expected the body sent twice, got %q", bodies)
+	}
+}
+
+func TestDoDoesNotRetryUnreplayableBody(t *testing.T) {
+	server, calls := statusServer(t, http.StatusBadGateway, http.StatusOK)
+	client := New("test-unreplayable", testConfig())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	req.Body = io.NopCloser(strings.NewReader("once"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d calls ending in %d", calls.Load(), resp.StatusCode)
+	}
+}
+
+func TestDoStopsWhenContextIsCancelled(t *testing.T) {
+	server, calls := statusServer(t, http.StatusServiceUnavailable)
+	cfg := testConfig()
+	cfg.BaseBackoff, cfg.MaxBackoff = time.Hour, time.Hour
+	client := New("test-cancel", cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to end the backoff, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call, got %d", calls.Load())
+	}
+}
+
+func TestBackoffHonoursRetryAfter(t *testing.T) {
+	client := &Client{cfg: Config{BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Second}}
+	tests := []struct {
+		retryAfter string
+		expected   time.Duration
+	}{
+		{retryAfter: "2", expected: 2 * time.Second},
+		{retryAfter: "0", expected: 0},
+		{retryAfter: "60", expected: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{"Retry-After": {tt.retryAfter}}}
+		if got := client.backoff(0, resp); got != tt.expected {
+			t.Errorf("Retry-After %s: expected %v, got %v", tt.retryAfter, tt.expected, got)
+		}
+	}
+
+	// A date or garbage falls back to the jittered backoff.
+	resp := &http.Response{Header: http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}}}
+	if got := client.backoff(0, resp); got > time.Millisecond {
+		t.Errorf("expected the exponential step, got %v", got)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	client := &Client{cfg: Config{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}}
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		seen := map[time.Duration]bool{}
+		for i := 0; i < 50; i++ {
+			wait := client.backoff(attempt, nil)
+			if wait < 0 || wait > ceiling {
+				t.Fatalf("attempt %d: wait %v outside [0, %v]", attempt, wait, ceiling)
+			}
+			seen[wait] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("attempt %d: expected jittered waits, got %v", attempt, seen)
+		}
+	}
+	// Shifting far enough to overflow still caps at MaxBackoff.
+	if wait := client.backoff(80, nil); wait < 0 || wait > time.Second {
+		t.Errorf("expected a capped wait, got %v", wait)
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	const threshold, openFor = 3, time.Minute
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &breaker{}
+
+	for i := 0; i < threshold-1; i++ {
+		b.failure(now, threshold, openFor)
+	}
+	if !b.allow(now) {
+		t.Fatal("expected the breaker to stay closed below the threshold")
+	}
+	b.failure(now, threshold, openFor)
+	if b.allow(now.Add(openFor - time.Second)) {
+		t.Fatal("expected the breaker to open at the threshold")
+	}
+
+	// Half-open: one trial only.
+	later := now.Add(openFor)
+	if !b.allow(later) {
+		t.Fatal("expected a trial request once the open period passed")
+	}
+	if b.allow(later) {
+		t.Fatal("expected only one trial request while half-open")
+	}
+
+	// A failed trial reopens straight away, below the threshold.
+	b.failure(later, threshold, openFor)
+	if b.allow(later.Add(time.Second)) {
+		t.Fatal("expected a failed trial to reopen the breaker")
+	}
+
+	// A successful trial closes it.
+	later = later.Add(openFor)
+	if !b.allow(later) {
+		t.Fatal("expected a second trial")
+	}
+	b.success()
+	if !b.allow(later) || !b.allow(later) {
+		t.Fatal("expected the breaker to close after a successful trial")
+	}
+}
+
+func TestDoShortCircuitsOpenBreaker(t *testing.T) {
+	server, calls := statusServer(t, http.StatusInternalServerError)
+	cfg := testConfig()
+	cfg.MaxRetries = 0
+	cfg.FailureThreshold = 2
+	cfg.OpenTimeout = 50 * time.Millisecond
+	client := New("test-breaker", cfg)
+	shortCircuits := client.Metrics().ShortCircuits.Value()
+
+	do := func() (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := do(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := do(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := client.Metrics().ShortCircuits.Value() - shortCircuits; calls.Load() != 2 || got != 1 {
+		t.Errorf("expected 2 calls and 1 short circuit, got %d and %d", calls.Load(), got)
+	}
+
+	time.Sleep(cfg.OpenTimeout)
+	if _, err := do(); err != nil {
+		t.Fatalf("expected a trial request, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected the trial to reach the server, got %d calls", calls.Load())
+	}
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// defaultColumns lists the header names recognised for each field when no
+// explicit mapping is supplied.
+var defaultColumns = map[string][]string{
+	"amount":      {"amount", "value", "total"},
+	"currency":    {"currency", "ccy", "currency_code"},
+	"category":    {"category", "type"},
+	"description": {"description", "memo", "merchant", "payee", "details"},
+	"date":        {"date", "transaction_date", "posted", "created_at"},
+	"receipt":     {"receipt", "receipt_url"},
+}
+
+func parseCSV(r io.Reader, mapping map[string]string) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("csv file is empty")
+		}
+		return nil, err
+	}
+	columns := resolveColumns(header, mapping)
+	if _, ok := columns["amount"]; !ok {
+		return nil, errors.New("csv header has no amount column")
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlank(row) {
+			continue
+		}
+		get := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		records = append(records, Record{
+			Line:        line,
+			Amount:      get("amount"),
+			Currency:    strings.ToUpper(get("currency")),
+			Category:    strings.ToLower(get("category")),
+			Description: get("description"),
+			Date:        get("date"),
+			Receipt:     get("receipt"),
+		})
+	}
+	return records, nil
+}
+
+func resolveColumns(header []string, mapping map[string]string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+
+	columns := map[string]int{}
+	for field, aliases := range defaultColumns {
+		if name, ok := mapping[field]; ok {
+			if i, found := index[strings.ToLower(strings.TrimSpace(name))]; found {
+				columns[field] = i
+			}
+			continue
+		}
+		for _, alias := range aliases {
+			if i, found := index[alias]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported import format")
+
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+)
+
+// Record is one parsed line of an import file before validation. Values are
+// kept as strings so the caller can report exactly what was in the file.
+type Record struct {
+	Line        int
+	Amount      string
+	Currency    string
+	Category    string
+	Description string
+	Date        string
+	Receipt     string
+	// Skip is set when the row is intentionally ignored, e.g. a statement credit.
+	Skip string
+}
+
+type Options struct {
+	// Mapping maps expense fields (amount, currency, ...) to CSV header names.
+	Mapping map[string]string
+	// Currency and Category fill in values statement formats do not carry.
+	Currency string
+	Category string
+}
+
+func Parse(format string, r io.Reader, opts Options) ([]Record, error) {
+	var (
+		records []Record
+		err     error
+	)
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		records, err = parseCSV(r, opts.Mapping)
+	case FormatOFX:
+		records, err = parseOFX(r)
+	case FormatQIF:
+		records, err = parseQIF(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if records[i].Currency == "" {
+			records[i].Currency = opts.Currency
+		}
+		if records[i].Category == "" {
+			records[i].Category = opts.Category
+		}
+	}
+	return records, nil
+}
+
+// DetectFormat guesses the format from the uploaded file name.
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".qif":
+		return FormatQIF
+	default:
+		return FormatCSV
+	}
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04:05Z07:00",
+	"20060102150405",
+	"20060102",
+	"01/02/2006",
+	"01/02/06",
+	"1/2/2006",
+	"1/2/06",
+	"02.01.2006",
+}
+
+// ParseDate understands the date formats found in CSV exports and OFX/QIF
+// statements. OFX timestamps may carry a trailing "[tz]" suffix and
+// fractional seconds, both of which are ignored.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if isOFXTimestamp(s) {
+		if i := strings.IndexAny(s, "[."); i > 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.ReplaceAll(s, "'", "/")
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognized date: " + s)
+}
+
+func isOFXTimestamp(s string) bool {
+	if len(s) < 8 {
+		return false
+	}
+	for _, r := range s[:8] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
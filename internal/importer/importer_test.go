@@ -0,0 +1,368 @@
+package importer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/importer"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "12", want: 12},
+		{in: "-12.00", want: -12},
+		{in: "  42.5 ", want: 42.5},
+		{in: "$1,234.50", want: 1234.5},
+		{in: "1,234,567.89", want: 1234567.89},
+		{in: "1.234,50", want: 1234.5},
+		{in: "1.234.567,89 €", want: 1234567.89},
+		{in: "12,50", want: 12.5},
+		{in: "12,5", want: 12.5},
+		{in: "1,234", want: 1234},
+		{in: "1.234.567", want: 1234567},
+		{in: "1 234,50", want: 1234.5},
+		{in: "1\u00a0234,50", want: 1234.5},
+		{in: "1'234.50", want: 1234.5},
+		{in: "£99.99", want: 99.99},
+		{in: "₦5000", want: 5000},
+		{in: "(12.00)", want: -12},
+		{in: "", wantErr: true},
+		{in: "   ", wantErr: true},
+		{in: "$", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "12.34.56,7.8", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "Inf", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := importer.ParseAmount(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	day := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2026-03-04", want: day},
+		{in: " 2026-03-04 ", want: day},
+		{in: "2026-03-04T10:30:00Z", want: day.Add(10*time.Hour + 30*time.Minute)},
+		{in: "20260304", want: day},
+		{in: "20260304103000", want: day.Add(10*time.Hour + 30*time.Minute)},
+		{in: "20260304103000.000", want: day.Add(10*time.Hour + 30*time.Minute)},
+		{in: "20260304103000.000[-5:EST]", want: day.Add(10*time.Hour + 30*time.Minute)},
+		{in: "20260304[0:GMT]", want: day},
+		{in: "03/04/2026", want: day},
+		{in: "03/04/26", want: day},
+		{in: "3/4/2026", want: day},
+		{in: "3/4/26", want: day},
+		{in: "3/4'26", want: day},
+		{in: "04.03.2026", want: day},
+		{in: "", wantErr: true},
+		{in: "yesterday", wantErr: true},
+		{in: "13/04/2026", wantErr: true},
+		{in: "2026-02-30", wantErr: true},
+		{in: "2026/03/04", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := importer.ParseDate(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"statement.OFX": importer.FormatOFX,
+		"bank.qfx":      importer.FormatOFX,
+		"export.qif":    importer.FormatQIF,
+		"expenses.csv":  importer.FormatCSV,
+		"noextension":   importer.FormatCSV,
+	} {
+		if got := importer.DetectFormat(name); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestParseUnsupportedFormat(t *testing.T) {
+	if _, err := importer.Parse("xls", strings.NewReader(""), importer.Options{}); !errors.Is(err, importer.ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    importer.Options
+		want    []importer.Record
+		wantErr string
+	}{
+		{
+			name:  "DefaultHeaders",
+			input: "Amount,Currency,Category,Description,Date,Receipt\n12.50,usd,Meals,Lunch,2026-03-04,r.png\n",
+			want: []importer.Record{
+				{Line: 2, Amount: "12.50", Currency: "USD", Category: "meals", Description: "Lunch", Date: "2026-03-04", Receipt: "r.png"},
+			},
+		},
+		{
+			name:  "AliasesBOMAndWhitespace",
+			input: "\ufeffvalue, ccy , memo,posted\n 9 , eur ,  Taxi ride , 03/04/2026 \n",
+			want: []importer.Record{
+				{Line: 2, Amount: "9", Currency: "EUR", Description: "Taxi ride", Date: "03/04/2026"},
+			},
+		},
+		{
+			name:  "ExplicitMapping",
+			input: "Betrag,Beschreibung,amount\n\"1.234,50\",Hotel,ignored\n",
+			opts:  importer.Options{Mapping: map[string]string{"amount": "Betrag", "description": "beschreibung"}},
+			want: []importer.Record{
+				{Line: 2, Amount: "1.234,50", Description: "Hotel"},
+			},
+		},
+		{
+			name:  "DefaultsFillMissingValues",
+			input: "amount,currency\n5,\n6,GBP\n",
+			opts:  importer.Options{Currency: "NGN", Category: "travel"},
+			want: []importer.Record{
+				{Line: 2, Amount: "5", Currency: "NGN", Category: "travel"},
+				{Line: 3, Amount: "6", Currency: "GBP", Category: "travel"},
+			},
+		},
+		{
+			name:  "QuotedFieldsWithCommasAndNewlines",
+			input: "amount,description\n\"1,000.00\",\"Dinner, drinks\"\n7,\"multi\nline\"\n",
+			want: []importer.Record{
+				{Line: 2, Amount: "1,000.00", Description: "Dinner, drinks"},
+				{Line: 3, Amount: "7", Description: "multi\nline"},
+			},
+		},
+		{
+			name:  "BlankAndShortRows",
+			input: "amount,currency,description\n\n , ,\n3\n4,usd,Extra,unexpected\n",
+			want: []importer.Record{
+				{Line: 4, Amount: "3"},
+				{Line: 5, Amount: "4", Currency: "USD", Description: "Extra"},
+			},
+		},
+		{
+			name:    "UnterminatedQuote",
+			input:   "amount,description\n5,\"never closed\n",
+			wantErr: "quote",
+		},
+		{
+			name:    "StrayQuote",
+			input:   "amount,description\n5,bad\"quote\n",
+			wantErr: "quote",
+		},
+		{
+			name:    "NoAmountColumn",
+			input:   "price,description\n5,x\n",
+			wantErr: "no amount column",
+		},
+		{
+			name:    "MappingToMissingColumn",
+			input:   "amount,description\n5,x\n",
+			opts:    importer.Options{Mapping: map[string]string{"amount": "price"}},
+			wantErr: "no amount column",
+		},
+		{
+			name:    "Empty",
+			input:   "",
+			wantErr: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importer.Parse(importer.FormatCSV, strings.NewReader(tt.input), tt.opts)
+			checkRecords(t, got, err, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func TestParseOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>eur
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260304120000.000[-5:EST]
+<TRNAMT>-1.234,50
+<NAME>HOTEL ADLON
+<MEMO>Room 12
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20260305
+<TRNAMT>100.00
+<NAME>REFUND
+</STMTTRN>
+<STMTTRN>
+<DTPOSTED>20260306
+<TRNAMT>-20
+<NAME>TAXI
+<MEMO>TAXI
+<CURRENCY><CURSYM>usd</CURRENCY>
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+	xml := `<?xml version="1.0"?><OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>GBP</CURDEF>
+<STMTTRN><DTPOSTED>20260304</DTPOSTED><TRNAMT>-9.99</TRNAMT><NAME>Coffee</NAME></STMTTRN>
+<STMTTRN><DTPOSTED>20260305</DTPOSTED><TRNAMT>oops</TRNAMT><NAME>Broken</NAME></STMTTRN>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    importer.Options
+		want    []importer.Record
+		wantErr string
+	}{
+		{
+			name:  "SGML",
+			input: sgml,
+			opts:  importer.Options{Category: "travel"},
+			want: []importer.Record{
+				{Line: 8, Amount: "1234.5", Currency: "EUR", Category: "travel", Description: "HOTEL ADLON - Room 12", Date: "20260304120000.000[-5:EST]"},
+				{Line: 15, Amount: "100.00", Currency: "EUR", Category: "travel", Description: "REFUND", Date: "20260305", Skip: "credit transaction"},
+				{Line: 21, Amount: "20", Currency: "USD", Category: "travel", Description: "TAXI", Date: "20260306"},
+			},
+		},
+		{
+			name:  "XMLWithUnparseableAmount",
+			input: xml,
+			want: []importer.Record{
+				{Line: 2, Amount: "9.99", Currency: "GBP", Description: "Coffee", Date: "20260304"},
+				{Line: 3, Amount: "oops", Currency: "GBP", Description: "Broken", Date: "20260305"},
+			},
+		},
+		{
+			name:  "NoTransactions",
+			input: "<OFX><CURDEF>USD</OFX>",
+		},
+		{
+			name:    "NotOFX",
+			input:   "amount,description\n1,x\n",
+			wantErr: "not an OFX statement",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importer.Parse(importer.FormatOFX, strings.NewReader(tt.input), tt.opts)
+			checkRecords(t, got, err, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func TestParseQIF(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  importer.Options
+		want  []importer.Record
+	}{
+		{
+			name: "Transactions",
+			input: "!Type:Bank\r\nD3/4'26\r\nT-1,234.50\r\nPHotel\r\nMTwo nights\r\nLTravel\r\n^\r\n" +
+				"D03/05/2026\nU25.00\nPRefund\n^\n",
+			opts: importer.Options{Currency: "USD", Category: "other"},
+			want: []importer.Record{
+				{Line: 2, Amount: "1234.5", Currency: "USD", Category: "travel", Description: "Hotel - Two nights", Date: "3/4'26"},
+				{Line: 8, Amount: "25.00", Currency: "USD", Category: "other", Description: "Refund", Date: "03/05/2026", Skip: "credit transaction"},
+			},
+		},
+		{
+			name:  "MissingTrailingCaret",
+			input: "!Type:CCard\nD2026-03-04\nT-5\nPSnack",
+			want: []importer.Record{
+				{Line: 2, Amount: "5", Description: "Snack", Date: "2026-03-04"},
+			},
+		},
+		{
+			name:  "MalformedAmountIsKept",
+			input: "D2026-03-04\nTtwelve\n^\n",
+			want: []importer.Record{
+				{Line: 1, Amount: "twelve", Date: "2026-03-04"},
+			},
+		},
+		{
+			name:  "UnknownCodesAndBlankLinesIgnored",
+			input: "\n!Account\nNChecking\nD2026-03-04\n\nT-3\nXunknown\n^\n",
+			want: []importer.Record{
+				{Line: 3, Amount: "3", Date: "2026-03-04"},
+			},
+		},
+		{
+			name:  "HeaderOnly",
+			input: "!Type:Bank\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importer.Parse(importer.FormatQIF, strings.NewReader(tt.input), tt.opts)
+			checkRecords(t, got, err, tt.want, "")
+		})
+	}
+}
+
+func checkRecords(t *testing.T, got []importer.Record, err error, want []importer.Record, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("expected error containing %q, got %v", wantErr, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d records, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d:\nexpected %+v\ngot      %+v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// parseOFX reads both SGML (OFX 1.x, unclosed leaf tags) and XML (OFX 2.x)
+// statements. Only debits are imported; credits are returned as skipped rows
+// so the result report still accounts for every transaction.
+func parseOFX(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body := string(data)
+	if !strings.Contains(strings.ToUpper(body), "<OFX>") {
+		return nil, errors.New("file is not an OFX statement")
+	}
+
+	var (
+		records  []Record
+		current  *Record
+		currency string
+	)
+	for _, m := range ofxTag.FindAllStringSubmatchIndex(body, -1) {
+		closing := body[m[2]:m[3]] == "/"
+		tag := strings.ToUpper(body[m[4]:m[5]])
+		value := strings.TrimSpace(body[m[6]:m[7]])
+
+		switch {
+		case tag == "CURDEF" && !closing:
+			currency = strings.ToUpper(value)
+		case tag == "STMTTRN" && !closing:
+			current = &Record{Line: strings.Count(body[:m[0]], "\n") + 1}
+		case tag == "STMTTRN" && closing && current != nil:
+			records = append(records, finishStatementRecord(*current, currency))
+			current = nil
+		case current != nil && !closing:
+			switch tag {
+			case "TRNAMT":
+				current.Amount = value
+			case "DTPOSTED":
+				current.Date = value
+			case "NAME":
+				current.Description = joinDescription(value, current.Description)
+			case "MEMO":
+				current.Description = joinDescription(current.Description, value)
+			case "CURSYM":
+				current.Currency = strings.ToUpper(value)
+			}
+		}
+	}
+	return records, nil
+}
+
+// parseQIF reads Quicken Interchange Format files. QIF carries no currency,
+// so rows rely on the Options.Currency default.
+func parseQIF(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	var (
+		records []Record
+		current Record
+		started bool
+		line    int
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "!") {
+			continue
+		}
+		if !started {
+			current = Record{Line: line}
+			started = true
+		}
+		code, value := text[0], strings.TrimSpace(text[1:])
+		switch code {
+		case 'D':
+			current.Date = value
+		case 'T', 'U':
+			current.Amount = value
+		case 'P':
+			current.Description = joinDescription(value, current.Description)
+		case 'M':
+			current.Description = joinDescription(current.Description, value)
+		case 'L':
+			current.Category = strings.ToLower(value)
+		case '^':
+			records = append(records, finishStatementRecord(current, ""))
+			started = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if started {
+		records = append(records, finishStatementRecord(current, ""))
+	}
+	return records, nil
+}
+
+func finishStatementRecord(rec Record, currency string) Record {
+	if rec.Currency == "" {
+		rec.Currency = currency
+	}
+	amount, err := ParseAmount(rec.Amount)
+	if err != nil {
+		return rec
+	}
+	if amount >= 0 {
+		rec.Skip = "credit transaction"
+		return rec
+	}
+	rec.Amount = strconv.FormatFloat(-amount, 'f', -1, 64)
+	return rec
+}
+
+func joinDescription(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "" || a == b:
+		return a
+	default:
+		return a + " - " + b
+	}
+}
+
+// ParseAmount accepts amounts with thousands separators, a currency symbol
+// and either decimal separator, e.g. "$1,234.50", "1.234,50 €", "12,50" or
+// "(12.00)" for a negative amount. A lone comma followed by three digits is
+// read as a thousands separator.
+func ParseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "'", "", "$", "", "€", "", "£", "", "₦", "").Replace(s)
+	if s == "" {
+		return 0, errors.New("amount is empty")
+	}
+	amount, err := strconv.ParseFloat(normalizeDecimal(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, errors.New("amount is not a number: " + s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// normalizeDecimal rewrites s to use "." as the decimal separator and no
+// grouping separators.
+func normalizeDecimal(s string) string {
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case comma > dot && dot >= 0:
+		// 1.234,50
+		return strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+	case comma >= 0 && dot < 0 && strings.Count(s, ",") == 1 && len(s)-comma-1 <= 2:
+		// 12,50
+		return strings.Replace(s, ",", ".", 1)
+	case comma < 0 && strings.Count(s, ".") > 1:
+		// 1.234.567
+		return strings.ReplaceAll(s, ".", "")
+	default:
+		return strings.ReplaceAll(s, ",", "")
+	}
+}
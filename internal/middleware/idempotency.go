@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the body read into memory for hashing.
+	maxIdempotentBodySize = 1 << 20
+)
+
+// IdempotencyMiddleware makes POST and PUT requests sent with an
+// Idempotency-Key header safe to retry. The first request with a key runs
+// and its response is stored; a retry with the same key and request gets the
+// stored response back with "Idempotent-Replayed: true", and reusing the key
+// for a different request is rejected with 422. Keys are scoped to the actor
+// sending them. Server errors are not stored, so the request can be retried
+// with the same key. Multipart uploads are not covered, since their bodies
+// are too large to hold in memory.
+func IdempotencyMiddleware(service services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || (c.Request.Method != http.MethodPost && c.Request.Method != http.MethodPut) ||
+			strings.HasPrefix(c.ContentType(), "multipart/") {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.BadRequestResponse(c, "Idempotency-Key must be at most 255 characters")
+			c.Abort()
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			utils.BadRequestResponse(c, "invalid request body")
+			c.Abort()
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request_too_large", "message": "requests with an Idempotency-Key must be at most 1 MiB"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(c.Request, body)
+		key = fmt.Sprintf("%d:%s", utils.ActorIDFromContext(c.Request.Context()), key)
+
+		stored, err := service.Begin(c.Request.Context(), key, hash)
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency_key_reused", "message": err.Error()})
+			c.Abort()
+			return
+		case errors.Is(err, services.ErrIdempotencyInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": "idempotency_key_in_progress", "message": err.Error()})
+			c.Abort()
+			return
+		case err != nil:
+			utils.InternalServerErrorResponse(c, err)
+			c.Abort()
+			return
+		case stored != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// Finish even if the client has gone away, so its retry is replayed.
+		ctx := context.WithoutCancel(c.Request.Context())
+		// Release the key unless a response was stored, including when a
+		// handler panics, so the request can be retried.
+		completed := false
+		defer func() {
+			if !completed {
+				service.Release(ctx, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		err = service.Complete(ctx, &models.IdempotencyKey{
+			Key:          key,
+			RequestHash:  hash,
+			StatusCode:   status,
+			ContentType:  recorder.Header().Get("Content-Type"),
+			ResponseBody: recorder.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("failed to store response for idempotency key %q: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// requestHash identifies a request by its method, path, query, the headers
+// that change what it does, and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	for _, name := range []string{"X-Actor-ID", "If-Match"} {
+		h.Write([]byte(name + ": " + r.Header.Get(name) + "\n"))
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body as it is written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,194 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+// memIdempotency is an in-memory IdempotencyService.
+type memIdempotency struct {
+	mu   sync.Mutex
+	keys map[string]*models.IdempotencyKey
+}
+
+func newMemIdempotency() *memIdempotency {
+	return &memIdempotency{keys: map[string]*models.IdempotencyKey{}}
+}
+
+func (m *memIdempotency) Begin(_ context.Context, key, requestHash string) (*models.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.keys[key]
+	switch {
+	case !ok:
+		m.keys[key] = &models.IdempotencyKey{Key: key, RequestHash: requestHash}
+		return nil, nil
+	case record.RequestHash != requestHash:
+		return nil, services.ErrIdempotencyKeyReused
+	case !record.Completed():
+		return nil, services.ErrIdempotencyInProgress
+	}
+	return record, nil
+}
+
+func (m *memIdempotency) Complete(_ context.Context, record *models.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[record.Key] = record
+	return nil
+}
+
+func (m *memIdempotency) Release(_ context.Context, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key)
+}
+
+func (m *memIdempotency) PurgeExpired(context.Context) (int64, error) { return 0, nil }
+
+type idempotentRequest struct {
+	body        string
+	contentType string
+	actor       string
+	ifMatch     string
+	panics      bool
+}
+
+func newIdempotencyRouter(service services.IdempotencyService, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery(), middleware.RequestContextMiddleware(), middleware.IdempotencyMiddleware(service))
+	router.POST("/things", func(c *gin.Context) {
+		*calls++
+		if c.GetHeader("X-Test-Panic") != "" {
+			panic("handler failed")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": *calls})
+	})
+	return router
+}
+
+func send(router *gin.Engine, req idempotentRequest) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/things", bytes.NewBufferString(req.body))
+	r.Header.Set("Idempotency-Key", "key-1")
+	contentType := req.contentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	r.Header.Set("Content-Type", contentType)
+	if req.actor != "" {
+		r.Header.Set("X-Actor-ID", req.actor)
+	}
+	if req.ifMatch != "" {
+		r.Header.Set("If-Match", req.ifMatch)
+	}
+	if req.panics {
+		r.Header.Set("X-Test-Panic", "true")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		first          idempotentRequest
+		retry          idempotentRequest
+		expectedStatus int
+		expectedCalls  int
+		replayed       bool
+	}{
+		{
+			name:           "ReplaysRetry",
+			first:          idempotentRequest{body: `{"a":1}`, actor: "7"},
+			retry:          idempotentRequest{body: `{"a":1}`, actor: "7"},
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  1,
+			replayed:       true,
+		},
+		{
+			name:           "RejectsDifferentBody",
+			first:          idempotentRequest{body: `{"a":1}`, actor: "7"},
+			retry:          idempotentRequest{body: `{"a":2}`, actor: "7"},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCalls:  1,
+		},
+		{
+			name:           "RejectsDifferentIfMatch",
+			first:          idempotentRequest{body: `{}`, actor: "7", ifMatch: `"1"`},
+			retry:          idempotentRequest{body: `{}`, actor: "7", ifMatch: `"2"`},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCalls:  1,
+		},
+		{
+			name:           "ScopesKeysByActor",
+			first:          idempotentRequest{body: `{}`, actor: "7"},
+			retry:          idempotentRequest{body: `{}`, actor: "8"},
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  2,
+		},
+		{
+			name:           "SkipsMultipart",
+			first:          idempotentRequest{body: "--x--", contentType: "multipart/form-data; boundary=x"},
+			retry:          idempotentRequest{body: "--x--", contentType: "multipart/form-data; boundary=x"},
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			router := newIdempotencyRouter(newMemIdempotency(), &calls)
+			if w := send(router, tt.first); w.Code != http.StatusCreated {
+				t.Fatalf("expected the first request to run, got %d", w.Code)
+			}
+			w := send(router, tt.retry)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d handler calls, got %d", tt.expectedCalls, calls)
+			}
+			if got := w.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+				t.Errorf("expected replayed %v, got %v", tt.replayed, got)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	calls := 0
+	service := newMemIdempotency()
+	router := newIdempotencyRouter(service, &calls)
+
+	if w := send(router, idempotentRequest{body: `{}`, panics: true}); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the panic to be recovered as 500, got %d", w.Code)
+	}
+	if len(service.keys) != 0 {
+		t.Fatalf("expected the key to be released, got %v", service.keys)
+	}
+	if w := send(router, idempotentRequest{body: `{}`}); w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected the retry to run, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsLargeBodies(t *testing.T) {
+	calls := 0
+	router := newIdempotencyRouter(newMemIdempotency(), &calls)
+
+	w := send(router, idempotentRequest{body: strings.Repeat("x", 1<<20+1)})
+	if w.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Errorf("expected 413 without running the handler, got %d after %d calls", w.Code, calls)
+	}
+}
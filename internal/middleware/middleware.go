@@ -1,16 +1,46 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
 	"net/http"
 
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/onunkwor/flypro-assestment-v2/internal/dto"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
 )
 
+// RequestContextMiddleware attaches a request ID and the acting user to the
+// request context so the service layer can attribute what it records. The
+// ID is taken from X-Request-ID when present and echoed back in the response.
+func RequestContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > 64 {
+			var b [16]byte
+			_, _ = rand.Read(b[:])
+			requestID = hex.EncodeToString(b[:])
+		}
+		c.Header("X-Request-ID", requestID)
+
+		ctx := utils.WithRequestID(c.Request.Context(), requestID)
+		if actor, err := strconv.ParseUint(c.GetHeader("X-Actor-ID"), 10, 64); err == nil && actor > 0 {
+			ctx = utils.WithActorID(ctx, uint(actor))
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
 func ReportOwnershipMiddleware(reportRepo repository.ReportRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr := c.Query("userID")
@@ -48,6 +78,57 @@ func ReportOwnershipMiddleware(reportRepo repository.ReportRepository) gin.Handl
 	}
 }
 
+// ReportReviewerMiddleware lets the report owner (?userID=) through, or an
+// approver (?approverID=) once the report has been submitted for review.
+// Approvers must be existing users other than the owner, the same rule
+// approve and reject apply. Anyone else is told the report does not exist,
+// so report IDs cannot be probed.
+func ReportReviewerMiddleware(reportRepo repository.ReportRepository, userRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil || reportID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report ID"})
+			c.Abort()
+			return
+		}
+		userID, userErr := strconv.ParseUint(c.Query("userID"), 10, 64)
+		approverID, approverErr := strconv.ParseUint(c.Query("approverID"), 10, 64)
+		if (userErr != nil || userID == 0) && (approverErr != nil || approverID == 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "userID or approverID is required"})
+			c.Abort()
+			return
+		}
+
+		report, err := reportRepo.GetExpenseReportByID(c.Request.Context(), uint(reportID))
+		if err != nil {
+			if errors.Is(err, repository.ErrReportNotFound) {
+				utils.NotFoundResponse(c, "report not found")
+			} else {
+				utils.InternalServerErrorResponse(c, err)
+			}
+			c.Abort()
+			return
+		}
+
+		allowed := false
+		switch {
+		case userErr == nil && userID != 0:
+			allowed = report.UserID == uint(userID)
+		case report.UserID != uint(approverID) && report.Status != models.ReportStatusDraft:
+			_, err := userRepo.GetUserByID(c.Request.Context(), uint(approverID))
+			allowed = err == nil
+		}
+		if !allowed {
+			utils.NotFoundResponse(c, "report not found")
+			c.Abort()
+			return
+		}
+
+		c.Set("reportID", uint(reportID))
+		c.Next()
+	}
+}
+
 func ExpenseOwnershipMiddleware(expenseRepo repository.ExpenseRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDIf, exists := c.Get("userID")
@@ -87,3 +168,48 @@ func ExpenseOwnershipMiddleware(expenseRepo repository.ExpenseRepository) gin.Ha
 		c.Next()
 	}
 }
+
+// CardSignatureMiddleware verifies the issuer's HMAC-SHA256 signature sent
+// as "X-Card-Signature: sha256=<hex>" over the raw request body.
+func CardSignatureMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "card webhook secret not configured"})
+			c.Abort()
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil {
+			utils.BadRequestResponse(c, "invalid request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !utils.VerifyHMAC(secret, body, c.GetHeader("X-Card-Signature")) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminTokenMiddleware guards the admin API with a shared bearer token. With
+// no token configured every request is rejected.
+func AdminTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "admin token not configured"})
+			c.Abort()
+			return
+		}
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEvent is an append-only record of a state change. Hash covers the
+// event contents and PrevHash, chaining every event to the one before it.
+type AuditEvent struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	ActorID    *uint                  `json:"actor_id"`
+	Action     string                 `json:"action" gorm:"not null"`
+	EntityType string                 `json:"entity_type" gorm:"not null"`
+	EntityID   uint                   `json:"entity_id" gorm:"not null"`
+	Changes    map[string]AuditChange `json:"changes" gorm:"serializer:json"`
+	RequestID  string                 `json:"request_id"`
+	PrevHash   string                 `json:"prev_hash" gorm:"not null"`
+	Hash       string                 `json:"hash" gorm:"not null"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	CardTransactionMatched   = "matched"
+	CardTransactionCreated   = "created"
+	CardTransactionUnmatched = "unmatched"
+)
+
+type CorporateCard struct {
+	BaseModel
+	UserID    uint   `json:"user_id" gorm:"not null"`
+	CardToken string `json:"card_token" gorm:"uniqueIndex;not null"`
+	Last4     string `json:"last4"`
+	User      *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+type CardTransaction struct {
+	BaseModel
+	ExternalID   string    `json:"external_id" gorm:"uniqueIndex;not null"`
+	CardID       uint      `json:"card_id" gorm:"not null"`
+	UserID       uint      `json:"user_id" gorm:"not null"`
+	Amount       float64   `json:"amount" gorm:"not null"`
+	Currency     string    `json:"currency" gorm:"not null"`
+	Merchant     string    `json:"merchant"`
+	Category     string    `json:"category"`
+	TransactedAt time.Time `json:"transacted_at"`
+	Status       string    `json:"status" gorm:"default:'unmatched'"`
+	ExpenseID    *uint     `json:"expense_id"`
+	Expense      *Expense  `json:"expense,omitempty" gorm:"foreignKey:ExpenseID"`
+}
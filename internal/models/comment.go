@@ -0,0 +1,13 @@
+package models
+
+// Comment belongs to either a report or a single expense. Mentions holds the
+// IDs of users referenced as @email in the body.
+type Comment struct {
+	BaseModel
+	ReportID  *uint  `json:"report_id,omitempty"`
+	ExpenseID *uint  `json:"expense_id,omitempty"`
+	AuthorID  uint   `json:"author_id" gorm:"not null"`
+	Body      string `json:"body" gorm:"not null"`
+	Mentions  []uint `json:"mentions" gorm:"serializer:json"`
+	Author    *User  `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+}
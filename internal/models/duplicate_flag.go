@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type DuplicateFlag struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ExpenseID     uint      `json:"expense_id" gorm:"not null"`
+	DuplicateOfID uint      `json:"duplicate_of_id" gorm:"not null"`
+	Reasons       []string  `json:"reasons" gorm:"serializer:json"`
+	Score         float64   `json:"score"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (DuplicateFlag) TableName() string {
+	return "expense_duplicate_flags"
+}
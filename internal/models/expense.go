@@ -1,5 +1,7 @@
 package models
 
+import "gorm.io/gorm"
+
 type Expense struct {
 	BaseModel
 	UserID       uint    `json:"user_id" gorm:"not null"`
@@ -10,6 +12,12 @@ type Expense struct {
 	Category     string  `json:"category" gorm:"not null"`
 	Description  string  `json:"description"`
 	Receipt      string  `json:"receipt"`
+	ReceiptHash  string  `json:"receipt_hash,omitempty"`
 	Status       string  `json:"status" gorm:"default:'pending'"`
 	User         *User   `json:"user" gorm:"foreignKey:UserID"`
+	// Version is bumped on every write and sent as the ETag.
+	Version uint `json:"version" gorm:"not null;default:1"`
+	// DeletedAt soft-deletes the expense; it is purged after the retention
+	// period.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
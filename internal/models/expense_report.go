@@ -1,11 +1,36 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpenseReport totals its expenses in USD (Total) and in the reporting
+// Currency (ReportingTotal, at ExchangeRate from USD). The rate is live while
+// the report can still change and frozen at RatesFrozenAt on submission.
 type ExpenseReport struct {
 	BaseModel
-	UserID   uint      `json:"user_id" gorm:"not null"`
-	Title    string    `json:"title" gorm:"not null"`
-	Status   string    `json:"status" gorm:"default:'draft'"`
-	Total    float64   `json:"total"`
-	User     *User     `json:"user" gorm:"foreignKey:UserID"`
-	Expenses []Expense `json:"expenses" gorm:"many2many:report_expenses;joinForeignKey:ReportID;joinReferences:ExpenseID"`
+	UserID         uint                 `json:"user_id" gorm:"not null"`
+	Title          string               `json:"title" gorm:"not null"`
+	Status         string               `json:"status" gorm:"default:'draft'"`
+	Total          float64              `json:"total"`
+	Currency       string               `json:"currency" gorm:"default:'USD'"`
+	ExchangeRate   float64              `json:"exchange_rate"`
+	ReportingTotal float64              `json:"reporting_total"`
+	RatesFrozenAt  *time.Time           `json:"rates_frozen_at"`
+	User           *User                `json:"user" gorm:"foreignKey:UserID"`
+	Expenses       []Expense            `json:"expenses" gorm:"many2many:report_expenses;joinForeignKey:ReportID;joinReferences:ExpenseID"`
+	History        []ReportStatusChange `json:"status_history,omitempty" gorm:"foreignKey:ReportID"`
+	// Version is bumped on every write and sent as the ETag.
+	Version uint `json:"version" gorm:"not null;default:1"`
+	// DeletedAt soft-deletes the report; it is purged after the retention
+	// period.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// RatesFrozen reports whether the reporting rate is a submission snapshot
+// rather than a live rate.
+func (r *ExpenseReport) RatesFrozen() bool {
+	return r.RatesFrozenAt != nil && r.Status != ReportStatusDraft && r.Status != ReportStatusRejected
 }
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header so a retry gets the same response instead of
+// repeating the change. StatusCode is 0 while the first request is still
+// running.
+type IdempotencyKey struct {
+	Key          string    `json:"key" gorm:"primaryKey"`
+	RequestHash  string    `json:"request_hash" gorm:"not null"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type"`
+	ResponseBody []byte    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// Completed reports whether the response has been stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+// Job is a unit of background work. Failed jobs are retried with backoff
+// until MaxAttempts, then parked as dead until an admin retries them.
+// Scheduled runs carry a UniqueKey so every worker enqueues a slot once.
+type Job struct {
+	BaseModel
+	Kind        string     `json:"kind" gorm:"not null"`
+	Payload     string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status      string     `json:"status" gorm:"default:'pending'"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	RunAt       time.Time  `json:"run_at"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	UniqueKey   *string    `json:"unique_key,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelInApp = "in_app"
+)
+
+type Notification struct {
+	BaseModel
+	UserID  uint       `json:"user_id" gorm:"not null"`
+	ActorID *uint      `json:"actor_id,omitempty"`
+	Event   string     `json:"event" gorm:"not null"`
+	Title   string     `json:"title" gorm:"not null"`
+	Body    string     `json:"body"`
+	Link    string     `json:"link,omitempty"`
+	ReadAt  *time.Time `json:"read_at"`
+}
+
+// NotificationDelivery records that a user was sent an event on a channel,
+// so an event the outbox delivers again does not notify them twice.
+type NotificationDelivery struct {
+	EventID   string `gorm:"primaryKey"`
+	UserID    uint   `gorm:"primaryKey"`
+	Channel   string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// NotificationPreference switches a channel on or off for one event type.
+// A missing preference means the channel is enabled.
+type NotificationPreference struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	Event     string    `json:"event" gorm:"primaryKey"`
+	Email     bool      `json:"email"`
+	InApp     bool      `json:"in_app"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	OutboxFailed    = "failed"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// it describes, waiting to be handed to subscribers.
+type OutboxEvent struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	EventID     string     `json:"event_id" gorm:"not null"`
+	EventType   string     `json:"event_type" gorm:"not null"`
+	Payload     string     `json:"payload" gorm:"type:jsonb;not null"`
+	OccurredAt  time.Time  `json:"occurred_at"`
+	Status      string     `json:"status" gorm:"default:'pending'"`
+	Attempts    int        `json:"attempts"`
+	AvailableAt time.Time  `json:"available_at"`
+	LastError   string     `json:"last_error,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	ReportStatusDraft     = "draft"
+	ReportStatusSubmitted = "submitted"
+	ReportStatusApproved  = "approved"
+	ReportStatusRejected  = "rejected"
+)
+
+// ReportStatusChange records a single transition of an ExpenseReport. The
+// first entry of every report has an empty FromStatus.
+type ReportStatusChange struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ReportID   uint      `json:"report_id" gorm:"not null"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status" gorm:"not null"`
+	ActorID    *uint     `json:"actor_id"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	Actor      *User     `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+func (ReportStatusChange) TableName() string {
+	return "report_status_history"
+}
@@ -1,7 +1,21 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type User struct {
 	BaseModel
-	Email string `json:"email" gorm:"uniqueIndex;not null"`
-	Name  string `json:"name" gorm:"not null"`
+	Email             string `json:"email" gorm:"not null"`
+	Name              string `json:"name" gorm:"not null"`
+	ReportingCurrency string `json:"reporting_currency,omitempty" gorm:"default:null"`
+	Active            bool   `json:"active" gorm:"not null;default:true"`
+	// ErasedAt is set once the user's personal data has been anonymized on
+	// request. Their expenses and reports are kept for accounting.
+	ErasedAt *time.Time `json:"erased_at,omitempty"`
+	// DeletedAt soft-deletes the user: the row and its expenses stay, but
+	// the user no longer shows up in queries.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
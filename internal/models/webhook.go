@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	BaseModel
+	URL        string   `json:"url" gorm:"not null"`
+	Secret     string   `json:"-" gorm:"not null"`
+	EventTypes []string `json:"event_types" gorm:"serializer:json"`
+	Active     bool     `json:"active" gorm:"default:true"`
+}
+
+// WebhookDelivery is one attempt to hand an event to a subscriber, retried
+// with backoff until it succeeds or runs out of attempts. Replays are new
+// deliveries pointing at the one they copy.
+type WebhookDelivery struct {
+	BaseModel
+	SubscriptionID uint                 `json:"subscription_id" gorm:"not null"`
+	EventID        string               `json:"event_id" gorm:"not null"`
+	EventType      string               `json:"event_type" gorm:"not null"`
+	Payload        string               `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string               `json:"status" gorm:"default:'pending'"`
+	Attempts       int                  `json:"attempts"`
+	NextAttemptAt  time.Time            `json:"next_attempt_at"`
+	ResponseStatus int                  `json:"response_status,omitempty"`
+	LastError      string               `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+	ReplayOf       *uint                `json:"replay_of,omitempty"`
+	Subscription   *WebhookSubscription `json:"-" gorm:"foreignKey:SubscriptionID"`
+}
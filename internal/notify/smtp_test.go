@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"testing"
+)
+
+func TestComposeKeepsSubjectOnOneLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  string
+		expected string
+	}{
+		{name: "BareLF", subject: "Report Trip\nBcc: victim@example.com", expected: "Report Trip Bcc: victim@example.com"},
+		{name: "BareCR", subject: "Report Trip\rBcc: victim@example.com", expected: "Report Trip Bcc: victim@example.com"},
+		{name: "CRLFBody", subject: "Report Trip\r\n\r\nforged body", expected: "Report Trip forged body"},
+		{name: "NonASCII", subject: "Rapport déplacement", expected: "Rapport déplacement"},
+	}
+	m := NewMailer("localhost:1025", "Flypro <noreply@example.com>", "", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := m.compose("approver@example.com", Message{Subject: tt.subject, Body: "Hello"})
+			msg, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("invalid message: %v", err)
+			}
+			if len(msg.Header["Bcc"]) != 0 {
+				t.Fatalf("expected no injected Bcc header, got %v", msg.Header["Bcc"])
+			}
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil || subject != tt.expected {
+				t.Errorf("expected subject %q, got %q (%v)", tt.expected, subject, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package notify renders notification messages and delivers them by email.
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates holds one template set per event type. Every file defines its
+// own "subject" and "body", so they cannot share a set.
+var templates = mustLoadTemplates()
+
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// TemplateData is what every template is executed with.
+type TemplateData struct {
+	Recipient *models.User
+	Actor     string
+	Report    events.ReportPayload
+}
+
+// HasTemplate reports whether eventType has a notification template.
+func HasTemplate(eventType string) bool {
+	_, ok := templates[eventType]
+	return ok
+}
+
+// Render executes the subject and body templates for eventType.
+func Render(eventType string, data TemplateData) (Message, error) {
+	t, ok := templates[eventType]
+	if !ok {
+		return Message{}, fmt.Errorf("no notification template for %s", eventType)
+	}
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: strings.TrimSpace(subject.String()), Body: strings.TrimSpace(body.String())}, nil
+}
+
+func mustLoadTemplates() map[string]*template.Template {
+	files, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		eventType := strings.TrimSuffix(path.Base(file), ".tmpl")
+		out[eventType] = template.Must(template.New(eventType).ParseFS(templateFS, file))
+	}
+	return out
+}
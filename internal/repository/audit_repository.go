@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// auditChainLock is the advisory lock key serialising appends so every event
+// sees the hash of the one committed before it.
+const auditChainLock = 727101
+
+type AuditRepository interface {
+	Append(ctx context.Context, events []*models.AuditEvent, seal func(event *models.AuditEvent, prevHash string) string) error
+	List(ctx context.Context, entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, error)
+	Stream(ctx context.Context, fn func(*models.AuditEvent) error) error
+}
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepo{db: db}
+}
+
+// Append chains events, in order, onto the last stored one. Called inside a
+// business transaction, the chain lock is held until that transaction ends,
+// so the events commit or roll back together with the change they describe.
+func (r *auditRepo) Append(ctx context.Context, events []*models.AuditEvent, seal func(event *models.AuditEvent, prevHash string) string) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+			return err
+		}
+		var last []string
+		if err := tx.Model(&models.AuditEvent{}).Order("id DESC").Limit(1).Pluck("hash", &last).Error; err != nil {
+			return err
+		}
+		prev := ""
+		if len(last) > 0 {
+			prev = last[0]
+		}
+		for _, event := range events {
+			event.PrevHash = prev
+			event.Hash = seal(event, prev)
+			prev = event.Hash
+		}
+		return tx.CreateInBatches(events, 500).Error
+	})
+}
+
+func (r *auditRepo) List(ctx context.Context, entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	query := conn(ctx, r.db).Where("entity_type = ?", entityType)
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	err := query.Order("id").Offset(offset).Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *auditRepo) Stream(ctx context.Context, fn func(*models.AuditEvent) error) error {
+	rows, err := conn(ctx, r.db).Model(&models.AuditEvent{}).Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := r.db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCardNotFound            = errors.New("card not found")
+	ErrCardTransactionNotFound = errors.New("card transaction not found")
+	ErrExpenseAlreadyLinked    = errors.New("expense already linked to a card transaction")
+	ErrCardTransactionExists   = errors.New("card transaction already recorded")
+)
+
+type CardRepository interface {
+	CreateCard(ctx context.Context, card *models.CorporateCard) error
+	GetCardByToken(ctx context.Context, token string) (*models.CorporateCard, error)
+	CreateTransaction(ctx context.Context, txn *models.CardTransaction) error
+	GetTransactionByID(ctx context.Context, id uint) (*models.CardTransaction, error)
+	GetTransactionByExternalID(ctx context.Context, externalID string) (*models.CardTransaction, error)
+	LinkExpense(ctx context.Context, txnID uint, expenseID uint, status string) error
+	ListTransactions(ctx context.Context, userID uint, status string, offset, limit int) ([]models.CardTransaction, error)
+	FindMatchCandidates(ctx context.Context, userID uint, amount float64, currency string, from, to time.Time) ([]models.Expense, error)
+	ListExpensesMissingReceipts(ctx context.Context, userID uint) ([]models.Expense, error)
+}
+
+type cardRepo struct {
+	db *gorm.DB
+}
+
+func NewCardRepository(db *gorm.DB) CardRepository {
+	return &cardRepo{db: db}
+}
+
+func (r *cardRepo) CreateCard(ctx context.Context, card *models.CorporateCard) error {
+	return conn(ctx, r.db).Create(card).Error
+}
+
+func (r *cardRepo) GetCardByToken(ctx context.Context, token string) (*models.CorporateCard, error) {
+	var card models.CorporateCard
+	if err := conn(ctx, r.db).Where("card_token = ?", token).First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+// CreateTransaction fails with ErrCardTransactionExists when a charge with
+// the same external ID was recorded first. A new charge is not linked to an
+// expense yet, so external_id is the only unique column it can clash on.
+func (r *cardRepo) CreateTransaction(ctx context.Context, txn *models.CardTransaction) error {
+	if err := conn(ctx, r.db).Create(txn).Error; err != nil {
+		if isUniqueViolation(err) {
+			return ErrCardTransactionExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *cardRepo) GetTransactionByID(ctx context.Context, id uint) (*models.CardTransaction, error) {
+	var txn models.CardTransaction
+	if err := conn(ctx, r.db).First(&txn, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCardTransactionNotFound
+		}
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *cardRepo) GetTransactionByExternalID(ctx context.Context, externalID string) (*models.CardTransaction, error) {
+	var txn models.CardTransaction
+	if err := conn(ctx, r.db).Where("external_id = ?", externalID).First(&txn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCardTransactionNotFound
+		}
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// LinkExpense fails with ErrExpenseAlreadyLinked when another charge is
+// already linked to the expense. Inside a transaction the update runs under
+// a savepoint, so the caller's transaction survives the conflict.
+func (r *cardRepo) LinkExpense(ctx context.Context, txnID uint, expenseID uint, status string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&models.CardTransaction{}).
+			Where("id = ?", txnID).
+			Updates(map[string]interface{}{"expense_id": expenseID, "status": status})
+		if result.Error != nil {
+			if isUniqueViolation(result.Error) {
+				return ErrExpenseAlreadyLinked
+			}
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCardTransactionNotFound
+		}
+		return nil
+	})
+}
+
+func (r *cardRepo) ListTransactions(ctx context.Context, userID uint, status string, offset, limit int) ([]models.CardTransaction, error) {
+	var txns []models.CardTransaction
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("transacted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Preload("Expense").
+		Find(&txns).Error
+	return txns, err
+}
+
+// FindMatchCandidates returns the user's expenses with the same amount and
+// currency inside the date window that are not yet linked to a card charge.
+func (r *cardRepo) FindMatchCandidates(ctx context.Context, userID uint, amount float64, currency string, from, to time.Time) ([]models.Expense, error) {
+	var expenses []models.Expense
+	err := conn(ctx, r.db).
+		Where("user_id = ? AND currency = ? AND ABS(amount - ?) < 0.005", userID, currency, amount).
+		Where("created_at BETWEEN ? AND ?", from, to).
+		Where("NOT EXISTS (SELECT 1 FROM card_transactions ct WHERE ct.expense_id = expenses.id)").
+		Order("created_at").
+		Find(&expenses).Error
+	return expenses, err
+}
+
+func (r *cardRepo) ListExpensesMissingReceipts(ctx context.Context, userID uint) ([]models.Expense, error) {
+	var expenses []models.Expense
+	err := conn(ctx, r.db).
+		Joins("JOIN card_transactions ct ON ct.expense_id = expenses.id").
+		Where("expenses.user_id = ?", userID).
+		Where("(expenses.receipt IS NULL OR expenses.receipt = '')").
+		Order("expenses.created_at DESC").
+		Find(&expenses).Error
+	return expenses, err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErasedCommentBody replaces the text of comments written by an erased user.
+const ErasedCommentBody = "[erased]"
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	ListForReport(ctx context.Context, reportID uint, offset, limit int) ([]models.Comment, error)
+	ListForExpense(ctx context.Context, expenseID uint, offset, limit int) ([]models.Comment, error)
+	EraseUser(ctx context.Context, userID uint, email string) error
+}
+
+type commentRepo struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepo{db: db}
+}
+
+func (r *commentRepo) Create(ctx context.Context, comment *models.Comment) error {
+	return conn(ctx, r.db).Create(comment).Error
+}
+
+func (r *commentRepo) ListForReport(ctx context.Context, reportID uint, offset, limit int) ([]models.Comment, error) {
+	return r.list(ctx, "report_id = ?", reportID, offset, limit)
+}
+
+func (r *commentRepo) ListForExpense(ctx context.Context, expenseID uint, offset, limit int) ([]models.Comment, error) {
+	return r.list(ctx, "expense_id = ?", expenseID, offset, limit)
+}
+
+func (r *commentRepo) list(ctx context.Context, where string, id uint, offset, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := conn(ctx, r.db).
+		Where(where, id).
+		Preload("Author").
+		Order("id").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+// EraseUser blanks the comments the user wrote and swaps their address for
+// the erased one in comments that mention them.
+func (r *commentRepo) EraseUser(ctx context.Context, userID uint, email string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Comment{}).
+			Where("author_id = ?", userID).
+			UpdateColumn("body", ErasedCommentBody).Error
+		if err != nil || email == "" {
+			return err
+		}
+		return tx.Model(&models.Comment{}).
+			Where("strpos(body, ?) > 0", email).
+			UpdateColumn("body", gorm.Expr("REPLACE(body, ?, ?)", email, ErasedEmail(userID))).Error
+	})
+}
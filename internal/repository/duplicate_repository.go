@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DuplicateRepository interface {
+	FindCandidates(ctx context.Context, expense *models.Expense, from, to time.Time) ([]models.Expense, error)
+	SaveFlags(ctx context.Context, flags []models.DuplicateFlag) error
+	ListFlagsForReport(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error)
+}
+
+type duplicateRepo struct {
+	db *gorm.DB
+}
+
+func NewDuplicateRepository(db *gorm.DB) DuplicateRepository {
+	return &duplicateRepo{db: db}
+}
+
+// FindCandidates returns the user's other expenses that share the receipt
+// hash, or the amount and currency within the date window.
+func (r *duplicateRepo) FindCandidates(ctx context.Context, expense *models.Expense, from, to time.Time) ([]models.Expense, error) {
+	var expenses []models.Expense
+	sameCharge := r.db.Where("currency = ? AND ABS(amount - ?) < 0.005 AND created_at BETWEEN ? AND ?",
+		expense.Currency, expense.Amount, from, to)
+	if expense.ReceiptHash != "" {
+		sameCharge = sameCharge.Or("receipt_hash = ?", expense.ReceiptHash)
+	}
+	err := conn(ctx, r.db).
+		Where("user_id = ? AND id <> ?", expense.UserID, expense.ID).
+		Where(sameCharge).
+		Order("created_at").
+		Limit(50).
+		Find(&expenses).Error
+	return expenses, err
+}
+
+func (r *duplicateRepo) SaveFlags(ctx context.Context, flags []models.DuplicateFlag) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "expense_id"}, {Name: "duplicate_of_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reasons", "score"}),
+		}).
+		Create(&flags).Error
+}
+
+func (r *duplicateRepo) ListFlagsForReport(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error) {
+	var flags []models.DuplicateFlag
+	err := conn(ctx, r.db).
+		Joins("JOIN report_expenses re ON re.expense_id = expense_duplicate_flags.expense_id").
+		Joins("JOIN expenses e ON e.id = expense_duplicate_flags.expense_id AND e.deleted_at IS NULL").
+		Joins("JOIN expenses d ON d.id = expense_duplicate_flags.duplicate_of_id AND d.deleted_at IS NULL").
+		Where("re.report_id = ?", reportID).
+		Order("expense_duplicate_flags.expense_id, expense_duplicate_flags.score DESC").
+		Find(&flags).Error
+	return flags, err
+}
@@ -3,19 +3,28 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
+	"time"
 
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var ErrExpenseNotFound = errors.New("expense not found")
 
 type ExpenseRepository interface {
 	Create(ctx context.Context, expense *models.Expense) error
+	CreateBatch(ctx context.Context, expenses []*models.Expense) error
 	GetExpenseByID(ctx context.Context, id uint) (*models.Expense, error)
 	GetExpenses(ctx context.Context, filters map[string]interface{}, offset, limit int) ([]models.Expense, error)
-	UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId uint) error
-	DeleteExpense(ctx context.Context, id uint, userId uint) error
+	UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId, version uint) error
+	PatchExpense(ctx context.Context, id, userId, version uint, fields map[string]interface{}) error
+	DeleteExpense(ctx context.Context, id uint, userId, version uint) error
+	RestoreExpense(ctx context.Context, id uint) (*models.Expense, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	StreamExpenses(ctx context.Context, filters map[string]interface{}, fn func(*models.Expense) error) error
+	StreamExpensesUnscoped(ctx context.Context, filters map[string]interface{}, fn func(*models.Expense) error) error
 }
 
 type expenseRepo struct {
@@ -26,14 +35,58 @@ func NewExpenseRepository(db *gorm.DB) ExpenseRepository {
 	return &expenseRepo{db: db}
 }
 
+// Create stores the expense. It fails with ErrUserInactive when its owner
+// is deactivated.
 func (r *expenseRepo) Create(ctx context.Context, expense *models.Expense) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkUsersActive(tx, expense.UserID); err != nil {
+			return err
+		}
+		return tx.Create(expense).Error
+	})
+}
 
-	return r.db.WithContext(ctx).Create(expense).Error
+func (r *expenseRepo) CreateBatch(ctx context.Context, expenses []*models.Expense) error {
+	userIDs := make([]uint, 0, 1)
+	for _, expense := range expenses {
+		if !slices.Contains(userIDs, expense.UserID) {
+			userIDs = append(userIDs, expense.UserID)
+		}
+	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkUsersActive(tx, userIDs...); err != nil {
+			return err
+		}
+		return tx.CreateInBatches(expenses, 100).Error
+	})
+}
+
+// checkUsersActive fails unless every user exists and is active. The rows
+// stay share-locked until the transaction ends, so a concurrent
+// deactivation waits for the new expenses instead of missing them.
+func checkUsersActive(tx *gorm.DB, userIDs ...uint) error {
+	var users []models.User
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Select("id", "active").
+		Where("id IN ?", userIDs).
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+	if len(users) != len(userIDs) {
+		return ErrUserNotFound
+	}
+	for _, user := range users {
+		if !user.Active {
+			return ErrUserInactive
+		}
+	}
+	return nil
 }
 
 func (r *expenseRepo) GetExpenseByID(ctx context.Context, id uint) (*models.Expense, error) {
 	var expense models.Expense
-	if err := r.db.WithContext(ctx).Preload("User").First(&expense, id).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("User").First(&expense, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrExpenseNotFound
 		}
@@ -44,42 +97,174 @@ func (r *expenseRepo) GetExpenseByID(ctx context.Context, id uint) (*models.Expe
 
 func (r *expenseRepo) GetExpenses(ctx context.Context, filters map[string]interface{}, offset, limit int) ([]models.Expense, error) {
 	var expenses []models.Expense
-	query := r.db.WithContext(ctx).Model(&models.Expense{}).Preload("User")
+	query := applyExpenseFilters(conn(ctx, r.db).Model(&models.Expense{}).Preload("User"), filters)
+
+	if err := query.Offset(offset).Limit(limit).Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// StreamExpenses walks every expense matching filters row by row, calling fn
+// for each one without loading the full result set.
+func (r *expenseRepo) StreamExpenses(ctx context.Context, filters map[string]interface{}, fn func(*models.Expense) error) error {
+	return r.stream(conn(ctx, r.db), filters, fn)
+}
+
+// StreamExpensesUnscoped is StreamExpenses including soft-deleted expenses.
+func (r *expenseRepo) StreamExpensesUnscoped(ctx context.Context, filters map[string]interface{}, fn func(*models.Expense) error) error {
+	return r.stream(conn(ctx, r.db).Unscoped(), filters, fn)
+}
+
+func (r *expenseRepo) stream(db *gorm.DB, filters map[string]interface{}, fn func(*models.Expense) error) error {
+	query := applyExpenseFilters(db.Model(&models.Expense{}), filters)
+	rows, err := query.Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expense models.Expense
+		if err := r.db.ScanRows(rows, &expense); err != nil {
+			return err
+		}
+		if err := fn(&expense); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
 
+func applyExpenseFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
 	allowedFilters := map[string]bool{"user_id": true, "category": true, "status": true}
 	for key, value := range filters {
 		if allowedFilters[key] {
 			query = query.Where(key+" = ?", value)
 		}
 	}
+	return query
+}
 
-	if err := query.Offset(offset).Limit(limit).Find(&expenses).Error; err != nil {
-		return nil, err
-	}
-	return expenses, nil
+// UpdateExpense writes the expense if it is still at version, moving it to
+// the next version. It fails with ErrVersionConflict when the expense was
+// changed in the meantime, and with ErrReportNotEditable while the expense
+// belongs to a submitted or approved report.
+func (r *expenseRepo) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId, version uint) error {
+	expense.Version = version + 1
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkExpenseUnlocked(tx, id); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Expense{}).
+			Where("id = ? AND user_id = ? AND version = ?", id, userId, version).
+			Updates(expense)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := versionMiss(tx.Model(&models.Expense{}).Where("id = ? AND user_id = ?", id, userId), version, ErrExpenseNotFound); err != nil {
+				return err
+			}
+			return ErrExpenseNotFound
+		}
+		return syncReportTotals(tx, id)
+	})
 }
 
-func (r *expenseRepo) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId uint) error {
-	result := r.db.WithContext(ctx).Model(&models.Expense{}).Where("id = ? AND user_id = ?", id, userId).Updates(expense)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrExpenseNotFound
+// PatchExpense writes just the given columns, zero values included, if the
+// expense is still at version, moving it to the next version. Like
+// UpdateExpense it fails with ErrReportNotEditable while the expense belongs
+// to a submitted or approved report, and keeps the totals of its other
+// reports in step.
+func (r *expenseRepo) PatchExpense(ctx context.Context, id, userId, version uint, fields map[string]interface{}) error {
+	columns := make(map[string]interface{}, len(fields)+1)
+	for column, value := range fields {
+		columns[column] = value
 	}
-	return nil
+	columns["version"] = version + 1
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkExpenseUnlocked(tx, id); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Expense{}).
+			Where("id = ? AND user_id = ? AND version = ?", id, userId, version).
+			Updates(columns)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := versionMiss(tx.Model(&models.Expense{}).Where("id = ? AND user_id = ?", id, userId), version, ErrExpenseNotFound); err != nil {
+				return err
+			}
+			return ErrExpenseNotFound
+		}
+		if _, ok := fields["amount_usd"]; !ok {
+			return nil
+		}
+		return syncReportTotals(tx, id)
+	})
 }
 
-func (r *expenseRepo) DeleteExpense(ctx context.Context, id uint, userId uint) error {
-	result := r.db.WithContext(ctx).
-		Where("id = ? AND user_id = ?", id, userId).
-		Delete(&models.Expense{})
+// DeleteExpense soft-deletes the expense if it is still at version and
+// takes it out of its reports' totals. It fails with ErrReportNotEditable
+// while the expense belongs to a submitted or approved report.
+func (r *expenseRepo) DeleteExpense(ctx context.Context, id uint, userId, version uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("id = ? AND user_id = ? AND version = ?", id, userId, version).
+			Delete(&models.Expense{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := versionMiss(tx.Model(&models.Expense{}).Where("id = ? AND user_id = ?", id, userId), version, ErrExpenseNotFound); err != nil {
+				return err
+			}
+			return ErrExpenseNotFound
+		}
+		if err := checkExpenseUnlocked(tx, id); err != nil {
+			return err
+		}
+		return syncReportTotals(tx, id)
+	})
+}
 
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrExpenseNotFound
+// RestoreExpense undoes a soft delete and adds the expense back to its
+// reports' totals. It fails with ErrExpenseNotFound unless the expense
+// exists and is deleted, and with ErrReportNotEditable when one of its
+// reports was submitted in the meantime.
+func (r *expenseRepo) RestoreExpense(ctx context.Context, id uint) (*models.Expense, error) {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().
+			Model(&models.Expense{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": bumpVersion})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrExpenseNotFound
+		}
+		if err := checkExpenseUnlocked(tx, id); err != nil {
+			return err
+		}
+		return syncReportTotals(tx, id)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return r.GetExpenseByID(ctx, id)
+}
+
+// PurgeDeleted permanently removes expenses soft-deleted before the cutoff,
+// along with their report links, flags and comments. Expenses that belong
+// to a submitted or approved report are kept with it.
+func (r *expenseRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	db := conn(ctx, r.db)
+	result := db.Unscoped().
+		Where("deleted_at < ?", before).
+		Where("id NOT IN (?)", lockedReportsOf(db)).
+		Delete(&models.Expense{})
+	return result.RowsAffected, result.Error
 }
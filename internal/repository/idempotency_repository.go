@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, key string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	Delete(ctx context.Context, key string) error
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyRepo struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepo{db: db}
+}
+
+// Claim inserts the key unless it already exists. It reports whether this
+// caller now owns the key.
+func (r *idempotencyRepo) Claim(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *idempotencyRepo) Get(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	if err := conn(ctx, r.db).Where("key = ?", key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepo) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	result := conn(ctx, r.db).Model(&models.IdempotencyKey{}).Where("key = ?", key).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": body,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotencyKeyNotFound
+	}
+	return nil
+}
+
+func (r *idempotencyRepo) Delete(ctx context.Context, key string) error {
+	return conn(ctx, r.db).Where("key = ?", key).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyRepo) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("expires_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+type JobStatusCount struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// JobActivity is what the queue shows of its workers: when one last ran a
+// job, and since when the oldest due job has been waiting.
+type JobActivity struct {
+	LastWorkedAt *time.Time
+	OldestDueAt  *time.Time
+}
+
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *models.Job) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Job, error)
+	SaveResult(ctx context.Context, job *models.Job) error
+	GetJob(ctx context.Context, id uint) (*models.Job, error)
+	ListJobs(ctx context.Context, status, kind string, offset, limit int) ([]models.Job, error)
+	CountByStatus(ctx context.Context) ([]JobStatusCount, error)
+	Activity(ctx context.Context, now time.Time) (*JobActivity, error)
+	Requeue(ctx context.Context, id uint, runAt time.Time) error
+	PurgeFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type jobRepo struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepo{db: db}
+}
+
+// Enqueue stores the job. A job whose unique key is already taken is
+// silently dropped, leaving its ID zero.
+func (r *jobRepo) Enqueue(ctx context.Context, job *models.Job) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+}
+
+// ClaimDue locks due jobs, marks them running and counts the attempt. Jobs
+// left running by a worker that died are picked up again once their lease
+// has expired.
+func (r *jobRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	var claimed []models.Job
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+				models.JobPending, now, models.JobRunning, now).
+			Order("run_at, id").
+			Limit(limit).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(claimed))
+		lockedUntil := now.Add(lease)
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+			claimed[i].Status = models.JobRunning
+			claimed[i].Attempts++
+			claimed[i].LockedUntil = &lockedUntil
+		}
+		return tx.Model(&models.Job{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":       models.JobRunning,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_until": lockedUntil,
+				"updated_at":   now,
+			}).Error
+	})
+	return claimed, err
+}
+
+func (r *jobRepo) SaveResult(ctx context.Context, job *models.Job) error {
+	return conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"status":       job.Status,
+			"run_at":       job.RunAt,
+			"locked_until": nil,
+			"last_error":   job.LastError,
+			"finished_at":  job.FinishedAt,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+func (r *jobRepo) GetJob(ctx context.Context, id uint) (*models.Job, error) {
+	var job models.Job
+	if err := conn(ctx, r.db).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepo) ListJobs(ctx context.Context, status, kind string, offset, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	query := conn(ctx, r.db)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *jobRepo) CountByStatus(ctx context.Context) ([]JobStatusCount, error) {
+	var counts []JobStatusCount
+	err := conn(ctx, r.db).
+		Model(&models.Job{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Order("status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Activity counts only jobs that left the pending status, since only a
+// worker moves them out of it.
+func (r *jobRepo) Activity(ctx context.Context, now time.Time) (*JobActivity, error) {
+	var activity JobActivity
+	err := conn(ctx, r.db).
+		Model(&models.Job{}).
+		Select("MAX(updated_at) FILTER (WHERE status <> ?) AS last_worked_at, "+
+			"MIN(run_at) FILTER (WHERE status = ? AND run_at <= ?) AS oldest_due_at",
+			models.JobPending, models.JobPending, now).
+		Scan(&activity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// Requeue gives a dead job a fresh set of attempts.
+func (r *jobRepo) Requeue(ctx context.Context, id uint, runAt time.Time) error {
+	result := conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, models.JobDead).
+		Updates(map[string]interface{}{
+			"status":      models.JobPending,
+			"attempts":    0,
+			"run_at":      runAt,
+			"finished_at": nil,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+func (r *jobRepo) PurgeFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("status = ? AND finished_at < ?", models.JobSucceeded, before).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	ListForUser(ctx context.Context, userID uint, unreadOnly bool, offset, limit int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID, id uint, read bool) error
+	MarkAllRead(ctx context.Context, userID uint) error
+	GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error)
+	SavePreference(ctx context.Context, pref *models.NotificationPreference) error
+	ClaimDelivery(ctx context.Context, eventID string, userID uint, channel string) (bool, error)
+	ReleaseDelivery(ctx context.Context, eventID string, userID uint, channel string) error
+	EraseUser(ctx context.Context, userID uint, name string) error
+}
+
+type notificationRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, notification *models.Notification) error {
+	return conn(ctx, r.db).Create(notification).Error
+}
+
+func (r *notificationRepo) ListForUser(ctx context.Context, userID uint, unreadOnly bool, offset, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepo) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, userID, id uint, read bool) error {
+	var readAt interface{}
+	if read {
+		readAt = time.Now()
+	}
+	result := conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", readAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *notificationRepo) MarkAllRead(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *notificationRepo) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("event").Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *notificationRepo) SavePreference(ctx context.Context, pref *models.NotificationPreference) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email", "in_app", "updated_at"}),
+	}).Create(pref).Error
+}
+
+// ClaimDelivery records that the event is being sent to the user on channel.
+// It returns false when an earlier delivery of the event already claimed it.
+func (r *notificationRepo) ClaimDelivery(ctx context.Context, eventID string, userID uint, channel string) (bool, error) {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NotificationDelivery{
+		EventID: eventID,
+		UserID:  userID,
+		Channel: channel,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseDelivery drops a claim whose send failed, so the next delivery of
+// the event retries it.
+func (r *notificationRepo) ReleaseDelivery(ctx context.Context, eventID string, userID uint, channel string) error {
+	return conn(ctx, r.db).
+		Where("event_id = ? AND user_id = ? AND channel = ?", eventID, userID, channel).
+		Delete(&models.NotificationDelivery{}).Error
+}
+
+// EraseUser deletes the user's notifications and replaces their name in the
+// notifications they caused. Notifications stored before actors were
+// recorded are scrubbed wherever the name appears.
+func (r *notificationRepo) EraseUser(ctx context.Context, userID uint, name string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if name == "" {
+			return nil
+		}
+		return tx.Model(&models.Notification{}).
+			Where("actor_id = ? OR actor_id IS NULL", userID).
+			Where("strpos(title, ?) > 0 OR strpos(body, ?) > 0", name, name).
+			UpdateColumns(map[string]interface{}{
+				"title": gorm.Expr("LEFT(REPLACE(title, ?, ?), 255)", name, ErasedUserName),
+				"body":  gorm.Expr("REPLACE(body, ?, ?)", name, ErasedUserName),
+			}).Error
+	})
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, event events.Event) error
+	ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error)
+	SaveResult(ctx context.Context, event *models.OutboxEvent) error
+	PurgePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepo{db: db}
+}
+
+// Add stores the event. Called with a transactional context it commits or
+// rolls back together with the change the event describes.
+func (r *outboxRepo) Add(ctx context.Context, event events.Event) error {
+	return conn(ctx, r.db).Create(&models.OutboxEvent{
+		EventID:     event.ID,
+		EventType:   event.Type,
+		Payload:     string(event.Data),
+		OccurredAt:  event.OccurredAt,
+		Status:      models.OutboxPending,
+		AvailableAt: event.OccurredAt,
+	}).Error
+}
+
+// ClaimPending locks the oldest pending events and moves them out of reach
+// of other dispatchers for the lease. An event whose dispatcher dies before
+// saving a result becomes available again once the lease expires.
+func (r *outboxRepo) ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var claimed []models.OutboxEvent
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= ?", models.OutboxPending, now).
+			Order("id").
+			Limit(limit).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(claimed))
+		for i, e := range claimed {
+			ids[i] = e.ID
+		}
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("available_at", now.Add(lease)).Error
+	})
+	return claimed, err
+}
+
+func (r *outboxRepo) SaveResult(ctx context.Context, event *models.OutboxEvent) error {
+	return conn(ctx, r.db).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", event.ID).
+		Updates(map[string]interface{}{
+			"status":       event.Status,
+			"attempts":     event.Attempts,
+			"available_at": event.AvailableAt,
+			"last_error":   event.LastError,
+			"published_at": event.PublishedAt,
+		}).Error
+}
+
+func (r *outboxRepo) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("status = ? AND published_at < ?", models.OutboxPublished, before).
+		Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -3,19 +3,29 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"gorm.io/gorm"
 )
 
-var ErrReportNotFound = errors.New("report not found")
+var (
+	ErrReportNotFound       = errors.New("report not found")
+	ErrReportStatusConflict = errors.New("report status changed concurrently")
+)
 
 type ReportRepository interface {
 	CreateReport(ctx context.Context, report *models.ExpenseReport) error
 	AddExpenseToReportWithTotal(ctx context.Context, reportID uint, expense *models.Expense) error
 	GetExpenseReportByID(ctx context.Context, id uint) (*models.ExpenseReport, error)
 	GetReportExpenses(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error)
-	SubmitReport(ctx context.Context, reportID uint) error
+	GetReportExpensesUnscoped(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error)
+	TransitionStatus(ctx context.Context, change *models.ReportStatusChange, version uint) error
+	FreezeReportingRate(ctx context.Context, reportID uint, rate float64, at time.Time) error
+	StreamReportExpenses(ctx context.Context, reportID uint, fn func(*models.Expense) error) error
+	DeleteReport(ctx context.Context, id, version uint) error
+	RestoreReport(ctx context.Context, id uint) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 type reportRepo struct {
@@ -27,12 +37,16 @@ func NewReportRepository(db *gorm.DB) ReportRepository {
 }
 
 func (r *reportRepo) CreateReport(ctx context.Context, report *models.ExpenseReport) error {
-	return r.db.WithContext(ctx).Create(report).Error
+	return conn(ctx, r.db).Create(report).Error
 }
 
+// AddExpenseToReportWithTotal links the expense and adds it to the total. It
+// fails with ErrReportNotEditable unless the report is a draft or rejected.
 func (r *reportRepo) AddExpenseToReportWithTotal(ctx context.Context, reportID uint, expense *models.Expense) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockEditableReport(tx, reportID); err != nil {
+			return err
+		}
 		if err := tx.Model(&models.ExpenseReport{BaseModel: models.BaseModel{ID: reportID}}).
 			Association("Expenses").
 			Append(expense); err != nil {
@@ -40,7 +54,7 @@ func (r *reportRepo) AddExpenseToReportWithTotal(ctx context.Context, reportID u
 		}
 		if err := tx.Model(&models.ExpenseReport{}).
 			Where("id = ?", reportID).
-			UpdateColumn("total", gorm.Expr("total + ?", expense.AmountUSD)).
+			UpdateColumns(map[string]interface{}{"total": gorm.Expr("total + ?", expense.AmountUSD), "version": bumpVersion}).
 			Error; err != nil {
 			return err
 		}
@@ -52,7 +66,13 @@ func (r *reportRepo) AddExpenseToReportWithTotal(ctx context.Context, reportID u
 
 func (r *reportRepo) GetExpenseReportByID(ctx context.Context, id uint) (*models.ExpenseReport, error) {
 	var report models.ExpenseReport
-	if err := r.db.WithContext(ctx).Preload("Expenses").First(&report, id).Error; err != nil {
+	err := conn(ctx, r.db).
+		Preload("Expenses").
+		Preload("User").
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("History.Actor").
+		First(&report, id).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrReportNotFound
 		}
@@ -62,8 +82,18 @@ func (r *reportRepo) GetExpenseReportByID(ctx context.Context, id uint) (*models
 }
 
 func (r *reportRepo) GetReportExpenses(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error) {
+	return r.listReports(conn(ctx, r.db), userID, offset, limit)
+}
+
+// GetReportExpensesUnscoped is GetReportExpenses including soft-deleted
+// reports, and soft-deleted expenses and users in them.
+func (r *reportRepo) GetReportExpensesUnscoped(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error) {
+	return r.listReports(conn(ctx, r.db).Unscoped(), userID, offset, limit)
+}
+
+func (r *reportRepo) listReports(db *gorm.DB, userID uint, offset, limit int) ([]models.ExpenseReport, error) {
 	var reports []models.ExpenseReport
-	err := r.db.WithContext(ctx).
+	err := db.
 		Where("user_id = ?", userID).
 		Offset(offset).
 		Limit(limit).
@@ -73,10 +103,103 @@ func (r *reportRepo) GetReportExpenses(ctx context.Context, userID uint, offset,
 	return reports, err
 }
 
-func (r *reportRepo) SubmitReport(ctx context.Context, reportID uint) error {
-	return r.db.WithContext(ctx).
+// TransitionStatus moves the report at version from change.FromStatus to
+// change.ToStatus and records the change in the same transaction. It fails
+// with ErrVersionConflict when the report was changed in the meantime and
+// ErrReportStatusConflict when it is no longer in FromStatus.
+func (r *reportRepo) TransitionStatus(ctx context.Context, change *models.ReportStatusChange, version uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ExpenseReport{}).
+			Where("id = ? AND status = ? AND version = ?", change.ReportID, change.FromStatus, version).
+			Updates(map[string]interface{}{"status": change.ToStatus, "version": bumpVersion, "updated_at": gorm.Expr("NOW()")})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := versionMiss(tx.Model(&models.ExpenseReport{}).Where("id = ?", change.ReportID), version, ErrReportNotFound); err != nil {
+				return err
+			}
+			return ErrReportStatusConflict
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// FreezeReportingRate stores the rate from USD to the reporting currency and
+// the total it gives, computed from the stored USD total.
+func (r *reportRepo) FreezeReportingRate(ctx context.Context, reportID uint, rate float64, at time.Time) error {
+	return conn(ctx, r.db).
 		Model(&models.ExpenseReport{}).
 		Where("id = ?", reportID).
-		UpdateColumn("status", "submitted").
-		Error
+		Updates(map[string]interface{}{
+			"exchange_rate":   rate,
+			"reporting_total": gorm.Expr("ROUND(total * ?, 2)", rate),
+			"rates_frozen_at": at,
+			"version":         bumpVersion,
+		}).Error
+}
+
+func (r *reportRepo) StreamReportExpenses(ctx context.Context, reportID uint, fn func(*models.Expense) error) error {
+	rows, err := conn(ctx, r.db).
+		Model(&models.Expense{}).
+		Select("expenses.*").
+		Joins("JOIN report_expenses ON report_expenses.expense_id = expenses.id").
+		Where("report_expenses.report_id = ?", reportID).
+		Order("expenses.id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expense models.Expense
+		if err := r.db.ScanRows(rows, &expense); err != nil {
+			return err
+		}
+		if err := fn(&expense); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DeleteReport soft-deletes the report if it is still at version. Its
+// expenses are left alone.
+func (r *reportRepo) DeleteReport(ctx context.Context, id, version uint) error {
+	db := conn(ctx, r.db)
+	result := db.Where("id = ? AND version = ?", id, version).Delete(&models.ExpenseReport{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := versionMiss(db.Model(&models.ExpenseReport{}).Where("id = ?", id), version, ErrReportNotFound); err != nil {
+			return err
+		}
+		return ErrReportNotFound
+	}
+	return nil
+}
+
+// RestoreReport undoes a soft delete. It fails with ErrReportNotFound unless
+// the report exists and is deleted.
+func (r *reportRepo) RestoreReport(ctx context.Context, id uint) error {
+	result := conn(ctx, r.db).Unscoped().
+		Model(&models.ExpenseReport{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": bumpVersion})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReportNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes reports soft-deleted before the cutoff,
+// along with their expense links, history and comments.
+func (r *reportRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Unscoped().Where("deleted_at < ?", before).Delete(&models.ExpenseReport{})
+	return result.RowsAffected, result.Error
 }
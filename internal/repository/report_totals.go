@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"math"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrReportNotEditable is returned when a write would change a report that
+// is submitted or approved. Only draft and rejected reports can change.
+var ErrReportNotEditable = errors.New("report is not editable")
+
+func reportEditable(status string) bool {
+	return status == models.ReportStatusDraft || status == models.ReportStatusRejected
+}
+
+// lockEditableReport locks the report row for the rest of the transaction
+// and checks it can still take changes, so a concurrent submit either
+// commits first and is seen here or waits for this transaction.
+func lockEditableReport(tx *gorm.DB, reportID uint) error {
+	var report models.ExpenseReport
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		First(&report, reportID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrReportNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !reportEditable(report.Status) {
+		return ErrReportNotEditable
+	}
+	return nil
+}
+
+// lockedReportsOf selects the reports holding the expense that can no
+// longer change, as a subquery.
+func lockedReportsOf(tx *gorm.DB) *gorm.DB {
+	return tx.Table("report_expenses").
+		Select("report_expenses.expense_id").
+		Joins("JOIN expense_reports ON expense_reports.id = report_expenses.report_id").
+		Where("expense_reports.status NOT IN ?", []string{models.ReportStatusDraft, models.ReportStatusRejected})
+}
+
+// checkExpenseUnlocked fails with ErrReportNotEditable when the expense
+// belongs to a submitted or approved report, whose lines are finance
+// records. The report rows are locked so a concurrent submit waits.
+func checkExpenseUnlocked(tx *gorm.DB, expenseID uint) error {
+	var statuses []string
+	err := tx.Model(&models.ExpenseReport{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN (?)", tx.Model(&models.ReportExpense{}).Select("report_id").Where("expense_id = ?", expenseID)).
+		Pluck("status", &statuses).Error
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if !reportEditable(status) {
+			return ErrReportNotEditable
+		}
+	}
+	return nil
+}
+
+// syncReportTotals recomputes the USD total of every report holding the
+// expense from its live expenses, after a write that may have changed the
+// expense's amount. It fails with ErrReportNotEditable, rolling the write
+// back, when the total of a submitted or approved report would change.
+func syncReportTotals(tx *gorm.DB, expenseID uint) error {
+	var reports []models.ExpenseReport
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status", "total").
+		Where("id IN (?)", tx.Model(&models.ReportExpense{}).Select("report_id").Where("expense_id = ?", expenseID)).
+		Find(&reports).Error
+	if err != nil {
+		return err
+	}
+	for _, report := range reports {
+		var total float64
+		err := tx.Model(&models.Expense{}).
+			Joins("JOIN report_expenses ON report_expenses.expense_id = expenses.id").
+			Where("report_expenses.report_id = ?", report.ID).
+			Select("COALESCE(SUM(expenses.amount_usd), 0)").
+			Scan(&total).Error
+		if err != nil {
+			return err
+		}
+		// Totals are floats summed in a different order; only a change of
+		// at least a cent counts.
+		if math.Abs(total-report.Total) < 0.005 {
+			continue
+		}
+		if !reportEditable(report.Status) {
+			return ErrReportNotEditable
+		}
+		err = tx.Model(&models.ExpenseReport{}).
+			Where("id = ?", report.ID).
+			UpdateColumns(map[string]interface{}{"total": total, "version": bumpVersion}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,12 +3,16 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"gorm.io/gorm"
 )
 
 var ErrUserNotFound = errors.New("user not found")
+var ErrUserInactive = errors.New("user is deactivated")
 var ErrDatabase = errors.New("database error")
 
 type userRepo struct {
@@ -18,7 +22,12 @@ type userRepo struct {
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	GetUserByIDUnscoped(ctx context.Context, id uint) (*models.User, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateUser(ctx context.Context, id uint, fields map[string]interface{}) error
+	DeleteUser(ctx context.Context, id uint) error
+	AnonymizeUser(ctx context.Context, id uint, at time.Time) error
+	ListUsers(ctx context.Context, search string, active *bool, offset, limit int) ([]models.User, error)
 }
 
 func NewUserRepository(db *gorm.DB) UserRepository {
@@ -26,12 +35,21 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepo) CreateUser(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepo) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.getUser(conn(ctx, r.db), id)
+}
+
+// GetUserByIDUnscoped also finds soft-deleted users.
+func (r *userRepo) GetUserByIDUnscoped(ctx context.Context, id uint) (*models.User, error) {
+	return r.getUser(conn(ctx, r.db).Unscoped(), id)
+}
+
+func (r *userRepo) getUser(db *gorm.DB, id uint) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+	if err := db.First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
@@ -42,7 +60,7 @@ func (r *userRepo) GetUserByID(ctx context.Context, id uint) (*models.User, erro
 
 func (r *userRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
@@ -50,3 +68,74 @@ func (r *userRepo) FindByEmail(ctx context.Context, email string) (*models.User,
 	}
 	return &user, nil
 }
+
+// UpdateUser writes the given columns. Deleted users cannot be updated.
+func (r *userRepo) UpdateUser(ctx context.Context, id uint, fields map[string]interface{}) error {
+	result := conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// DeleteUser soft-deletes the user, leaving their expenses and reports in
+// place.
+func (r *userRepo) DeleteUser(ctx context.Context, id uint) error {
+	result := conn(ctx, r.db).Delete(&models.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ErasedUserName replaces the name of an erased user wherever it was stored.
+const ErasedUserName = "Erased user"
+
+// ErasedEmail is the placeholder address an erased user is left with.
+func ErasedEmail(id uint) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", id)
+}
+
+// AnonymizeUser overwrites the user's name and email and deactivates them.
+// Deleted users are anonymized too, since they keep their row.
+func (r *userRepo) AnonymizeUser(ctx context.Context, id uint, at time.Time) error {
+	result := conn(ctx, r.db).Unscoped().Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":      ErasedUserName,
+		"email":     ErasedEmail(id),
+		"active":    false,
+		"erased_at": at,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ListUsers returns live users whose name or email contains search, newest
+// first. A nil active lists both active and deactivated users.
+func (r *userRepo) ListUsers(ctx context.Context, search string, active *bool, offset, limit int) ([]models.User, error) {
+	query := conn(ctx, r.db).Model(&models.User{})
+	if search != "" {
+		pattern := "%" + likeEscaper.Replace(search) + "%"
+		query = query.Where("(name ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+	if active != nil {
+		query = query.Where("active = ?", *active)
+	}
+	var users []models.User
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when a row was changed after the caller
+// read it, so its version no longer matches the one the caller sent.
+var ErrVersionConflict = errors.New("record was modified by another request")
+
+// bumpVersion is the assignment every write to a versioned table includes.
+var bumpVersion = gorm.Expr("version + 1")
+
+// versionMiss explains a versioned write that matched no rows: notFound when
+// the row is gone and ErrVersionConflict when it has moved past version.
+// It returns nil when neither applies, leaving the caller's own condition.
+// row must select the written row with the write's ownership conditions, so
+// a caller who cannot see the row is told it is not found rather than that
+// it changed.
+func versionMiss(row *gorm.DB, version uint, notFound error) error {
+	var current struct{ Version uint }
+	err := row.Select("version").Take(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	if current.Version != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"log"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
+)
+
+var (
+	adminTokenOnce sync.Once
+	adminToken     string
+)
+
+// adminGroup returns a group under /api/admin that requires
+// "Authorization: Bearer $ADMIN_API_TOKEN".
+func adminGroup(router *gin.Engine, path string) *gin.RouterGroup {
+	adminTokenOnce.Do(func() {
+		adminToken = config.GetenvDefault("ADMIN_API_TOKEN", "")
+		if adminToken == "" {
+			log.Println("ADMIN_API_TOKEN not set, admin endpoints will be rejected")
+		}
+	})
+	return router.Group("/api/admin"+path, middleware.AdminTokenMiddleware(adminToken))
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+func RegisterAuditRoutes(router *gin.Engine) {
+	auditService := services.NewAuditService(repository.NewAuditRepository(config.DB))
+	auditHandler := handlers.NewAuditHandler(auditService)
+	auditGroup := router.Group("/api/audit")
+	{
+		auditGroup.GET("", auditHandler.ListEvents)
+		auditGroup.GET("/verify", auditHandler.VerifyChain)
+	}
+}
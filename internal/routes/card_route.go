@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+func RegisterCardRoutes(router *gin.Engine, broker events.Broker) {
+	webhookSecret := config.GetenvDefault("CARD_WEBHOOK_SECRET", "")
+	if webhookSecret == "" {
+		log.Println("CARD_WEBHOOK_SECRET not set, card transaction webhooks will be rejected")
+	}
+
+	cardRepository := repository.NewCardRepository(config.DB)
+	expenseRepository := repository.NewExpenseRepository(config.DB)
+	auditService := services.NewAuditService(repository.NewAuditRepository(config.DB))
+	expenseService := services.NewExpenseService(redisClient(), currencyService(), expenseRepository, auditService, broker, repository.NewTransactor(config.DB))
+	cardService := services.NewCardService(cardRepository, expenseRepository, expenseService, repository.NewTransactor(config.DB))
+	cardHandler := handlers.NewCardHandler(cardService)
+
+	cardGroup := router.Group("/api/cards")
+	{
+		cardGroup.POST("/", cardHandler.RegisterCard)
+		cardGroup.POST(
+			"/transactions",
+			middleware.CardSignatureMiddleware(webhookSecret),
+			cardHandler.ReceiveTransaction,
+		)
+		cardGroup.GET("/transactions", cardHandler.ListTransactions)
+		cardGroup.POST("/transactions/:id/match", cardHandler.MatchTransaction)
+		cardGroup.GET("/attention", cardHandler.Attention)
+	}
+}
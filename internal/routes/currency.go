@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/httpclient"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+// currencyHTTPClient is shared by every currency service in the process so
+// they trip the same circuit breaker.
+var currencyHTTPClient = sync.OnceValue(func() *httpclient.Client {
+	cfg := httpclient.DefaultConfig()
+	cfg.Timeout = durationEnv("FX_HTTP_TIMEOUT", 5*time.Second)
+	return httpclient.New("currency", cfg)
+})
+
+// currencyService is the exchange-rate client shared by the expense, report
+// and card routes and the rate refresh job, so they read and refresh one
+// rate cache.
+var currencyService = sync.OnceValue(func() *services.CurrencyService {
+	currencyApi, err := config.Getenv("CURRENCY_API")
+	if err != nil {
+		log.Fatal("CURRENCY_API not set in environment")
+	}
+	ttl := durationEnv("FX_RATE_TTL", 60*time.Hour)
+	freshFor := durationEnv("FX_RATE_FRESH", 2*time.Hour)
+	return services.NewCurrencyService(redisClient(), currencyHTTPClient(), currencyApi, ttl, freshFor, config.GetenvDefault("FX_BASE_CURRENCY", "USD"))
+})
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+)
+
+// durationEnv reads a positive duration, falling back on a missing or
+// invalid value.
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(config.GetenvDefault(key, fallback.String()))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
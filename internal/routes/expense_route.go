@@ -1,31 +1,54 @@
 package routes
 
 import (
-	"log"
-	"time"
-
 	"github.com/gin-gonic/gin"
 	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
 	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
 )
 
-func RegisterExpenseRoutes(router *gin.Engine) {
-	currencyApi, err := config.Getenv("CURRENCY_API")
-	if err != nil {
-		log.Fatal("CURRENCY_API not set in environment")
-	}
+func RegisterExpenseRoutes(router *gin.Engine, broker events.Broker) {
 	expenseRepository := repository.NewExpenseRepository(config.DB)
-	currencyService := services.NewCurrencyService(config.Redis, currencyApi, 60*time.Hour)
-	expenseService := services.NewExpenseService(config.Redis, currencyService, expenseRepository)
-	expenseHandler := handlers.NewExpenseHandler(expenseService)
+	auditService := services.NewAuditService(repository.NewAuditRepository(config.DB))
+	redis := redisClient()
+	expenseService := services.NewExpenseService(redis, currencyService(), expenseRepository, auditService, broker, repository.NewTransactor(config.DB))
+	if redis != nil {
+		invalidateCache := services.NewExpenseCacheInvalidator(redis)
+		for _, event := range []string{events.ExpenseCreated, events.ExpenseUpdated, events.ExpenseDeleted, events.ExpenseRestored} {
+			broker.Subscribe(event, invalidateCache)
+		}
+	}
+	reportRepository := repository.NewReportRepository(config.DB)
+	duplicateService := services.NewDuplicateService(
+		repository.NewDuplicateRepository(config.DB),
+		reportRepository,
+		broker,
+	)
+	receiptStore := storage.NewReceiptStore(config.GetenvDefault("RECEIPTS_DIR", "./receipts"))
+	expenseHandler := handlers.NewExpenseHandler(expenseService, duplicateService, receiptStore)
+	commentService := services.NewCommentService(
+		repository.NewCommentRepository(config.DB),
+		repository.NewUserRepository(config.DB),
+		reportRepository,
+		expenseRepository,
+	)
+	commentHandler := handlers.NewCommentHandler(commentService)
 	expenseGroup := router.Group("api/expenses")
 	{
 		expenseGroup.POST("/", expenseHandler.CreateExpense)
+		expenseGroup.POST("/import", expenseHandler.ImportExpenses)
+		expenseGroup.POST("/receipts", expenseHandler.UploadReceipt)
+		expenseGroup.GET("/export", expenseHandler.ExportExpenses)
 		expenseGroup.GET("/:id", expenseHandler.GetExpenseByID)
 		expenseGroup.GET("/", expenseHandler.GetExpenses)
 		expenseGroup.PUT("/:id", expenseHandler.UpdateExpense)
+		expenseGroup.PATCH("/:id", expenseHandler.PatchExpense)
 		expenseGroup.DELETE("/:id", expenseHandler.DeleteExpense)
+		expenseGroup.POST("/:id/comments", commentHandler.AddExpenseComment)
+		expenseGroup.GET("/:id/comments", commentHandler.ListExpenseComments)
 	}
+	adminGroup(router, "").POST("/expenses/:id/restore", expenseHandler.RestoreExpense)
 }
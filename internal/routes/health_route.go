@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+// RegisterHealthRoutes reports whether the dependencies are reachable. Redis
+// being down only degrades the service, since requests bypass the cache, so
+// the check fails only when the database is unreachable. A missing job
+// worker degrades it too: scheduled maintenance stops running.
+func RegisterHealthRoutes(router *gin.Engine) {
+	jobService := services.NewJobService(repository.NewJobRepository(config.DB), 0)
+	router.GET("/health", func(c *gin.Context) {
+		status, code, database := "ok", http.StatusOK, "up"
+		if sqlDB, err := config.DB.DB(); err != nil || sqlDB.PingContext(c.Request.Context()) != nil {
+			status, code, database = "unavailable", http.StatusServiceUnavailable, "down"
+		}
+		redis := config.RedisStatus()
+		jobs := "up"
+		if up, err := jobService.WorkerUp(c.Request.Context()); err != nil || !up {
+			jobs = "down"
+		}
+		if (redis == "down" || jobs == "down") && code == http.StatusOK {
+			status = "degraded"
+		}
+		c.JSON(code, gin.H{"status": status, "database": database, "redis": redis, "jobs": jobs})
+	})
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+// UseIdempotency honours Idempotency-Key on every POST and PUT route. It must
+// be called before the routes are registered.
+func UseIdempotency(router *gin.Engine) {
+	router.Use(middleware.IdempotencyMiddleware(newIdempotencyService()))
+}
+
+func newIdempotencyService() services.IdempotencyService {
+	return services.NewIdempotencyService(
+		redisClient(),
+		repository.NewIdempotencyRepository(config.DB),
+		durationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+	)
+}
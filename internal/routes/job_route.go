@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+func RegisterJobRoutes(router *gin.Engine) {
+	jobService := services.NewJobService(repository.NewJobRepository(config.DB), 0)
+	jobHandler := handlers.NewJobHandler(jobService)
+	jobGroup := adminGroup(router, "/jobs")
+	{
+		jobGroup.GET("", jobHandler.ListJobs)
+		jobGroup.GET("/stats", jobHandler.Stats)
+		jobGroup.GET("/:id", jobHandler.GetJob)
+		jobGroup.POST("/:id/retry", jobHandler.RetryJob)
+	}
+	go watchJobWorker(context.Background(), jobService, 10*time.Minute)
+}
+
+// watchJobWorker logs while no worker process is running, since the
+// maintenance jobs (outbox.purge, retention.purge, fx.refresh, ...) only
+// run in cmd/worker.
+func watchJobWorker(ctx context.Context, jobService services.JobService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		up, err := jobService.WorkerUp(ctx)
+		if err == nil && !up {
+			log.Println("no job worker is running: scheduled jobs such as outbox.purge and retention.purge will not run until cmd/worker is started")
+		}
+	}
+}
+
+// RegisterJobHandlers wires the jobs the worker knows how to run and their
+// schedules.
+func RegisterJobHandlers(jobService services.JobService) error {
+	outboxService := services.NewOutboxService(repository.NewOutboxRepository(config.DB), events.NewBus(), nil, "")
+	jobService.Register("outbox.purge", func(ctx context.Context, _ json.RawMessage) error {
+		n, err := outboxService.PurgePublished(ctx)
+		if err == nil && n > 0 {
+			log.Printf("purged %d published outbox events", n)
+		}
+		return err
+	})
+	if err := jobService.Schedule("outbox.purge", config.GetenvDefault("OUTBOX_PURGE_SCHEDULE", "@hourly")); err != nil {
+		return err
+	}
+
+	idempotencyService := newIdempotencyService()
+	jobService.Register("idempotency.purge", func(ctx context.Context, _ json.RawMessage) error {
+		n, err := idempotencyService.PurgeExpired(ctx)
+		if err == nil && n > 0 {
+			log.Printf("purged %d expired idempotency keys", n)
+		}
+		return err
+	})
+	if err := jobService.Schedule("idempotency.purge", config.GetenvDefault("IDEMPOTENCY_PURGE_SCHEDULE", "@hourly")); err != nil {
+		return err
+	}
+
+	retentionService := services.NewRetentionService(
+		repository.NewExpenseRepository(config.DB),
+		repository.NewReportRepository(config.DB),
+		durationEnv("SOFT_DELETE_RETENTION", 90*24*time.Hour),
+	)
+	jobService.Register("retention.purge", func(ctx context.Context, _ json.RawMessage) error {
+		result, err := retentionService.PurgeDeleted(ctx)
+		if err == nil && result.Expenses+result.Reports > 0 {
+			log.Printf("purged %d deleted expenses and %d deleted reports", result.Expenses, result.Reports)
+		}
+		return err
+	})
+	if err := jobService.Schedule("retention.purge", config.GetenvDefault("RETENTION_PURGE_SCHEDULE", "@daily")); err != nil {
+		return err
+	}
+
+	rates := currencyService()
+	jobService.Register("fx.refresh", func(ctx context.Context, _ json.RawMessage) error {
+		_, err := rates.Refresh(ctx)
+		return err
+	})
+	if err := jobService.Schedule("fx.refresh", config.GetenvDefault("FX_REFRESH_SCHEDULE", "@every 1h")); err != nil {
+		return err
+	}
+	// Warm the rate cache right away instead of waiting for the first slot.
+	_, err := jobService.Enqueue(context.Background(), "fx.refresh", nil, time.Now())
+	return err
+}
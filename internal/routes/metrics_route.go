@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"expvar"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMetricsRoutes exposes the process counters, including the
+// outbound HTTP client metrics, in expvar's JSON format. They include the
+// command line and memory stats, so they are served on the admin API only.
+func RegisterMetricsRoutes(router *gin.Engine) {
+	adminGroup(router, "").GET("/debug/vars", gin.WrapH(expvar.Handler()))
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/notify"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+func RegisterNotificationRoutes(router *gin.Engine, broker events.Broker) {
+	notificationRepository := repository.NewNotificationRepository(config.DB)
+
+	channels := []services.NotificationChannel{services.NewInAppChannel(notificationRepository)}
+	if smtpAddr := config.GetenvDefault("SMTP_ADDR", ""); smtpAddr != "" {
+		mailer := notify.NewMailer(
+			smtpAddr,
+			config.GetenvDefault("SMTP_FROM", "FlyPro <no-reply@flypro.local>"),
+			config.GetenvDefault("SMTP_USERNAME", ""),
+			config.GetenvDefault("SMTP_PASSWORD", ""),
+		)
+		channels = append(channels, services.NewEmailChannel(mailer))
+	} else {
+		log.Println("SMTP_ADDR not set, email notifications are disabled")
+	}
+
+	notificationService := services.NewNotificationService(
+		notificationRepository,
+		repository.NewUserRepository(config.DB),
+		parseUserIDs(config.GetenvDefault("NOTIFY_APPROVER_IDS", "")),
+		channels...,
+	)
+	for _, event := range services.NotificationEvents {
+		broker.Subscribe(event, notificationService.HandleEvent)
+	}
+
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	notificationGroup := router.Group("/api/notifications")
+	{
+		notificationGroup.GET("", notificationHandler.ListNotifications)
+		notificationGroup.PUT("/read-all", notificationHandler.MarkAllRead)
+		notificationGroup.PUT("/:id/read", notificationHandler.MarkRead)
+		notificationGroup.PUT("/:id/unread", notificationHandler.MarkUnread)
+		notificationGroup.GET("/preferences", notificationHandler.GetPreferences)
+		notificationGroup.PUT("/preferences", notificationHandler.UpdatePreference)
+	}
+}
+
+func parseUserIDs(value string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
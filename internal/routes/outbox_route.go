@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"context"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+// StartOutbox builds the broker the other routes publish to and starts its
+// dispatcher. Events are mirrored to a Redis Stream when OUTBOX_REDIS_STREAM
+// is set.
+func StartOutbox() events.Broker {
+	var stream services.StreamAdder
+	streamName := config.GetenvDefault("OUTBOX_REDIS_STREAM", "")
+	if streamName != "" && config.Redis != nil {
+		stream = config.Redis
+	}
+	outboxService := services.NewOutboxService(
+		repository.NewOutboxRepository(config.DB),
+		events.NewBus(),
+		stream,
+		streamName,
+	)
+
+	go outboxService.Run(context.Background(), durationEnv("OUTBOX_POLL_INTERVAL", 2*time.Second))
+	return outboxService
+}
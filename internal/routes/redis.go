@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+// redisClient returns the shared Redis client for the caches, failing fast
+// while Redis is down, or nil when Redis is not configured.
+func redisClient() services.RedisClient {
+	if config.Redis == nil {
+		return nil
+	}
+	return services.NewGuardedRedis(config.Redis, services.RedisHealth{
+		Healthy:  config.RedisHealthy,
+		Epoch:    config.CacheEpoch,
+		MarkDown: config.MarkRedisDown,
+	})
+}
@@ -3,13 +3,15 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
 	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
 	"github.com/onunkwor/flypro-assestment-v2/internal/middleware"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
 )
 
-func RegisterReportRoutes(router *gin.Engine) {
+func RegisterReportRoutes(router *gin.Engine, broker events.Broker) {
 	reportRepository := repository.NewReportRepository(config.DB)
 	expenseRepository := repository.NewExpenseRepository(config.DB)
 	userRepository := repository.NewUserRepository(config.DB)
@@ -19,9 +21,26 @@ func RegisterReportRoutes(router *gin.Engine) {
 		expenseRepository,
 		userRepository,
 		config.Redis,
+		services.NewAuditService(repository.NewAuditRepository(config.DB)),
+		broker,
+		repository.NewTransactor(config.DB),
+		currencyService(),
+		config.GetenvDefault("REPORTING_CURRENCY", "USD"),
 	)
 
-	reportHandler := handlers.NewReportHandler(reportService)
+	receiptStore := storage.NewReceiptStore(config.GetenvDefault("RECEIPTS_DIR", "./receipts"))
+
+	duplicateService := services.NewDuplicateService(repository.NewDuplicateRepository(config.DB), reportRepository, broker)
+
+	reportHandler := handlers.NewReportHandler(reportService, duplicateService, receiptStore)
+
+	commentService := services.NewCommentService(
+		repository.NewCommentRepository(config.DB),
+		userRepository,
+		reportRepository,
+		expenseRepository,
+	)
+	commentHandler := handlers.NewCommentHandler(commentService)
 
 	reportRoutes := router.Group("/api/reports")
 	{
@@ -37,6 +56,36 @@ func RegisterReportRoutes(router *gin.Engine) {
 			middleware.ReportOwnershipMiddleware(reportRepository),
 			reportHandler.SubmitReport,
 		)
+		reportRoutes.PUT("/:id/approve", reportHandler.ApproveReport)
+		reportRoutes.PUT("/:id/reject", reportHandler.RejectReport)
 		reportRoutes.GET("/", reportHandler.GetReportExpenses)
+		reportRoutes.GET(
+			"/:id",
+			middleware.ReportReviewerMiddleware(reportRepository, userRepository),
+			reportHandler.GetReport,
+		)
+		reportRoutes.GET(
+			"/:id/export",
+			middleware.ReportOwnershipMiddleware(reportRepository),
+			reportHandler.ExportReport,
+		)
+		reportRoutes.GET(
+			"/:id/pdf",
+			middleware.ReportReviewerMiddleware(reportRepository, userRepository),
+			reportHandler.ReportPDF,
+		)
+		reportRoutes.GET(
+			"/:id/duplicates",
+			middleware.ReportReviewerMiddleware(reportRepository, userRepository),
+			reportHandler.ListDuplicates,
+		)
+		reportRoutes.POST("/:id/comments", commentHandler.AddReportComment)
+		reportRoutes.GET("/:id/comments", commentHandler.ListReportComments)
+		reportRoutes.DELETE(
+			"/:id",
+			middleware.ReportOwnershipMiddleware(reportRepository),
+			reportHandler.DeleteReport,
+		)
 	}
+	adminGroup(router, "").POST("/reports/:id/restore", reportHandler.RestoreReport)
 }
@@ -6,16 +6,27 @@ import (
 	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
 )
 
 func RegisterUserRoutes(router *gin.Engine) {
 	userRepo := repository.NewUserRepository(config.DB)
-	userService := services.NewUserService(config.Redis, userRepo)
-	userHandler := handlers.NewUserHandler(userService)
+	auditService := services.NewAuditService(repository.NewAuditRepository(config.DB))
+	userService := services.NewUserService(redisClient(), userRepo, repository.NewNotificationRepository(config.DB), repository.NewCommentRepository(config.DB), auditService, repository.NewTransactor(config.DB))
+	receiptStore := storage.NewReceiptStore(config.GetenvDefault("RECEIPTS_DIR", "./receipts"))
+	exportService := services.NewUserExportService(userRepo, repository.NewExpenseRepository(config.DB), repository.NewReportRepository(config.DB), receiptStore)
+	userHandler := handlers.NewUserHandler(userService, exportService)
 	userGroup := router.Group("/api/users")
 	{
 		userGroup.POST("/", userHandler.CreateUser)
+		userGroup.GET("/", userHandler.ListUsers)
 		userGroup.GET("/:id", userHandler.GetUserByID)
+		userGroup.PUT("/:id", userHandler.UpdateUser)
+		userGroup.DELETE("/:id", userHandler.DeleteUser)
+		userGroup.POST("/:id/deactivate", userHandler.DeactivateUser)
+		userGroup.POST("/:id/reactivate", userHandler.ReactivateUser)
+		userGroup.GET("/:id/export", userHandler.ExportUserData)
+		userGroup.POST("/:id/erase", userHandler.EraseUser)
 
 	}
 }
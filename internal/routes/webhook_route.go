@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onunkwor/flypro-assestment-v2/internal/config"
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/handlers"
+	"github.com/onunkwor/flypro-assestment-v2/internal/httpclient"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+)
+
+func RegisterWebhookRoutes(router *gin.Engine, broker events.Broker) {
+	webhookService := services.NewWebhookService(
+		repository.NewWebhookRepository(config.DB),
+		webhookHTTPClient(),
+	)
+	for _, event := range services.WebhookEvents {
+		broker.Subscribe(event, webhookService.HandleEvent)
+	}
+
+	go webhookService.Run(context.Background(), durationEnv("WEBHOOK_POLL_INTERVAL", 5*time.Second))
+
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	webhookGroup := adminGroup(router, "/webhooks")
+	{
+		webhookGroup.POST("", webhookHandler.CreateSubscription)
+		webhookGroup.GET("", webhookHandler.ListSubscriptions)
+		webhookGroup.DELETE("/:id", webhookHandler.DeleteSubscription)
+		webhookGroup.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+		webhookGroup.POST("/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+	}
+}
+
+// webhookHTTPClient does not retry: the delivery worker already retries on
+// its own, much longer schedule. The breaker stops a dead endpoint from
+// tying up the worker, and PublicOnly keeps subscribers from pointing
+// deliveries at internal services.
+func webhookHTTPClient() *httpclient.Client {
+	cfg := httpclient.DefaultConfig()
+	cfg.Timeout = durationEnv("WEBHOOK_HTTP_TIMEOUT", 15*time.Second)
+	cfg.MaxRetries = 0
+	cfg.PublicOnly = true
+	return httpclient.New("webhooks", cfg)
+}
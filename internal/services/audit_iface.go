@@ -0,0 +1,7 @@
+package services
+
+import "context"
+
+type AuditLogger interface {
+	Record(ctx context.Context, entries ...AuditEntry) error
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+const (
+	AuditEntityUser    = "user"
+	AuditEntityExpense = "expense"
+	AuditEntityReport  = "report"
+)
+
+// auditIgnoredFields are bookkeeping or nested fields that would only add
+// noise to a diff.
+var auditIgnoredFields = map[string]bool{
+	"created_at":     true,
+	"updated_at":     true,
+	"user":           true,
+	"expenses":       true,
+	"status_history": true,
+}
+
+// auditRedactedFields are personal data kept out of the chain per entity
+// type. Events can never be edited, so storing these values would leave a
+// copy behind when a user is erased; the diff records that the field
+// changed, but not its values.
+var auditRedactedFields = map[string]map[string]bool{
+	AuditEntityUser: {"name": true, "email": true},
+}
+
+const auditRedacted = "[redacted]"
+
+var errAuditChainBroken = errors.New("audit chain broken")
+
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   uint
+	// ActorID is used when the request did not identify an actor.
+	ActorID uint
+	Before  interface{}
+	After   interface{}
+}
+
+type AuditVerification struct {
+	Valid    bool `json:"valid"`
+	Checked  int  `json:"checked"`
+	BrokenAt uint `json:"broken_at,omitempty"`
+}
+
+type AuditService interface {
+	AuditLogger
+	List(ctx context.Context, entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, error)
+	Verify(ctx context.Context) (*AuditVerification, error)
+}
+
+type auditSrv struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditSrv{repo: repo}
+}
+
+// Record appends one event per entry to the chain. Call it inside the
+// transaction making the change, so a change is never committed without its
+// event; a batch of entries takes the chain lock once.
+func (s *auditSrv) Record(ctx context.Context, entries ...AuditEntry) error {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	requestID := utils.RequestIDFromContext(ctx)
+	actor := utils.ActorIDFromContext(ctx)
+	batch := make([]*models.AuditEvent, len(entries))
+	for i, entry := range entries {
+		event := &models.AuditEvent{
+			Action:     entry.Action,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			Changes:    redactAudit(entry.EntityType, auditDiff(entry.Before, entry.After)),
+			RequestID:  requestID,
+			CreatedAt:  now,
+		}
+		if id := actor; id != 0 {
+			event.ActorID = &id
+		} else if id := entry.ActorID; id != 0 {
+			event.ActorID = &id
+		}
+		batch[i] = event
+	}
+	if err := s.repo.Append(ctx, batch, auditHash); err != nil {
+		return fmt.Errorf("record audit events: %w", err)
+	}
+	return nil
+}
+
+func (s *auditSrv) List(ctx context.Context, entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, error) {
+	return s.repo.List(ctx, entityType, entityID, offset, limit)
+}
+
+// Verify walks the whole chain and reports the first event whose hash or
+// link to its predecessor no longer matches.
+func (s *auditSrv) Verify(ctx context.Context) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	prev := ""
+	err := s.repo.Stream(ctx, func(e *models.AuditEvent) error {
+		result.Checked++
+		if e.PrevHash != prev || auditHash(e, e.PrevHash) != e.Hash {
+			result.Valid = false
+			result.BrokenAt = e.ID
+			return errAuditChainBroken
+		}
+		prev = e.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+	return result, nil
+}
+
+func auditHash(e *models.AuditEvent, prevHash string) string {
+	changes, _ := json.Marshal(e.Changes)
+	actor := ""
+	if e.ActorID != nil {
+		actor = strconv.FormatUint(uint64(*e.ActorID), 10)
+	}
+	payload := strings.Join([]string{
+		prevHash,
+		actor,
+		e.Action,
+		e.EntityType,
+		strconv.FormatUint(uint64(e.EntityID), 10),
+		string(changes),
+		e.RequestID,
+		strconv.FormatInt(e.CreatedAt.UnixMicro(), 10),
+	}, "|")
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditDiff compares the JSON representations of before and after and
+// returns the fields that changed. Either side may be nil for creations and
+// deletions.
+func auditDiff(before, after interface{}) map[string]models.AuditChange {
+	b, a := toAuditMap(before), toAuditMap(after)
+	changes := map[string]models.AuditChange{}
+	for key, av := range a {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if bv, ok := b[key]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[key] = models.AuditChange{Before: b[key], After: av}
+		}
+	}
+	for key, bv := range b {
+		if _, ok := a[key]; !ok && !auditIgnoredFields[key] {
+			changes[key] = models.AuditChange{Before: bv, After: nil}
+		}
+	}
+	return changes
+}
+
+// redactAudit replaces the values of the entity type's personal fields.
+func redactAudit(entityType string, changes map[string]models.AuditChange) map[string]models.AuditChange {
+	for key, change := range changes {
+		if !auditRedactedFields[entityType][key] {
+			continue
+		}
+		if change.Before != nil {
+			change.Before = auditRedacted
+		}
+		if change.After != nil {
+			change.After = auditRedacted
+		}
+		changes[key] = change
+	}
+	return changes
+}
+
+func toAuditMap(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return out
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return out
+	}
+	_ = json.Unmarshal(raw, &out)
+	return out
+}
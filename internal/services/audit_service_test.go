@@ -0,0 +1,160 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored []models.AuditEvent
+	mockRepo := mocks.NewMockAuditRepository(ctrl)
+	mockRepo.EXPECT().Append(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, batch []*models.AuditEvent, seal func(*models.AuditEvent, string) string) error {
+			for _, e := range batch {
+				if len(stored) > 0 {
+					e.PrevHash = stored[len(stored)-1].Hash
+				}
+				e.Hash = seal(e, e.PrevHash)
+				e.ID = uint(len(stored) + 1)
+				stored = append(stored, *e)
+			}
+			return nil
+		}).Times(2)
+	mockRepo.EXPECT().Stream(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(*models.AuditEvent) error) error {
+			for i := range stored {
+				if err := fn(&stored[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Times(2)
+
+	svc := services.NewAuditService(mockRepo)
+	ctx := utils.WithRequestID(utils.WithActorID(context.Background(), 9), "req-1")
+
+	before := &models.Expense{Amount: 10, Currency: "USD", Description: "Lunch"}
+	after := &models.Expense{Amount: 12, Currency: "USD", Description: "Lunch"}
+	if err := svc.Record(ctx, services.AuditEntry{Action: "create", EntityType: services.AuditEntityExpense, EntityID: 1, After: before}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := svc.Record(ctx,
+		services.AuditEntry{Action: "update", EntityType: services.AuditEntityExpense, EntityID: 1, Before: before, After: after},
+		services.AuditEntry{Action: "delete", EntityType: services.AuditEntityExpense, EntityID: 1, Before: after})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	update := stored[1]
+	if len(update.Changes) != 1 || update.Changes["amount"].After != float64(12) {
+		t.Errorf("expected only the amount to change, got %+v", update.Changes)
+	}
+	if update.ActorID == nil || *update.ActorID != 9 || update.RequestID != "req-1" {
+		t.Errorf("expected actor 9 and request req-1, got %v %q", update.ActorID, update.RequestID)
+	}
+
+	result, err := svc.Verify(context.Background())
+	if err != nil || !result.Valid || result.Checked != 3 {
+		t.Fatalf("expected a valid chain of 3, got %+v (%v)", result, err)
+	}
+
+	stored[0].Changes["amount"] = models.AuditChange{After: float64(1)}
+	result, err = svc.Verify(context.Background())
+	if err != nil || result.Valid || result.BrokenAt != 1 {
+		t.Fatalf("expected tampering at event 1, got %+v (%v)", result, err)
+	}
+}
+
+func TestAuditFailureRollsBackTheChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	mockAudit.EXPECT().Append(gomock.Any(), gomock.Len(1), gomock.Any()).Return(errDBDown)
+	mockRepo := mocks.NewMockExpenseRepository(ctrl)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	tx := &recordingTransactor{}
+	svc := services.NewExpenseService(nil, nil, mockRepo, services.NewAuditService(mockAudit), nil, tx)
+	err := svc.CreateExpense(context.Background(), &models.Expense{UserID: 1, Amount: 5, Currency: "USD"})
+	if !errors.Is(err, errDBDown) {
+		t.Fatalf("expected the audit failure, got %v", err)
+	}
+	if tx.committed {
+		t.Error("expected the transaction to roll back")
+	}
+}
+
+func TestImportRecordsOneAuditBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	mockAudit.EXPECT().Append(gomock.Any(), gomock.Len(3), gomock.Any()).Return(nil).Times(1)
+	mockRepo := mocks.NewMockExpenseRepository(ctrl)
+	mockRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(3)).Return(nil)
+
+	svc := services.NewExpenseService(nil, nil, mockRepo, services.NewAuditService(mockAudit), nil, &recordingTransactor{})
+	expenses := []*models.Expense{
+		{UserID: 1, Amount: 1, Currency: "USD"},
+		{UserID: 1, Amount: 2, Currency: "USD"},
+		{UserID: 1, Amount: 3, Currency: "USD"},
+	}
+	if _, err := svc.ImportExpenses(context.Background(), expenses, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// recordingTransactor runs the unit of work directly and notes whether it
+// would have been committed.
+type recordingTransactor struct {
+	committed bool
+}
+
+func (r *recordingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	r.committed = true
+	return nil
+}
+
+func TestAuditRedactsPersonalData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored []*models.AuditEvent
+	mockRepo := mocks.NewMockAuditRepository(ctrl)
+	mockRepo.EXPECT().Append(gomock.Any(), gomock.Len(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, batch []*models.AuditEvent, _ func(*models.AuditEvent, string) string) error {
+			stored = append(stored, batch...)
+			return nil
+		})
+
+	svc := services.NewAuditService(mockRepo)
+	before := &models.User{Name: "Ada", Email: "ada@example.com", Active: true}
+	after := &models.User{Name: "Ada L", Email: "ada@example.org", Active: false}
+	if err := svc.Record(context.Background(), services.AuditEntry{Action: "update", EntityType: services.AuditEntityUser, EntityID: 1, Before: before, After: after}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changes := stored[0].Changes
+	for _, field := range []string{"name", "email"} {
+		if change := changes[field]; change.Before != "[redacted]" || change.After != "[redacted]" {
+			t.Errorf("expected %s to be redacted, got %+v", field, change)
+		}
+	}
+	if changes["active"].Before != true || changes["active"].After != false {
+		t.Errorf("expected active to be kept, got %+v", changes["active"])
+	}
+}
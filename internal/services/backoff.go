@@ -0,0 +1,16 @@
+package services
+
+import "time"
+
+// exponentialBackoff returns how long to wait after the given number of
+// failed attempts: base after the first, doubling each time, capped at max.
+func exponentialBackoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+	return wait
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// negativeEntry marks a cached "not found". Neither codec produces it.
+const negativeEntry = "\x00notfound"
+
+// Codec turns cached values into bytes and back.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+var (
+	// JSONCodec is readable with redis-cli and the default.
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec is more compact, but only readable from Go.
+	GobCodec Codec = gobCodec{}
+)
+
+type CacheOptions struct {
+	TTL time.Duration
+	// Jitter spreads expiry by up to this fraction of TTL either way, so
+	// entries written together do not all expire together.
+	Jitter float64
+	// NotFound is the error a loader returns for a missing record. When set
+	// with NegativeTTL, misses are cached too and NotFound is returned for
+	// them without calling the loader.
+	NotFound    error
+	NegativeTTL time.Duration
+	Codec       Codec
+}
+
+// Cache is a typed cache-aside over Redis. Redis is never the source of
+// truth: when it is down or returns garbage the cache logs, counts the
+// error and behaves as a miss, so callers only see errors from the loader.
+// A nil client disables caching.
+type Cache[V any] struct {
+	name    string
+	redis   RedisClient
+	opts    CacheOptions
+	metrics *CacheMetrics
+}
+
+func NewCache[V any](name string, r RedisClient, opts CacheOptions) *Cache[V] {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec
+	}
+	return &Cache[V]{name: name, redis: r, opts: opts, metrics: publishCacheMetrics(name)}
+}
+
+func (c *Cache[V]) Metrics() *CacheMetrics {
+	return c.metrics
+}
+
+// GetOrLoad returns the cached value for key, calling load and caching its
+// result on a miss.
+func (c *Cache[V]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (V, error)) (V, error) {
+	value, found, err := c.Get(ctx, key)
+	if found {
+		return value, err
+	}
+	value, err = load(ctx)
+	switch {
+	case err == nil:
+		c.Set(ctx, key, value)
+	case c.negative() && errors.Is(err, c.opts.NotFound):
+		c.write(ctx, key, []byte(negativeEntry), c.opts.NegativeTTL)
+	}
+	return value, err
+}
+
+// Get returns the cached value for key. A cached "not found" is reported
+// as a hit with the NotFound error.
+func (c *Cache[V]) Get(ctx context.Context, key string) (V, bool, error) {
+	var zero V
+	if c.redis == nil {
+		return zero, false, nil
+	}
+	val, err := c.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		switch {
+		case err == redis.Nil:
+			c.metrics.Misses.Add(1)
+		case errors.Is(err, ErrRedisUnavailable):
+			c.metrics.Bypassed.Add(1)
+		default:
+			c.fail("get", key, err)
+		}
+		return zero, false, nil
+	}
+	if c.negative() && string(val) == negativeEntry {
+		c.metrics.NegativeHits.Add(1)
+		return zero, true, c.opts.NotFound
+	}
+	var value V
+	if err := c.opts.Codec.Unmarshal(val, &value); err != nil {
+		c.fail("decode", key, err)
+		c.Delete(ctx, key)
+		return zero, false, nil
+	}
+	c.metrics.Hits.Add(1)
+	return value, true, nil
+}
+
+// Set caches value under key for the configured TTL, with jitter.
+func (c *Cache[V]) Set(ctx context.Context, key string, value V) {
+	if c.redis == nil {
+		return
+	}
+	data, err := c.opts.Codec.Marshal(value)
+	if err != nil {
+		c.fail("encode", key, err)
+		return
+	}
+	c.write(ctx, key, data, c.opts.TTL)
+}
+
+// Delete drops keys from the cache.
+func (c *Cache[V]) Delete(ctx context.Context, keys ...string) {
+	if err := c.Purge(ctx, keys...); err != nil {
+		c.fail("del", keys[0], err)
+	}
+}
+
+// Purge drops keys like Delete, but returns the error rather than logging
+// it, for callers that must know the keys are gone.
+func (c *Cache[V]) Purge(ctx context.Context, keys ...string) error {
+	if c.redis == nil || len(keys) == 0 {
+		return nil
+	}
+	return c.redis.Del(ctx, keys...).Err()
+}
+
+func (c *Cache[V]) write(ctx context.Context, key string, data []byte, ttl time.Duration) {
+	if err := c.redis.Set(ctx, key, data, c.jitter(ttl)).Err(); err != nil {
+		c.fail("set", key, err)
+		return
+	}
+	c.metrics.Sets.Add(1)
+}
+
+func (c *Cache[V]) jitter(ttl time.Duration) time.Duration {
+	if c.opts.Jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	spread := time.Duration(float64(ttl) * c.opts.Jitter)
+	return ttl - spread + time.Duration(rand.Int63n(int64(2*spread)+1))
+}
+
+func (c *Cache[V]) negative() bool {
+	return c.opts.NotFound != nil && c.opts.NegativeTTL > 0
+}
+
+func (c *Cache[V]) fail(op, key string, err error) {
+	if errors.Is(err, ErrRedisUnavailable) {
+		// Already reported by the health check; don't log every request.
+		c.metrics.Bypassed.Add(1)
+		return
+	}
+	c.metrics.Errors.Add(1)
+	log.Printf("cache %s: %s %s: %v", c.name, op, key, err)
+}
+
+// CacheMetrics counts cache traffic. Errors covers Redis failures and
+// entries that could not be encoded or decoded, Bypassed the commands
+// skipped while Redis is known to be down; all of them are served as misses.
+type CacheMetrics struct {
+	Hits         expvar.Int
+	NegativeHits expvar.Int
+	Misses       expvar.Int
+	Sets         expvar.Int
+	Errors       expvar.Int
+	Bypassed     expvar.Int
+}
+
+var (
+	cacheMetricsMu sync.Mutex
+	cacheMetrics   = map[string]*CacheMetrics{}
+)
+
+// publishCacheMetrics returns the metrics for name, registering them with
+// expvar as "cache.<name>" the first time.
+func publishCacheMetrics(name string) *CacheMetrics {
+	cacheMetricsMu.Lock()
+	defer cacheMetricsMu.Unlock()
+	if m, ok := cacheMetrics[name]; ok {
+		return m
+	}
+	m := &CacheMetrics{}
+	vars := new(expvar.Map)
+	vars.Set("hits", &m.Hits)
+	vars.Set("negative_hits", &m.NegativeHits)
+	vars.Set("misses", &m.Misses)
+	vars.Set("sets", &m.Sets)
+	vars.Set("errors", &m.Errors)
+	vars.Set("bypassed", &m.Bypassed)
+	expvar.Publish("cache."+name, vars)
+	cacheMetrics[name] = m
+	return m
+}
@@ -0,0 +1,203 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+// ttlWithin matches an expiry of base spread by up to jitter either way.
+func ttlWithin(base time.Duration, jitter float64) gomock.Matcher {
+	spread := time.Duration(float64(base) * jitter)
+	return gomock.Cond(func(x any) bool {
+		ttl, ok := x.(time.Duration)
+		return ok && ttl >= base-spread && ttl <= base+spread
+	})
+}
+
+type cachedItem struct {
+	ID   uint
+	Name string
+}
+
+var (
+	errItemNotFound = errors.New("item not found")
+	errDBDown       = errors.New("db down")
+)
+
+func TestCacheGetOrLoad(t *testing.T) {
+	item := &cachedItem{ID: 1, Name: "widget"}
+	tests := []struct {
+		name         string
+		codec        services.Codec
+		mockRedis    func(r *mocks.MockRedisClient)
+		loadErr      error
+		expectLoad   bool
+		expectedErr  error
+		expectedItem *cachedItem
+		metric       func(m *services.CacheMetrics) int64
+		metricCount  int64
+	}{
+		{
+			name: "MissLoadsAndStores",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("", redis.Nil))
+				r.EXPECT().Set(gomock.Any(), "item:1", []byte(`{"ID":1,"Name":"widget"}`), ttlWithin(time.Hour, 0.2)).
+					Return(redis.NewStatusResult("OK", nil))
+			},
+			expectLoad:   true,
+			expectedItem: item,
+			metric:       func(m *services.CacheMetrics) int64 { return m.Misses.Value() },
+		},
+		{
+			name: "HitSkipsLoader",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult(`{"ID":1,"Name":"widget"}`, nil))
+			},
+			expectedItem: item,
+			metric:       func(m *services.CacheMetrics) int64 { return m.Hits.Value() },
+		},
+		{
+			name:  "GobCodec",
+			codec: services.GobCodec,
+			mockRedis: func(r *mocks.MockRedisClient) {
+				data, _ := services.GobCodec.Marshal(item)
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult(string(data), nil))
+			},
+			expectedItem: item,
+			metric:       func(m *services.CacheMetrics) int64 { return m.Hits.Value() },
+		},
+		{
+			name: "NotFoundIsCachedBriefly",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("", redis.Nil))
+				r.EXPECT().Set(gomock.Any(), "item:1", gomock.Any(), ttlWithin(time.Minute, 0.2)).
+					Return(redis.NewStatusResult("OK", nil))
+			},
+			loadErr:     errItemNotFound,
+			expectLoad:  true,
+			expectedErr: errItemNotFound,
+			metric:      func(m *services.CacheMetrics) int64 { return m.Sets.Value() },
+		},
+		{
+			name: "CachedNotFoundSkipsLoader",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("\x00notfound", nil))
+			},
+			expectedErr: errItemNotFound,
+			metric:      func(m *services.CacheMetrics) int64 { return m.NegativeHits.Value() },
+		},
+		{
+			name: "OtherErrorsAreNotCached",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("", redis.Nil))
+			},
+			loadErr:     errDBDown,
+			expectLoad:  true,
+			expectedErr: errDBDown,
+		},
+		{
+			name: "CorruptEntryIsDropped",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("{not json", nil))
+				r.EXPECT().Del(gomock.Any(), "item:1").Return(redis.NewIntResult(1, nil))
+				r.EXPECT().Set(gomock.Any(), "item:1", gomock.Any(), gomock.Any()).Return(redis.NewStatusResult("OK", nil))
+			},
+			expectLoad:   true,
+			expectedItem: item,
+			metric:       func(m *services.CacheMetrics) int64 { return m.Errors.Value() },
+		},
+		{
+			name: "RedisDownFallsBackToLoader",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("", errors.New("connection refused")))
+				r.EXPECT().Set(gomock.Any(), "item:1", gomock.Any(), gomock.Any()).Return(redis.NewStatusResult("", errors.New("connection refused")))
+			},
+			expectLoad:   true,
+			expectedItem: item,
+			metric:       func(m *services.CacheMetrics) int64 { return m.Errors.Value() },
+			metricCount:  2,
+		},
+		{
+			name: "RedisKnownDownIsBypassed",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "item:1").Return(redis.NewStringResult("", services.ErrRedisUnavailable))
+				r.EXPECT().Set(gomock.Any(), "item:1", gomock.Any(), gomock.Any()).Return(redis.NewStatusResult("", services.ErrRedisUnavailable))
+			},
+			expectLoad:   true,
+			expectedItem: item,
+			metric:       func(m *services.CacheMetrics) int64 { return m.Bypassed.Value() },
+			metricCount:  2,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.mockRedis(mockRedis)
+			cache := services.NewCache[*cachedItem](fmt.Sprintf("test_get_or_load_%d", i), mockRedis, services.CacheOptions{
+				TTL:         time.Hour,
+				Jitter:      0.2,
+				NotFound:    errItemNotFound,
+				NegativeTTL: time.Minute,
+				Codec:       tt.codec,
+			})
+
+			loaded := false
+			got, err := cache.GetOrLoad(context.Background(), "item:1", func(context.Context) (*cachedItem, error) {
+				loaded = true
+				if tt.loadErr != nil {
+					return nil, tt.loadErr
+				}
+				return item, nil
+			})
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr == nil && tt.loadErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if loaded != tt.expectLoad {
+				t.Errorf("expected loader called %v, got %v", tt.expectLoad, loaded)
+			}
+			if tt.expectedItem != nil && (got == nil || *got != *tt.expectedItem) {
+				t.Errorf("expected %+v, got %+v", tt.expectedItem, got)
+			}
+			if tt.metric != nil {
+				if tt.metricCount == 0 {
+					tt.metricCount = 1
+				}
+				if got := tt.metric(cache.Metrics()); got != tt.metricCount {
+					t.Errorf("expected the metric to count %d, got %d", tt.metricCount, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheWithoutRedisAlwaysLoads(t *testing.T) {
+	cache := services.NewCache[int]("test_no_redis", nil, services.CacheOptions{TTL: time.Minute})
+	calls := 0
+	for i := 0; i < 2; i++ {
+		v, err := cache.GetOrLoad(context.Background(), "n", func(context.Context) (int, error) {
+			calls++
+			return 7, nil
+		})
+		if err != nil || v != 7 {
+			t.Fatalf("expected 7, got %v (%v)", v, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected the loader on every call, got %d", calls)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+const cardMatchWindow = 3 * 24 * time.Hour
+
+var (
+	ErrCardNotFound          = errors.New("service: card not found")
+	ErrTransactionNotFound   = errors.New("service: card transaction not found")
+	ErrTransactionAlreadySet = errors.New("service: card transaction already linked to an expense")
+	ErrExpenseAlreadyLinked  = errors.New("service: expense already linked to a card transaction")
+)
+
+var expenseCategories = map[string]bool{"travel": true, "meals": true, "office": true, "supplies": true}
+
+type CardService interface {
+	RegisterCard(ctx context.Context, card *models.CorporateCard) error
+	IngestTransaction(ctx context.Context, cardToken string, txn *models.CardTransaction) (*models.CardTransaction, error)
+	MatchTransaction(ctx context.Context, txnID uint, expenseID uint, userID uint) error
+	ListTransactions(ctx context.Context, userID uint, status string, offset, limit int) ([]models.CardTransaction, error)
+	ListExpensesMissingReceipts(ctx context.Context, userID uint) ([]models.Expense, error)
+}
+
+type cardSrv struct {
+	repo       repository.CardRepository
+	expenses   repository.ExpenseRepository
+	expenseSvc ExpenseService
+	tx         repository.Transactor
+}
+
+func NewCardService(repo repository.CardRepository, expenses repository.ExpenseRepository, expenseSvc ExpenseService, tx repository.Transactor) CardService {
+	return &cardSrv{repo: repo, expenses: expenses, expenseSvc: expenseSvc, tx: tx}
+}
+
+func (s *cardSrv) RegisterCard(ctx context.Context, card *models.CorporateCard) error {
+	return s.repo.CreateCard(ctx, card)
+}
+
+// IngestTransaction stores a charge reported by the issuer and reconciles
+// it: an existing expense with the same amount, currency and a nearby date
+// is linked, otherwise a draft expense is created for the cardholder. The
+// charge, the draft expense and the link are written in one transaction.
+// Redelivered charges are recognised by their issuer ID and returned as-is,
+// including one that arrives while the first delivery is being stored.
+func (s *cardSrv) IngestTransaction(ctx context.Context, cardToken string, txn *models.CardTransaction) (*models.CardTransaction, error) {
+	existing, err := s.repo.GetTransactionByExternalID(ctx, txn.ExternalID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrCardTransactionNotFound) {
+		return nil, err
+	}
+
+	card, err := s.repo.GetCardByToken(ctx, cardToken)
+	if err != nil {
+		if errors.Is(err, repository.ErrCardNotFound) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+	txn.CardID = card.ID
+	txn.UserID = card.UserID
+	txn.Currency = strings.ToUpper(txn.Currency)
+	txn.Category = strings.ToLower(strings.TrimSpace(txn.Category))
+	txn.Status = models.CardTransactionUnmatched
+	err = withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		return s.reconcile(ctx, txn)
+	})
+	if errors.Is(err, repository.ErrCardTransactionExists) {
+		return s.repo.GetTransactionByExternalID(ctx, txn.ExternalID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// reconcile records the charge and links it to a matching or new expense.
+func (s *cardSrv) reconcile(ctx context.Context, txn *models.CardTransaction) error {
+	if err := s.repo.CreateTransaction(ctx, txn); err != nil {
+		return err
+	}
+
+	candidates, err := s.repo.FindMatchCandidates(ctx, txn.UserID, txn.Amount, txn.Currency,
+		txn.TransactedAt.Add(-cardMatchWindow), txn.TransactedAt.Add(cardMatchWindow))
+	if err != nil {
+		return err
+	}
+	if match := bestMatch(candidates, txn); match != nil {
+		err := s.link(ctx, txn, match.ID, models.CardTransactionMatched)
+		if errors.Is(err, ErrExpenseAlreadyLinked) {
+			// Another charge claimed the expense since the candidates were
+			// read; leave this one unmatched for the cardholder.
+			log.Printf("card transaction %s: expense %d was linked concurrently", txn.ExternalID, match.ID)
+			return nil
+		}
+		return err
+	}
+
+	if !expenseCategories[txn.Category] {
+		return nil
+	}
+	expense := &models.Expense{
+		UserID:      txn.UserID,
+		Amount:      txn.Amount,
+		Currency:    txn.Currency,
+		Category:    txn.Category,
+		Description: txn.Merchant,
+		Status:      "draft",
+		BaseModel:   models.BaseModel{CreatedAt: txn.TransactedAt},
+	}
+	if err := s.expenseSvc.CreateExpense(ctx, expense); err != nil {
+		if errors.Is(err, ErrCurrencyConversionFailed) || errors.Is(err, ErrUserInactive) || errors.Is(err, ErrUserNotFound) {
+			// The charge stays unmatched and is surfaced to the cardholder.
+			log.Printf("card transaction %s: no draft expense for user %d: %v", txn.ExternalID, txn.UserID, err)
+			return nil
+		}
+		return err
+	}
+	return s.link(ctx, txn, expense.ID, models.CardTransactionCreated)
+}
+
+func (s *cardSrv) MatchTransaction(ctx context.Context, txnID uint, expenseID uint, userID uint) error {
+	txn, err := s.repo.GetTransactionByID(ctx, txnID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCardTransactionNotFound) {
+			return ErrTransactionNotFound
+		}
+		return err
+	}
+	if txn.UserID != userID {
+		return ErrInvalidOwnership
+	}
+	if txn.ExpenseID != nil {
+		return ErrTransactionAlreadySet
+	}
+	expense, err := s.expenses.GetExpenseByID(ctx, expenseID)
+	if err != nil {
+		return err
+	}
+	if expense.UserID != userID {
+		return ErrInvalidOwnership
+	}
+	return s.link(ctx, txn, expense.ID, models.CardTransactionMatched)
+}
+
+func (s *cardSrv) ListTransactions(ctx context.Context, userID uint, status string, offset, limit int) ([]models.CardTransaction, error) {
+	return s.repo.ListTransactions(ctx, userID, status, offset, limit)
+}
+
+func (s *cardSrv) ListExpensesMissingReceipts(ctx context.Context, userID uint) ([]models.Expense, error) {
+	return s.repo.ListExpensesMissingReceipts(ctx, userID)
+}
+
+func (s *cardSrv) link(ctx context.Context, txn *models.CardTransaction, expenseID uint, status string) error {
+	if err := s.repo.LinkExpense(ctx, txn.ID, expenseID, status); err != nil {
+		if errors.Is(err, repository.ErrExpenseAlreadyLinked) {
+			return ErrExpenseAlreadyLinked
+		}
+		return err
+	}
+	txn.ExpenseID = &expenseID
+	txn.Status = status
+	return nil
+}
+
+// bestMatch prefers a candidate whose description mentions the merchant and
+// otherwise falls back to the one closest in time to the charge.
+func bestMatch(candidates []models.Expense, txn *models.CardTransaction) *models.Expense {
+	merchant := strings.ToLower(strings.TrimSpace(txn.Merchant))
+	var best *models.Expense
+	var bestGap time.Duration
+	for i := range candidates {
+		c := &candidates[i]
+		if merchant != "" && strings.Contains(strings.ToLower(c.Description), merchant) {
+			return c
+		}
+		gap := c.CreatedAt.Sub(txn.TransactedAt)
+		if gap < 0 {
+			gap = -gap
+		}
+		if best == nil || gap < bestGap {
+			best, bestGap = c, gap
+		}
+	}
+	return best
+}
@@ -0,0 +1,257 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIngestTransaction(t *testing.T) {
+	chargedAt := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	card := &models.CorporateCard{BaseModel: models.BaseModel{ID: 3}, UserID: 7, CardToken: "tok_1"}
+	newTxn := func(category string) *models.CardTransaction {
+		return &models.CardTransaction{
+			ExternalID:   "txn_1",
+			Amount:       42.5,
+			Currency:     "usd",
+			Merchant:     "Uber",
+			Category:     category,
+			TransactedAt: chargedAt,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		txn            *models.CardTransaction
+		mockCard       func(repo *mocks.MockCardRepository)
+		mockExpenseSvc func(svc *mocks.MockExpenseService)
+		expectedErr    error
+		expectedStatus string
+	}{
+		{
+			name: "AlreadyReceived",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").
+					Return(&models.CardTransaction{ExternalID: "txn_1", Status: models.CardTransactionMatched}, nil)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {},
+			expectedStatus: models.CardTransactionMatched,
+		},
+		{
+			name: "UnknownCard",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(nil, repository.ErrCardNotFound)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {},
+			expectedErr:    services.ErrCardNotFound,
+		},
+		{
+			name: "MatchesExistingExpenseByMerchant",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, txn *models.CardTransaction) error {
+						txn.ID = 11
+						return nil
+					})
+				repo.EXPECT().FindMatchCandidates(gomock.Any(), uint(7), 42.5, "USD", chargedAt.Add(-72*time.Hour), chargedAt.Add(72*time.Hour)).
+					Return([]models.Expense{
+						{BaseModel: models.BaseModel{ID: 1, CreatedAt: chargedAt}, Description: "Taxi"},
+						{BaseModel: models.BaseModel{ID: 2, CreatedAt: chargedAt.Add(48 * time.Hour)}, Description: "uber to airport"},
+					}, nil)
+				repo.EXPECT().LinkExpense(gomock.Any(), uint(11), uint(2), models.CardTransactionMatched).Return(nil)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {},
+			expectedStatus: models.CardTransactionMatched,
+		},
+		{
+			name: "CreatesDraftExpense",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, txn *models.CardTransaction) error {
+						txn.ID = 12
+						return nil
+					})
+				repo.EXPECT().FindMatchCandidates(gomock.Any(), uint(7), 42.5, "USD", gomock.Any(), gomock.Any()).Return(nil, nil)
+				repo.EXPECT().LinkExpense(gomock.Any(), uint(12), uint(99), models.CardTransactionCreated).Return(nil)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {
+				svc.EXPECT().CreateExpense(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, e *models.Expense) error {
+						if e.Status != "draft" || e.UserID != 7 || e.Category != "travel" {
+							t.Errorf("unexpected draft expense %+v", e)
+						}
+						e.ID = 99
+						return nil
+					})
+			},
+			expectedStatus: models.CardTransactionCreated,
+		},
+		{
+			name: "ExpenseLinkedConcurrentlyStaysUnmatched",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, txn *models.CardTransaction) error {
+						txn.ID = 13
+						return nil
+					})
+				repo.EXPECT().FindMatchCandidates(gomock.Any(), uint(7), 42.5, "USD", gomock.Any(), gomock.Any()).
+					Return([]models.Expense{{BaseModel: models.BaseModel{ID: 2, CreatedAt: chargedAt}}}, nil)
+				repo.EXPECT().LinkExpense(gomock.Any(), uint(13), uint(2), models.CardTransactionMatched).
+					Return(repository.ErrExpenseAlreadyLinked)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {},
+			expectedStatus: models.CardTransactionUnmatched,
+		},
+		{
+			name: "DraftExpenseFailureStaysUnmatched",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().FindMatchCandidates(gomock.Any(), uint(7), 42.5, "USD", gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {
+				svc.EXPECT().CreateExpense(gomock.Any(), gomock.Any()).Return(services.ErrCurrencyConversionFailed)
+			},
+			expectedStatus: models.CardTransactionUnmatched,
+		},
+		{
+			name: "DraftExpenseWriteFailureRollsBack",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().FindMatchCandidates(gomock.Any(), uint(7), 42.5, "USD", gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {
+				svc.EXPECT().CreateExpense(gomock.Any(), gomock.Any()).Return(errDBDown)
+			},
+			expectedErr: errDBDown,
+		},
+		{
+			name: "ConcurrentRedeliveryReturnsStoredCharge",
+			txn:  newTxn("travel"),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				gomock.InOrder(
+					repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound),
+					repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").
+						Return(&models.CardTransaction{ExternalID: "txn_1", Status: models.CardTransactionCreated}, nil),
+				)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(repository.ErrCardTransactionExists)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {},
+			expectedStatus: models.CardTransactionCreated,
+		},
+		{
+			name: "UnknownCategoryStaysUnmatched",
+			txn:  newTxn(""),
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByExternalID(gomock.Any(), "txn_1").Return(nil, repository.ErrCardTransactionNotFound)
+				repo.EXPECT().GetCardByToken(gomock.Any(), "tok_1").Return(card, nil)
+				repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().FindMatchCandidates(gomock.Any(), uint(7), 42.5, "USD", gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			mockExpenseSvc: func(svc *mocks.MockExpenseService) {},
+			expectedStatus: models.CardTransactionUnmatched,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockCardRepo := mocks.NewMockCardRepository(ctrl)
+			mockExpenseSvc := mocks.NewMockExpenseService(ctrl)
+			tt.mockCard(mockCardRepo)
+			tt.mockExpenseSvc(mockExpenseSvc)
+
+			tx := &recordingTransactor{}
+			svc := services.NewCardService(mockCardRepo, nil, mockExpenseSvc, tx)
+
+			txn, err := svc.IngestTransaction(context.Background(), "tok_1", tt.txn)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && txn.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, txn.Status)
+			}
+			if err != nil && tx.committed {
+				t.Error("expected the transaction to roll back")
+			}
+		})
+	}
+}
+
+func TestMatchTransaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockCard    func(repo *mocks.MockCardRepository)
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByID(gomock.Any(), uint(11)).Return(&models.CardTransaction{BaseModel: models.BaseModel{ID: 11}, UserID: 7}, nil)
+				repo.EXPECT().LinkExpense(gomock.Any(), uint(11), uint(2), models.CardTransactionMatched).Return(nil)
+			},
+		},
+		{
+			name: "TransactionAlreadyLinked",
+			mockCard: func(repo *mocks.MockCardRepository) {
+				linked := uint(5)
+				repo.EXPECT().GetTransactionByID(gomock.Any(), uint(11)).Return(&models.CardTransaction{BaseModel: models.BaseModel{ID: 11}, UserID: 7, ExpenseID: &linked}, nil)
+			},
+			expectedErr: services.ErrTransactionAlreadySet,
+		},
+		{
+			name: "ExpenseAlreadyLinked",
+			mockCard: func(repo *mocks.MockCardRepository) {
+				repo.EXPECT().GetTransactionByID(gomock.Any(), uint(11)).Return(&models.CardTransaction{BaseModel: models.BaseModel{ID: 11}, UserID: 7}, nil)
+				repo.EXPECT().LinkExpense(gomock.Any(), uint(11), uint(2), models.CardTransactionMatched).Return(repository.ErrExpenseAlreadyLinked)
+			},
+			expectedErr: services.ErrExpenseAlreadyLinked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockCardRepo := mocks.NewMockCardRepository(ctrl)
+			mockExpenseRepo := mocks.NewMockExpenseRepository(ctrl)
+			tt.mockCard(mockCardRepo)
+			mockExpenseRepo.EXPECT().GetExpenseByID(gomock.Any(), uint(2)).
+				Return(&models.Expense{BaseModel: models.BaseModel{ID: 2}, UserID: 7}, nil).AnyTimes()
+
+			svc := services.NewCardService(mockCardRepo, mockExpenseRepo, nil, nil)
+			err := svc.MatchTransaction(context.Background(), 11, 2, 7)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
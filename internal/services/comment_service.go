@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+var (
+	ErrCommentAuthorNotFound = errors.New("comment author not found")
+
+	// mentionPattern matches "@" followed by an email address, e.g.
+	// "@jane@example.com".
+	mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+)
+
+type CommentService interface {
+	AddReportComment(ctx context.Context, reportID uint, comment *models.Comment) error
+	AddExpenseComment(ctx context.Context, expenseID uint, comment *models.Comment) error
+	ListReportComments(ctx context.Context, reportID uint, offset, limit int) ([]models.Comment, error)
+	ListExpenseComments(ctx context.Context, expenseID uint, offset, limit int) ([]models.Comment, error)
+}
+
+type commentSrv struct {
+	repo        repository.CommentRepository
+	userRepo    repository.UserRepository
+	reportRepo  repository.ReportRepository
+	expenseRepo repository.ExpenseRepository
+}
+
+func NewCommentService(repo repository.CommentRepository, userRepo repository.UserRepository, reportRepo repository.ReportRepository, expenseRepo repository.ExpenseRepository) CommentService {
+	return &commentSrv{
+		repo:        repo,
+		userRepo:    userRepo,
+		reportRepo:  reportRepo,
+		expenseRepo: expenseRepo,
+	}
+}
+
+func (s *commentSrv) AddReportComment(ctx context.Context, reportID uint, comment *models.Comment) error {
+	if _, err := s.reportRepo.GetExpenseReportByID(ctx, reportID); err != nil {
+		return err
+	}
+	comment.ReportID = &reportID
+	return s.create(ctx, comment)
+}
+
+func (s *commentSrv) AddExpenseComment(ctx context.Context, expenseID uint, comment *models.Comment) error {
+	if _, err := s.expenseRepo.GetExpenseByID(ctx, expenseID); err != nil {
+		return err
+	}
+	comment.ExpenseID = &expenseID
+	return s.create(ctx, comment)
+}
+
+func (s *commentSrv) ListReportComments(ctx context.Context, reportID uint, offset, limit int) ([]models.Comment, error) {
+	return s.repo.ListForReport(ctx, reportID, offset, limit)
+}
+
+func (s *commentSrv) ListExpenseComments(ctx context.Context, expenseID uint, offset, limit int) ([]models.Comment, error) {
+	return s.repo.ListForExpense(ctx, expenseID, offset, limit)
+}
+
+func (s *commentSrv) create(ctx context.Context, comment *models.Comment) error {
+	if _, err := s.userRepo.GetUserByID(ctx, comment.AuthorID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrCommentAuthorNotFound
+		}
+		return err
+	}
+	mentions, err := s.resolveMentions(ctx, comment.Body)
+	if err != nil {
+		return err
+	}
+	comment.Mentions = mentions
+	return s.repo.Create(ctx, comment)
+}
+
+// resolveMentions returns the IDs of the users mentioned in body. Addresses
+// that do not belong to a user are left as plain text.
+func (s *commentSrv) resolveMentions(ctx context.Context, body string) ([]uint, error) {
+	mentions := []uint{}
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := m[1]
+		if seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+		user, err := s.userRepo.FindByEmail(ctx, email)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		mentions = append(mentions, user.ID)
+	}
+	return mentions, nil
+}
@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAddReportComment(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		mockUser         func(repo *mocks.MockUserRepository)
+		mockComment      func(repo *mocks.MockCommentRepository)
+		expectedErr      error
+		expectedMentions []uint
+	}{
+		{
+			name: "ResolvesMentions",
+			body: "@jane@example.com please check, cc @ghost@example.com and @jane@example.com",
+			mockUser: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{BaseModel: models.BaseModel{ID: 1}}, nil)
+				repo.EXPECT().FindByEmail(gomock.Any(), "jane@example.com").Return(&models.User{BaseModel: models.BaseModel{ID: 4}}, nil)
+				repo.EXPECT().FindByEmail(gomock.Any(), "ghost@example.com").Return(nil, repository.ErrUserNotFound)
+			},
+			mockComment: func(repo *mocks.MockCommentRepository) {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedMentions: []uint{4},
+		},
+		{
+			name: "EmailWithoutMentionIsIgnored",
+			body: "sent to jane@example.com",
+			mockUser: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{BaseModel: models.BaseModel{ID: 1}}, nil)
+			},
+			mockComment: func(repo *mocks.MockCommentRepository) {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedMentions: []uint{},
+		},
+		{
+			name: "UnknownAuthor",
+			body: "hello",
+			mockUser: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(nil, repository.ErrUserNotFound)
+			},
+			mockComment: func(repo *mocks.MockCommentRepository) {},
+			expectedErr: services.ErrCommentAuthorNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockReportRepo := mocks.NewMockReportRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			mockCommentRepo := mocks.NewMockCommentRepository(ctrl)
+			mockReportRepo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(9)).Return(&models.ExpenseReport{}, nil)
+			tt.mockUser(mockUserRepo)
+			tt.mockComment(mockCommentRepo)
+
+			svc := services.NewCommentService(mockCommentRepo, mockUserRepo, mockReportRepo, nil)
+
+			comment := &models.Comment{AuthorID: 1, Body: tt.body}
+			err := svc.AddReportComment(context.Background(), 9, comment)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if comment.ReportID == nil || *comment.ReportID != 9 {
+				t.Errorf("expected comment on report 9, got %v", comment.ReportID)
+			}
+			if len(comment.Mentions) != len(tt.expectedMentions) {
+				t.Fatalf("expected mentions %v, got %v", tt.expectedMentions, comment.Mentions)
+			}
+			for i, id := range tt.expectedMentions {
+				if comment.Mentions[i] != id {
+					t.Errorf("expected mentions %v, got %v", tt.expectedMentions, comment.Mentions)
+				}
+			}
+		})
+	}
+}
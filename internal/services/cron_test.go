@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2026, 10, 19, 10, 17, 30, 0, time.UTC) // a Monday
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, 10, 20, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2,3 *", time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * 5", time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := parseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.spec, err)
+		}
+		if got := schedule.next(from); !got.Equal(tt.expected) {
+			t.Errorf("%q: expected %s, got %s", tt.spec, tt.expected, got)
+		}
+	}
+}
@@ -3,76 +3,210 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// RateTable holds the rates from Base to every currency the API knows,
+// fetched together at FetchedAt.
+type RateTable struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+}
+
+// Rate derives the rate between any two currencies in the table by going
+// through the base currency.
+func (t *RateTable) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	fromRate, ok := t.lookup(from)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	toRate, ok := t.lookup(to)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+	return toRate / fromRate, nil
+}
+
+func (t *RateTable) lookup(code string) (float64, bool) {
+	if code == t.Base {
+		return 1, true
+	}
+	rate, ok := t.Rates[code]
+	return rate, ok && rate > 0
+}
+
+const (
+	currencyLocalTTL          = time.Minute
+	currencyRevalidateTimeout = 30 * time.Second
 )
 
 type CurrencyService struct {
-	redis  *redis.Client
-	apiURL string
-	ttl    time.Duration
+	cache    *Cache[*RateTable]
+	client   HTTPDoer
+	apiURL   string
+	freshFor time.Duration
+	base     string
+	// local holds the table in process for a minute in front of Redis; the
+	// service only ever caches its base currency's table. group coalesces
+	// concurrent loads and refreshes into a single call.
+	local atomic.Pointer[localRates]
+	group singleflight.Group
 }
 
-func NewCurrencyService(r *redis.Client, apiURL string, ttl time.Duration) *CurrencyService {
-	return &CurrencyService{redis: r, apiURL: apiURL, ttl: ttl}
+type localRates struct {
+	table     *RateTable
+	expiresAt time.Time
 }
 
-func cacheKey(from, to string) string {
-	return fmt.Sprintf("fx:%s:%s", strings.ToUpper(from), strings.ToUpper(to))
+// NewCurrencyService builds the converter. Only the rate table for base is
+// fetched and cached; every other pair is derived from it. Tables are cached
+// for ttl, which should comfortably exceed the refresh interval. A table
+// older than freshFor is still served while it is refreshed in the
+// background.
+func NewCurrencyService(r RedisClient, client HTTPDoer, apiURL string, ttl, freshFor time.Duration, base string) *CurrencyService {
+	return &CurrencyService{
+		cache:    NewCache[*RateTable]("fx_rates", r, CacheOptions{TTL: ttl}),
+		client:   client,
+		apiURL:   apiURL,
+		freshFor: freshFor,
+		base:     strings.ToUpper(base),
+	}
 }
 
+func (s *CurrencyService) localTable() (*RateTable, bool) {
+	local := s.local.Load()
+	if local == nil || time.Now().After(local.expiresAt) {
+		return nil, false
+	}
+	return local.table, true
+}
+
+func (s *CurrencyService) setLocalTable(table *RateTable) {
+	s.local.Store(&localRates{table: table, expiresAt: time.Now().Add(currencyLocalTTL)})
+}
+
+func rateTableKey(base string) string {
+	return fmt.Sprintf("fx:rates:%s", strings.ToUpper(base))
+}
+
+// Convert converts between any two currencies in the rate table, serving
+// from the cache kept warm by Refresh. It returns the converted amount and
+// the rate used.
 func (s *CurrencyService) Convert(ctx context.Context, amount float64, from, to string) (float64, float64, error) {
+	table, err := s.Rates(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	rate, err := table.Rate(from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return amount * rate, rate, nil
+}
 
-	from = strings.ToUpper(from)
-	to = strings.ToUpper(to)
-	key := cacheKey(from, to)
-
-	if s.redis != nil {
-		if val, err := s.redis.Get(ctx, key).Result(); err == nil {
-			var rate float64
-			if _ = json.Unmarshal([]byte(val), &rate); rate != 0 {
-				return amount * rate, rate, nil
-			}
-		} else if err != redis.Nil {
-			log.Printf("Redis error: %v", err)
+// Rates returns the rate table from the in-process cache, then Redis, and
+// only fetches it when both miss. Concurrent misses share one lookup. A
+// stale table is returned as is while a single background refresh runs.
+func (s *CurrencyService) Rates(ctx context.Context) (*RateTable, error) {
+	table, ok := s.localTable()
+	if !ok {
+		// The shared lookup outlives the first caller's cancellation, since
+		// other callers may be waiting on it.
+		v, err, _ := s.group.Do("load", func() (interface{}, error) {
+			return s.load(context.WithoutCancel(ctx))
+		})
+		if err != nil {
+			return nil, err
 		}
+		table = v.(*RateTable)
+	}
+	if s.freshFor > 0 && time.Since(table.FetchedAt) > s.freshFor {
+		s.revalidate()
+	}
+	return table, nil
+}
+
+func (s *CurrencyService) load(ctx context.Context) (*RateTable, error) {
+	if table, ok, _ := s.cache.Get(ctx, rateTableKey(s.base)); ok && table != nil && len(table.Rates) > 0 {
+		s.setLocalTable(table)
+		return table, nil
 	}
-	url := fmt.Sprintf("%s/latest/%s", s.apiURL, from)
-	resp, err := http.Get(url)
+	return s.Refresh(ctx)
+}
+
+// revalidate starts a background refresh unless one is already running.
+func (s *CurrencyService) revalidate() {
+	s.group.DoChan("refresh", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), currencyRevalidateTimeout)
+		defer cancel()
+		table, err := s.refresh(ctx)
+		if err != nil {
+			log.Printf("failed to revalidate %s rates: %v", s.base, err)
+		}
+		return table, err
+	})
+}
+
+// Refresh fetches the base currency's rate table and caches it, so Convert
+// never waits on the API.
+func (s *CurrencyService) Refresh(ctx context.Context) (*RateTable, error) {
+	v, err, _ := s.group.Do("refresh", func() (interface{}, error) {
+		return s.refresh(ctx)
+	})
 	if err != nil {
-		return 0, 0, err
+		return nil, err
+	}
+	return v.(*RateTable), nil
+}
+
+func (s *CurrencyService) refresh(ctx context.Context) (*RateTable, error) {
+	table, err := s.fetchRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.setLocalTable(table)
+	s.cache.Set(ctx, rateTableKey(s.base), table)
+	return table, nil
+}
+
+func (s *CurrencyService) fetchRates(ctx context.Context) (*RateTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/latest/%s", s.apiURL, s.base), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, 0, fmt.Errorf("failed to fetch exchange rate: %s", resp.Status)
+		return nil, fmt.Errorf("failed to fetch exchange rate: %s", resp.Status)
 	}
 
 	var data struct {
 		ConversionRates map[string]float64 `json:"conversion_rates"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return 0, 0, err
+		return nil, err
 	}
-
-	rate, ok := data.ConversionRates[to]
-
-	if !ok {
-		return 0, 0, fmt.Errorf("unsupported currency: %s", to)
+	if len(data.ConversionRates) == 0 {
+		return nil, errors.New("exchange rate response has no rates")
 	}
-
-	if s.redis != nil {
-		if val, err := json.Marshal(rate); err == nil {
-			if err := s.redis.Set(ctx, key, val, s.ttl).Err(); err != nil {
-				log.Printf("Redis error: %v", err)
-			}
-		}
-	}
-
-	return amount * rate, rate, nil
+	return &RateTable{Base: s.base, Rates: data.ConversionRates, FetchedAt: time.Now().UTC()}, nil
 }
@@ -0,0 +1,288 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/httpclient"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeRateAPI serves /latest/<BASE> from a fixed table and counts calls.
+func fakeRateAPI(t *testing.T, tables map[string]map[string]float64) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rates, ok := tables[strings.TrimPrefix(r.URL.Path, "/latest/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"conversion_rates": rates})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func testHTTPClient(retries, threshold int) *httpclient.Client {
+	return httpclient.New("test", httpclient.Config{
+		Timeout:          time.Second,
+		MaxRetries:       retries,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: threshold,
+		OpenTimeout:      time.Minute,
+	})
+}
+
+func TestConvertServesWarmCache(t *testing.T) {
+	table := `{"base":"USD","rates":{"USD":1,"EUR":0.8,"NGN":1500},"fetched_at":"2026-10-19T10:00:00Z"}`
+	tests := []struct {
+		name         string
+		from, to     string
+		expectedRate float64
+		expectedErr  error
+	}{
+		{name: "FromBase", from: "usd", to: "ngn", expectedRate: 1500},
+		{name: "ToBase", from: "EUR", to: "USD", expectedRate: 1.25},
+		{name: "CrossRate", from: "EUR", to: "NGN", expectedRate: 1875},
+		{name: "SameCurrency", from: "GBP", to: "GBP", expectedRate: 1},
+		{name: "UnknownCurrency", from: "EUR", to: "XYZ", expectedErr: services.ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server, calls := fakeRateAPI(t, nil)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			mockRedis.EXPECT().Get(gomock.Any(), "fx:rates:USD").Return(redis.NewStringResult(table, nil))
+
+			svc := services.NewCurrencyService(mockRedis, testHTTPClient(0, 0), server.URL, time.Hour, 0, "USD")
+			amount, rate, err := svc.Convert(context.Background(), 10, tt.from, tt.to)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if math.Abs(rate-tt.expectedRate) > 1e-9 || math.Abs(amount-10*tt.expectedRate) > 1e-6 {
+				t.Errorf("expected rate %v, got %v (amount %v)", tt.expectedRate, rate, amount)
+			}
+			if *calls != 0 {
+				t.Errorf("expected no upstream call, got %d", *calls)
+			}
+		})
+	}
+}
+
+func TestConvertFetchesTableOnMiss(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server, calls := fakeRateAPI(t, map[string]map[string]float64{
+		"USD": {"USD": 1, "EUR": 0.8, "GBP": 0.75},
+	})
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Get(gomock.Any(), "fx:rates:USD").Return(redis.NewStringResult("", redis.Nil))
+	mockRedis.EXPECT().Set(gomock.Any(), "fx:rates:USD", gomock.Any(), 2*time.Hour).
+		DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) *redis.StatusCmd {
+			var table services.RateTable
+			if err := json.Unmarshal(value.([]byte), &table); err != nil || table.FetchedAt.IsZero() || len(table.Rates) != 3 {
+				t.Errorf("expected the whole table with its fetch time, got %s", value)
+			}
+			return redis.NewStatusResult("OK", nil)
+		})
+
+	svc := services.NewCurrencyService(mockRedis, testHTTPClient(0, 0), server.URL, 2*time.Hour, 0, "usd")
+	_, rate, err := svc.Convert(context.Background(), 1, "GBP", "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(rate-0.8/0.75) > 1e-9 {
+		t.Errorf("expected cross rate %v, got %v", 0.8/0.75, rate)
+	}
+	if *calls != 1 {
+		t.Errorf("expected one upstream call, got %d", *calls)
+	}
+}
+
+func TestRefreshSurvivesFlakyUpstream(t *testing.T) {
+	okBody := `{"conversion_rates":{"USD":1,"EUR":0.8}}`
+	tests := []struct {
+		name          string
+		responses     []int
+		retries       int
+		threshold     int
+		refreshes     int
+		expectedCalls int32
+		expectedErr   error
+		expectFailure bool
+	}{
+		{name: "RetriesServerErrors", responses: []int{503, 502, 200}, retries: 3, refreshes: 1, expectedCalls: 3},
+		{name: "RetriesRateLimit", responses: []int{429, 200}, retries: 3, refreshes: 1, expectedCalls: 2},
+		{name: "DoesNotRetryClientErrors", responses: []int{404}, retries: 3, refreshes: 1, expectedCalls: 1, expectFailure: true},
+		{name: "GivesUpAfterRetries", responses: []int{500, 500, 500}, retries: 2, refreshes: 1, expectedCalls: 3, expectFailure: true},
+		{name: "BreakerShortCircuits", responses: []int{500, 500, 200}, threshold: 2, refreshes: 3, expectedCalls: 2, expectedErr: httpclient.ErrCircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				status := tt.responses[min(int(n), len(tt.responses))-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(okBody))
+				}
+			}))
+			defer server.Close()
+
+			svc := services.NewCurrencyService(nil, testHTTPClient(tt.retries, tt.threshold), server.URL, time.Hour, 0, "USD")
+			var err error
+			for i := 0; i < tt.refreshes; i++ {
+				_, err = svc.Refresh(context.Background())
+			}
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr == nil && (err != nil) != tt.expectFailure {
+				t.Fatalf("expected failure %v, got %v", tt.expectFailure, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d upstream calls, got %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRefreshTimesOutHungUpstream(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := httpclient.New("test", httpclient.Config{Timeout: 50 * time.Millisecond})
+	svc := services.NewCurrencyService(nil, client, server.URL, time.Hour, 0, "USD")
+
+	start := time.Now()
+	if _, err := svc.Refresh(context.Background()); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the request to be cut off, took %s", elapsed)
+	}
+}
+
+func TestConvertCoalescesConcurrentMisses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"conversion_rates":{"USD":1,"EUR":0.8}}`))
+	}))
+	defer server.Close()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Get(gomock.Any(), "fx:rates:USD").Return(redis.NewStringResult("", redis.Nil)).Times(1)
+	mockRedis.EXPECT().Set(gomock.Any(), "fx:rates:USD", gomock.Any(), time.Hour).Return(redis.NewStatusResult("OK", nil)).Times(1)
+
+	svc := services.NewCurrencyService(mockRedis, testHTTPClient(0, 0), server.URL, time.Hour, time.Hour, "USD")
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.Convert(context.Background(), 10, "USD", "EUR"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected one upstream call, got %d", calls)
+	}
+
+	// Later lookups are served from memory without touching Redis.
+	if _, _, err := svc.Convert(context.Background(), 10, "EUR", "USD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConvertServesStaleTableWhileRevalidating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	release := make(chan struct{})
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, _ = w.Write([]byte(`{"conversion_rates":{"USD":1,"EUR":0.9}}`))
+	}))
+	defer server.Close()
+
+	stale := fmt.Sprintf(`{"base":"USD","rates":{"USD":1,"EUR":0.8},"fetched_at":%q}`, time.Now().Add(-3*time.Hour).Format(time.RFC3339))
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Get(gomock.Any(), "fx:rates:USD").Return(redis.NewStringResult(stale, nil)).Times(1)
+	refreshed := make(chan struct{})
+	mockRedis.EXPECT().Set(gomock.Any(), "fx:rates:USD", gomock.Any(), time.Hour).
+		DoAndReturn(func(context.Context, string, interface{}, time.Duration) *redis.StatusCmd {
+			close(refreshed)
+			return redis.NewStatusResult("OK", nil)
+		})
+
+	svc := services.NewCurrencyService(mockRedis, testHTTPClient(0, 0), server.URL, time.Hour, time.Hour, "USD")
+	for i := 0; i < 10; i++ {
+		_, rate, err := svc.Convert(context.Background(), 1, "USD", "EUR")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rate != 0.8 {
+			t.Fatalf("expected the stale rate while revalidating, got %v", rate)
+		}
+	}
+	close(release)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("expected a background refresh")
+	}
+	if calls != 1 {
+		t.Errorf("expected one upstream call, got %d", calls)
+	}
+	_, rate, err := svc.Convert(context.Background(), 1, "USD", "EUR")
+	if err != nil || rate != 0.9 {
+		t.Errorf("expected the refreshed rate 0.9, got %v (%v)", rate, err)
+	}
+}
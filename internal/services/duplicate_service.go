@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+const (
+	duplicateWindow          = 3 * 24 * time.Hour
+	descriptionSimilarityMin = 0.6
+)
+
+type DuplicateService interface {
+	CheckExpense(ctx context.Context, expense *models.Expense) ([]models.DuplicateFlag, error)
+	CheckReport(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error)
+	ListReportFlags(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error)
+}
+
+type duplicateSrv struct {
+	repo       repository.DuplicateRepository
+	reportRepo repository.ReportRepository
+	publisher  events.Publisher
+}
+
+func NewDuplicateService(repo repository.DuplicateRepository, reportRepo repository.ReportRepository, publisher events.Publisher) DuplicateService {
+	return &duplicateSrv{repo: repo, reportRepo: reportRepo, publisher: publisher}
+}
+
+// CheckExpense compares the expense with the user's other expenses and
+// records a flag for every likely duplicate. An identical receipt is always
+// a duplicate; otherwise amount, currency and date must line up and the
+// descriptions must be similar.
+func (s *duplicateSrv) CheckExpense(ctx context.Context, expense *models.Expense) ([]models.DuplicateFlag, error) {
+	candidates, err := s.repo.FindCandidates(ctx, expense,
+		expense.CreatedAt.Add(-duplicateWindow), expense.CreatedAt.Add(duplicateWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []models.DuplicateFlag
+	for i := range candidates {
+		if flag, ok := compareExpenses(expense, &candidates[i]); ok {
+			flags = append(flags, flag)
+		}
+	}
+	if err := s.repo.SaveFlags(ctx, flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func (s *duplicateSrv) CheckReport(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error) {
+	report, err := s.reportRepo.GetExpenseReportByID(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	var flags []models.DuplicateFlag
+	for i := range report.Expenses {
+		found, err := s.CheckExpense(ctx, &report.Expenses[i])
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, found...)
+	}
+	if len(flags) > 0 && s.publisher != nil {
+		s.publishViolation(ctx, report, flags)
+	}
+	return flags, nil
+}
+
+// publishViolation announces that a report breaks the no-duplicates policy.
+func (s *duplicateSrv) publishViolation(ctx context.Context, report *models.ExpenseReport, flags []models.DuplicateFlag) {
+	issues := make([]string, 0, len(flags))
+	for _, f := range flags {
+		issues = append(issues, fmt.Sprintf("expense #%d looks like a duplicate of expense #%d (%s)",
+			f.ExpenseID, f.DuplicateOfID, strings.Join(f.Reasons, ", ")))
+	}
+	payload := events.ReportPayload{
+		ReportID: report.ID,
+		UserID:   report.UserID,
+		Title:    report.Title,
+		Status:   report.Status,
+		Total:    report.Total,
+		Issues:   issues,
+	}
+	if err := s.publisher.Publish(ctx, events.ReportPolicyViolation, payload); err != nil {
+		log.Printf("failed to publish %s for report %d: %v", events.ReportPolicyViolation, report.ID, err)
+	}
+}
+
+func (s *duplicateSrv) ListReportFlags(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error) {
+	return s.repo.ListFlagsForReport(ctx, reportID)
+}
+
+func compareExpenses(expense, other *models.Expense) (models.DuplicateFlag, bool) {
+	flag := models.DuplicateFlag{ExpenseID: expense.ID, DuplicateOfID: other.ID}
+
+	if expense.ReceiptHash != "" && expense.ReceiptHash == other.ReceiptHash {
+		flag.Reasons = append(flag.Reasons, "identical receipt")
+		flag.Score = 1
+	}
+
+	gap := expense.CreatedAt.Sub(other.CreatedAt)
+	if gap < 0 {
+		gap = -gap
+	}
+	sameCharge := strings.EqualFold(expense.Currency, other.Currency) &&
+		absFloat(expense.Amount-other.Amount) < 0.005 &&
+		gap <= duplicateWindow
+	if sameCharge {
+		similarity := descriptionSimilarity(expense.Description, other.Description)
+		if similarity >= descriptionSimilarityMin {
+			flag.Reasons = append(flag.Reasons, "same amount and currency within "+describeWindow(duplicateWindow), "similar description")
+			if score := 0.5 + similarity/2; score > flag.Score {
+				flag.Score = score
+			}
+		}
+	}
+	return flag, len(flag.Reasons) > 0
+}
+
+// describeWindow renders d for reasons shown to approvers, e.g. "3 days".
+func describeWindow(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d == day:
+		return "1 day"
+	case d%day == 0:
+		return fmt.Sprintf("%d days", d/day)
+	default:
+		return d.String()
+	}
+}
+
+// descriptionSimilarity is the Jaccard index of the word sets. Two empty
+// descriptions count as identical.
+func descriptionSimilarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	union := len(wa) + len(wb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func words(s string) map[string]bool {
+	out := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		out[w] = true
+	}
+	return out
+}
+
+func absFloat(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCheckExpense(t *testing.T) {
+	filedAt := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+	expense := &models.Expense{
+		BaseModel:   models.BaseModel{ID: 10, CreatedAt: filedAt},
+		UserID:      1,
+		Amount:      25,
+		Currency:    "EUR",
+		Description: "Dinner at Luigi's",
+		ReceiptHash: "abc",
+	}
+
+	tests := []struct {
+		name           string
+		candidates     []models.Expense
+		expectedDups   []uint
+		expectedReason string
+	}{
+		{
+			name: "IdenticalReceipt",
+			candidates: []models.Expense{
+				{BaseModel: models.BaseModel{ID: 1, CreatedAt: filedAt.Add(-30 * 24 * time.Hour)}, Amount: 99, Currency: "USD", ReceiptHash: "abc"},
+			},
+			expectedDups:   []uint{1},
+			expectedReason: "identical receipt",
+		},
+		{
+			name: "SameAmountSimilarDescription",
+			candidates: []models.Expense{
+				{BaseModel: models.BaseModel{ID: 2, CreatedAt: filedAt.Add(-24 * time.Hour)}, Amount: 25, Currency: "EUR", Description: "dinner at luigi's"},
+			},
+			expectedDups:   []uint{2},
+			expectedReason: "same amount and currency within 3 days",
+		},
+		{
+			name: "SameAmountDifferentDescription",
+			candidates: []models.Expense{
+				{BaseModel: models.BaseModel{ID: 3, CreatedAt: filedAt}, Amount: 25, Currency: "EUR", Description: "Taxi to airport"},
+			},
+			expectedDups: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockDuplicateRepository(ctrl)
+			mockRepo.EXPECT().
+				FindCandidates(gomock.Any(), expense, filedAt.Add(-72*time.Hour), filedAt.Add(72*time.Hour)).
+				Return(tt.candidates, nil)
+			mockRepo.EXPECT().
+				SaveFlags(gomock.Any(), gomock.Len(len(tt.expectedDups))).
+				Return(nil)
+
+			svc := services.NewDuplicateService(mockRepo, nil, nil)
+
+			flags, err := svc.CheckExpense(context.Background(), expense)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(flags) != len(tt.expectedDups) {
+				t.Fatalf("expected %d flags, got %d (%+v)", len(tt.expectedDups), len(flags), flags)
+			}
+			for i, id := range tt.expectedDups {
+				if flags[i].DuplicateOfID != id || flags[i].ExpenseID != expense.ID {
+					t.Errorf("expected flag for %d -> %d, got %+v", expense.ID, id, flags[i])
+				}
+			}
+			if tt.expectedReason != "" && !slices.Contains(flags[0].Reasons, tt.expectedReason) {
+				t.Errorf("expected reason %q, got %v", tt.expectedReason, flags[0].Reasons)
+			}
+		})
+	}
+}
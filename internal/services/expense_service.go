@@ -2,13 +2,15 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
 	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
 	"github.com/redis/go-redis/v9"
 )
@@ -17,24 +19,44 @@ var ErrCurrencyConversionFailed = errors.New("currency conversion failed")
 
 type ExpenseService interface {
 	CreateExpense(ctx context.Context, expense *models.Expense) error
+	ImportExpenses(ctx context.Context, expenses []*models.Expense, dryRun bool) ([]error, error)
 	GetExpenseByID(ctx context.Context, id uint) (*models.Expense, error)
-	UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId uint) error
-	DeleteExpense(ctx context.Context, id uint, userId uint) error
+	UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId, version uint) error
+	PatchExpense(ctx context.Context, id, userId, version uint, patch ExpensePatch) (*models.Expense, error)
+	DeleteExpense(ctx context.Context, id uint, userId, version uint) error
+	RestoreExpense(ctx context.Context, id uint) (*models.Expense, error)
 	GetExpenses(ctx context.Context, filters map[string]interface{}, offset, limit int) ([]models.Expense, error)
+	StreamExpenses(ctx context.Context, filters map[string]interface{}, fn func(*models.Expense) error) error
+}
+
+// ExpensePatch holds the fields to change; nil fields are left as they are
+// and an empty Description or Receipt clears it.
+type ExpensePatch struct {
+	Amount      *float64
+	Currency    *string
+	Category    *string
+	Description *string
+	Receipt     *string
 }
 
 type expenseSrv struct {
 	repo        repository.ExpenseRepository
 	redis       RedisClient
+	lists       *Cache[[]models.Expense]
 	currencySvc CurrencyConverter
+	audit       AuditLogger
+	publisher   events.Publisher
+	tx          repository.Transactor
 }
 
-func NewExpenseService(redis RedisClient, currencySvc CurrencyConverter, repo repository.ExpenseRepository) ExpenseService {
-	return &expenseSrv{repo: repo, redis: redis, currencySvc: currencySvc}
+func NewExpenseService(redis RedisClient, currencySvc CurrencyConverter, repo repository.ExpenseRepository, audit AuditLogger, publisher events.Publisher, tx repository.Transactor) ExpenseService {
+	lists := NewCache[[]models.Expense]("expenses", redis, CacheOptions{TTL: 30 * time.Minute, Jitter: 0.1})
+	return &expenseSrv{repo: repo, redis: redis, lists: lists, currencySvc: currencySvc, audit: audit, publisher: publisher, tx: tx}
 }
 
 func (s *expenseSrv) CreateExpense(ctx context.Context, expense *models.Expense) error {
 	currency := strings.ToUpper(expense.Currency)
+	expense.ReceiptHash = storage.HashFromName(expense.Receipt)
 
 	if expense.Currency == "USD" {
 
@@ -48,16 +70,86 @@ func (s *expenseSrv) CreateExpense(ctx context.Context, expense *models.Expense)
 		expense.AmountUSD = convertedAmount
 		expense.ExchangeRate = rate
 	}
-	s.invalidateExpensesCache(ctx)
-	return s.repo.Create(ctx, expense)
+	return withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, expense); err != nil {
+			return ownerError(err)
+		}
+		if err := s.recordAudit(ctx, "create", expense.ID, expense.UserID, nil, expense); err != nil {
+			return err
+		}
+		return s.publish(ctx, events.ExpenseCreated, expense)
+	})
+}
+
+// ImportExpenses converts and stores a batch of expenses. Each distinct
+// currency is looked up once and its rate reused for every row. The returned
+// slice is aligned with expenses and holds nil for rows that were accepted.
+func (s *expenseSrv) ImportExpenses(ctx context.Context, expenses []*models.Expense, dryRun bool) ([]error, error) {
+	rates := map[string]float64{"USD": 1.0}
+	rowErrs := make([]error, len(expenses))
+	valid := make([]*models.Expense, 0, len(expenses))
+
+	for i, expense := range expenses {
+		currency := strings.ToUpper(expense.Currency)
+		rate, ok := rates[currency]
+		if !ok {
+			_, fetched, err := s.currencySvc.Convert(ctx, 1, currency, "USD")
+			if err != nil {
+				fetched = 0
+			}
+			rates[currency] = fetched
+			rate = fetched
+		}
+		if rate == 0 {
+			rowErrs[i] = ErrCurrencyConversionFailed
+			continue
+		}
+		expense.Currency = currency
+		expense.ReceiptHash = storage.HashFromName(expense.Receipt)
+		expense.ExchangeRate = rate
+		expense.AmountUSD = expense.Amount * rate
+		valid = append(valid, expense)
+	}
+
+	if dryRun || len(valid) == 0 {
+		return rowErrs, nil
+	}
+	err := withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.CreateBatch(ctx, valid); err != nil {
+			return ownerError(err)
+		}
+		if s.audit != nil {
+			// One batch, so the whole import takes the audit chain lock once.
+			entries := make([]AuditEntry, len(valid))
+			for i, expense := range valid {
+				entries[i] = expenseAuditEntry("import", expense.ID, expense.UserID, nil, expense)
+			}
+			if err := s.audit.Record(ctx, entries...); err != nil {
+				return err
+			}
+		}
+		for _, expense := range valid {
+			if err := s.publish(ctx, events.ExpenseCreated, expense); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rowErrs, nil
 }
 
 func (s *expenseSrv) GetExpenseByID(ctx context.Context, id uint) (*models.Expense, error) {
 	return s.repo.GetExpenseByID(ctx, id)
 }
 
-func (s *expenseSrv) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId uint) error {
+// UpdateExpense overwrites the expense if it is still at version, the one
+// the caller read. On success expense.Version holds the new version.
+func (s *expenseSrv) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId, version uint) error {
 	currency := strings.ToUpper(expense.Currency)
+	expense.ReceiptHash = storage.HashFromName(expense.Receipt)
 
 	if currency == "USD" {
 		expense.AmountUSD = expense.Amount
@@ -70,47 +162,257 @@ func (s *expenseSrv) UpdateExpense(ctx context.Context, id uint, expense *models
 		expense.AmountUSD = convertedAmount
 		expense.ExchangeRate = rate
 	}
-	s.invalidateExpensesCache(ctx)
-	return s.repo.UpdateExpense(ctx, id, expense, userId)
+	before := s.auditSnapshot(ctx, id)
+	return withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.UpdateExpense(ctx, id, expense, userId, version); err != nil {
+			return lockedReportError(err)
+		}
+		if before != nil {
+			if err := s.recordAudit(ctx, "update", id, userId, before, s.auditSnapshot(ctx, id)); err != nil {
+				return err
+			}
+		}
+		updated := *expense
+		updated.ID, updated.UserID = id, userId
+		return s.publish(ctx, events.ExpenseUpdated, &updated)
+	})
 }
 
-func (s *expenseSrv) DeleteExpense(ctx context.Context, id uint, userId uint) error {
-	s.invalidateExpensesCache(ctx)
-	return s.repo.DeleteExpense(ctx, id, userId)
-}
+// PatchExpense changes only the fields set in patch, if the expense is still
+// at version. The USD amount is converted again only when the amount or
+// currency actually change, so a patched description keeps the original rate.
+func (s *expenseSrv) PatchExpense(ctx context.Context, id, userId, version uint, patch ExpensePatch) (*models.Expense, error) {
+	before, err := s.repo.GetExpenseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.UserID != userId {
+		return nil, repository.ErrExpenseNotFound
+	}
+	if before.Version != version {
+		return nil, repository.ErrVersionConflict
+	}
 
-func (s *expenseSrv) GetExpenses(ctx context.Context, filters map[string]interface{}, offset, limit int) ([]models.Expense, error) {
-	key := utils.ExpensesCacheKey(filters, offset, limit)
-	if s.redis != nil {
-		val, err := s.redis.Get(ctx, key).Result()
-		if err == nil {
-			var expenses []models.Expense
-			if unmarshalErr := json.Unmarshal([]byte(val), &expenses); unmarshalErr == nil {
-				return expenses, nil
+	fields := map[string]interface{}{}
+	amount, currency := before.Amount, before.Currency
+	if patch.Amount != nil && *patch.Amount != amount {
+		amount = *patch.Amount
+		fields["amount"] = amount
+	}
+	if patch.Currency != nil && !strings.EqualFold(*patch.Currency, currency) {
+		currency = strings.ToUpper(*patch.Currency)
+		fields["currency"] = currency
+	}
+	if len(fields) > 0 {
+		amountUSD, rate := amount, 1.0
+		if !strings.EqualFold(currency, "USD") {
+			if amountUSD, rate, err = s.currencySvc.Convert(ctx, amount, strings.ToUpper(currency), "USD"); err != nil {
+				return nil, ErrCurrencyConversionFailed
 			}
-			_ = s.redis.Del(ctx, key).Err()
-		} else if err != redis.Nil {
-			return nil, err
 		}
+		fields["amount_usd"] = amountUSD
+		fields["exchange_rate"] = rate
+	}
+	if patch.Category != nil && *patch.Category != before.Category {
+		fields["category"] = *patch.Category
 	}
-	expenses, err := s.repo.GetExpenses(ctx, filters, offset, limit)
+	if patch.Description != nil && *patch.Description != before.Description {
+		fields["description"] = *patch.Description
+	}
+	if patch.Receipt != nil && *patch.Receipt != before.Receipt {
+		fields["receipt"] = *patch.Receipt
+		fields["receipt_hash"] = storage.HashFromName(*patch.Receipt)
+	}
+	if len(fields) == 0 {
+		return before, nil
+	}
+
+	var after *models.Expense
+	err = withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.PatchExpense(ctx, id, userId, version, fields); err != nil {
+			return lockedReportError(err)
+		}
+		var err error
+		if after, err = s.repo.GetExpenseByID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.recordAudit(ctx, "update", id, userId, before, after); err != nil {
+			return err
+		}
+		return s.publish(ctx, events.ExpenseUpdated, after)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if s.redis != nil {
-		bytes, _ := json.Marshal(expenses)
-		_ = s.redis.Set(ctx, key, bytes, time.Minute*30).Err()
-	}
+	return after, nil
+}
 
-	return expenses, nil
+func (s *expenseSrv) DeleteExpense(ctx context.Context, id uint, userId, version uint) error {
+	before := s.auditSnapshot(ctx, id)
+	return withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.DeleteExpense(ctx, id, userId, version); err != nil {
+			return lockedReportError(err)
+		}
+		if before != nil {
+			if err := s.recordAudit(ctx, "delete", id, userId, before, nil); err != nil {
+				return err
+			}
+		}
+		return s.publish(ctx, events.ExpenseDeleted, &models.Expense{BaseModel: models.BaseModel{ID: id}, UserID: userId})
+	})
+}
+
+// RestoreExpense brings back a soft-deleted expense.
+func (s *expenseSrv) RestoreExpense(ctx context.Context, id uint) (*models.Expense, error) {
+	var expense *models.Expense
+	err := withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		var err error
+		if expense, err = s.repo.RestoreExpense(ctx, id); err != nil {
+			return lockedReportError(err)
+		}
+		if err := s.recordAudit(ctx, "restore", id, *actorOrOwner(ctx, expense.UserID), nil, expense); err != nil {
+			return err
+		}
+		return s.publish(ctx, events.ExpenseRestored, expense)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expense, nil
 }
 
-func (s *expenseSrv) invalidateExpensesCache(ctx context.Context) {
+func (s *expenseSrv) GetExpenses(ctx context.Context, filters map[string]interface{}, offset, limit int) ([]models.Expense, error) {
+	load := func(ctx context.Context) ([]models.Expense, error) {
+		return s.repo.GetExpenses(ctx, filters, offset, limit)
+	}
 	if s.redis == nil {
-		return
+		return load(ctx)
+	}
+	userID, _ := filters["user_id"].(uint)
+	version, err := expensesCacheVersion(ctx, s.redis, userID)
+	if err != nil {
+		// Without the current version a cached list may be stale.
+		if !errors.Is(err, ErrRedisUnavailable) {
+			log.Printf("cache expenses: version for user %d: %v", userID, err)
+		}
+		return load(ctx)
+	}
+	return s.lists.GetOrLoad(ctx, utils.ExpensesCacheKey(filters, version, offset, limit), load)
+}
+
+func (s *expenseSrv) StreamExpenses(ctx context.Context, filters map[string]interface{}, fn func(*models.Expense) error) error {
+	return s.repo.StreamExpenses(ctx, filters, fn)
+}
+
+// ownerError reports an expense refused because of its owner with the
+// service's user errors.
+func ownerError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repository.ErrUserInactive):
+		return ErrUserInactive
+	}
+	return err
+}
+
+// lockedReportError reports a write refused because the expense belongs to
+// a submitted or approved report as ErrReportLocked.
+func lockedReportError(err error) error {
+	if errors.Is(err, repository.ErrReportNotEditable) {
+		return ErrReportLocked
+	}
+	return err
+}
+
+// auditSnapshot loads the current state of an expense for the audit diff.
+// It is skipped entirely when auditing is disabled.
+func (s *expenseSrv) auditSnapshot(ctx context.Context, id uint) *models.Expense {
+	if s.audit == nil {
+		return nil
+	}
+	expense, err := s.repo.GetExpenseByID(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return expense
+}
+
+// recordAudit appends the change to the audit chain. It runs inside the
+// write transaction, so a failure rolls the change back.
+func (s *expenseSrv) recordAudit(ctx context.Context, action string, id, userID uint, before, after interface{}) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(ctx, expenseAuditEntry(action, id, userID, before, after))
+}
+
+func expenseAuditEntry(action string, id, userID uint, before, after interface{}) AuditEntry {
+	return AuditEntry{
+		Action:     action,
+		EntityType: AuditEntityExpense,
+		EntityID:   id,
+		ActorID:    userID,
+		Before:     before,
+		After:      after,
+	}
+}
+
+// publish records an expense event. Called inside the write transaction, the
+// event is committed or discarded together with the change.
+func (s *expenseSrv) publish(ctx context.Context, eventType string, expense *models.Expense) error {
+	if s.publisher == nil {
+		return nil
 	}
-	iter := s.redis.Scan(ctx, 0, "expenses:*", 0).Iterator()
-	for iter.Next(ctx) {
-		_ = s.redis.Del(ctx, iter.Val()).Err()
+	return s.publisher.Publish(ctx, eventType, events.ExpensePayload{
+		ExpenseID:   expense.ID,
+		UserID:      expense.UserID,
+		Amount:      expense.Amount,
+		Currency:    expense.Currency,
+		AmountUSD:   expense.AmountUSD,
+		Category:    expense.Category,
+		Description: expense.Description,
+		Status:      expense.Status,
+	})
+}
+
+// expensesCacheVersion reads the current generation of a user's expense
+// lists, or of the unfiltered lists when userID is 0.
+func expensesCacheVersion(ctx context.Context, r RedisClient, userID uint) (int64, error) {
+	version, err := r.Get(ctx, utils.ExpensesCacheVersionKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// NewExpenseCacheInvalidator retires the cached expense lists a change can
+// affect: the owner's lists and the unfiltered ones. Rather than deleting
+// keys it bumps their generation counters, so other users' lists stay warm
+// and the cost does not grow with the keyspace. It subscribes to expense
+// events, so the cache is only invalidated once a change has committed, and
+// a failure while Redis is down makes the outbox deliver the event again.
+func NewExpenseCacheInvalidator(redis RedisClient) events.Handler {
+	return func(ctx context.Context, e events.Event) error {
+		var payload events.ExpensePayload
+		if err := e.Decode(&payload); err != nil {
+			return err
+		}
+		return bumpExpensesCacheVersion(ctx, redis, payload.UserID)
+	}
+}
+
+// bumpExpensesCacheVersion retires the cached lists of userID and the
+// unfiltered lists.
+func bumpExpensesCacheVersion(ctx context.Context, r RedisClient, userID uint) error {
+	scopes := []uint{0}
+	if userID != 0 {
+		scopes = append(scopes, userID)
+	}
+	for _, scope := range scopes {
+		if err := r.Incr(ctx, utils.ExpensesCacheVersionKey(scope)).Err(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
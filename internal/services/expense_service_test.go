@@ -2,11 +2,22 @@ package services_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
 	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/mock/gomock"
 )
 
@@ -85,6 +96,25 @@ func TestCreateExpense(t *testing.T) {
 			assert: func(t *testing.T, exp *models.Expense) {
 			},
 		},
+		{
+			name: "DeactivatedUser",
+			expense: &models.Expense{
+				UserID:   9,
+				Currency: "USD",
+				Amount:   10,
+			},
+			mockRepo: func(repo *mocks.MockExpenseRepository) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(repository.ErrUserInactive)
+			},
+			mockCurrency: func(ctrl *gomock.Controller) *mocks.MockCurrencyConverter {
+				return mocks.NewMockCurrencyConverter(ctrl)
+			},
+			expectedErr: services.ErrUserInactive,
+			assert: func(t *testing.T, exp *models.Expense) {
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -96,7 +126,7 @@ func TestCreateExpense(t *testing.T) {
 
 			tt.mockRepo(mockRepo)
 
-			svc := services.NewExpenseService(nil, mockCurr, mockRepo)
+			svc := services.NewExpenseService(nil, mockCurr, mockRepo, nil, nil, nil)
 
 			err := svc.CreateExpense(context.Background(), tt.expense)
 			if (tt.expectedErr != nil && (err == nil || err.Error() != tt.expectedErr.Error())) ||
@@ -108,3 +138,511 @@ func TestCreateExpense(t *testing.T) {
 		})
 	}
 }
+
+func TestImportExpenses(t *testing.T) {
+	tests := []struct {
+		name         string
+		dryRun       bool
+		expenses     []*models.Expense
+		mockRepo     func(repo *mocks.MockExpenseRepository)
+		mockCurrency func(curr *mocks.MockCurrencyConverter)
+		expectedErrs []error
+	}{
+		{
+			name:   "ConvertsEachCurrencyOnce",
+			dryRun: false,
+			expenses: []*models.Expense{
+				{Currency: "EUR", Amount: 10},
+				{Currency: "USD", Amount: 5},
+				{Currency: "eur", Amount: 20},
+			},
+			mockRepo: func(repo *mocks.MockExpenseRepository) {
+				repo.EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(3)).
+					Return(nil)
+			},
+			mockCurrency: func(curr *mocks.MockCurrencyConverter) {
+				curr.EXPECT().
+					Convert(gomock.Any(), 1.0, "EUR", "USD").
+					Return(1.1, 1.1, nil).
+					Times(1)
+			},
+			expectedErrs: []error{nil, nil, nil},
+		},
+		{
+			name:   "DryRunDoesNotPersist",
+			dryRun: true,
+			expenses: []*models.Expense{
+				{Currency: "USD", Amount: 5},
+			},
+			mockRepo:     func(repo *mocks.MockExpenseRepository) {},
+			mockCurrency: func(curr *mocks.MockCurrencyConverter) {},
+			expectedErrs: []error{nil},
+		},
+		{
+			name:   "FailedCurrencyOnlyRejectsItsRows",
+			dryRun: false,
+			expenses: []*models.Expense{
+				{Currency: "GBP", Amount: 10},
+				{Currency: "USD", Amount: 5},
+			},
+			mockRepo: func(repo *mocks.MockExpenseRepository) {
+				repo.EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(1)).
+					Return(nil)
+			},
+			mockCurrency: func(curr *mocks.MockCurrencyConverter) {
+				curr.EXPECT().
+					Convert(gomock.Any(), 1.0, "GBP", "USD").
+					Return(0.0, 0.0, errors.New("upstream down"))
+			},
+			expectedErrs: []error{services.ErrCurrencyConversionFailed, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockCurr := mocks.NewMockCurrencyConverter(ctrl)
+			tt.mockRepo(mockRepo)
+			tt.mockCurrency(mockCurr)
+
+			svc := services.NewExpenseService(nil, mockCurr, mockRepo, nil, nil, nil)
+
+			rowErrs, err := svc.ImportExpenses(context.Background(), tt.expenses, tt.dryRun)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, expected := range tt.expectedErrs {
+				if !errors.Is(rowErrs[i], expected) {
+					t.Errorf("row %d: expected error %v, got %v", i, expected, rowErrs[i])
+				}
+			}
+			for i, exp := range tt.expenses {
+				if rowErrs[i] == nil && exp.AmountUSD != exp.Amount*exp.ExchangeRate {
+					t.Errorf("row %d: AmountUSD %v does not match rate %v", i, exp.AmountUSD, exp.ExchangeRate)
+				}
+			}
+		})
+	}
+}
+
+func TestGetExpensesCachesPerUserVersion(t *testing.T) {
+	filters := map[string]interface{}{"user_id": uint(42)}
+	cached := []models.Expense{{UserID: 42, Amount: 10, Currency: "USD"}}
+	tests := []struct {
+		name      string
+		version   *redis.StringCmd
+		listKey   string
+		cacheHit  bool
+		expectSet bool
+	}{
+		{
+			name:     "HitUnderCurrentVersion",
+			version:  redis.NewStringResult("3", nil),
+			listKey:  utils.ExpensesCacheKey(filters, 3, 0, 10),
+			cacheHit: true,
+		},
+		{
+			name:      "NoVersionYet",
+			version:   redis.NewStringResult("", redis.Nil),
+			listKey:   utils.ExpensesCacheKey(filters, 0, 0, 10),
+			expectSet: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			mockRedis.EXPECT().Get(gomock.Any(), "expenses:version:user:42").Return(tt.version)
+			if tt.cacheHit {
+				body, _ := json.Marshal(cached)
+				mockRedis.EXPECT().Get(gomock.Any(), tt.listKey).Return(redis.NewStringResult(string(body), nil))
+			} else {
+				mockRedis.EXPECT().Get(gomock.Any(), tt.listKey).Return(redis.NewStringResult("", redis.Nil))
+				mockRepo.EXPECT().GetExpenses(gomock.Any(), filters, 0, 10).Return(cached, nil)
+			}
+			if tt.expectSet {
+				mockRedis.EXPECT().Set(gomock.Any(), tt.listKey, gomock.Any(), ttlWithin(30*time.Minute, 0.1)).Return(redis.NewStatusResult("OK", nil))
+			}
+
+			svc := services.NewExpenseService(mockRedis, nil, mockRepo, nil, nil, nil)
+			expenses, err := svc.GetExpenses(context.Background(), filters, 0, 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(expenses) != 1 || expenses[0].UserID != 42 {
+				t.Errorf("unexpected expenses %+v", expenses)
+			}
+		})
+	}
+}
+
+func TestGetExpensesBypassesCacheWhenRedisFails(t *testing.T) {
+	for _, redisErr := range []error{errors.New("connection refused"), services.ErrRedisUnavailable} {
+		t.Run(redisErr.Error(), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			filters := map[string]interface{}{"user_id": uint(42)}
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockRepo.EXPECT().GetExpenses(gomock.Any(), filters, 0, 10).Return([]models.Expense{{UserID: 42}}, nil)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			mockRedis.EXPECT().Get(gomock.Any(), "expenses:version:user:42").Return(redis.NewStringResult("", redisErr))
+
+			svc := services.NewExpenseService(mockRedis, nil, mockRepo, nil, nil, nil)
+			expenses, err := svc.GetExpenses(context.Background(), filters, 0, 10)
+			if err != nil {
+				t.Fatalf("expected the database result, got error %v", err)
+			}
+			if len(expenses) != 1 {
+				t.Errorf("expected 1 expense, got %d", len(expenses))
+			}
+		})
+	}
+}
+
+func TestExpenseCacheInvalidatorBumpsOwnerVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:all").Return(redis.NewIntResult(8, nil))
+	mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:user:42").Return(redis.NewIntResult(4, nil))
+
+	data, _ := json.Marshal(events.ExpensePayload{ExpenseID: 7, UserID: 42})
+	invalidate := services.NewExpenseCacheInvalidator(mockRedis)
+	if err := invalidate(context.Background(), events.Event{Type: events.ExpenseUpdated, Data: data}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestExpenseCacheInvalidatorFailsWhileRedisDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:all").Return(redis.NewIntResult(0, services.ErrRedisUnavailable))
+
+	data, _ := json.Marshal(events.ExpensePayload{ExpenseID: 7, UserID: 42})
+	invalidate := services.NewExpenseCacheInvalidator(mockRedis)
+	// The error makes the outbox redeliver the event once Redis is back.
+	err := invalidate(context.Background(), events.Event{Type: events.ExpenseUpdated, Data: data})
+	if !errors.Is(err, services.ErrRedisUnavailable) {
+		t.Fatalf("expected %v, got %v", services.ErrRedisUnavailable, err)
+	}
+}
+
+// memRedis is an in-memory stand-in for Redis used by the benchmarks. It
+// models the work each command does, not network round trips.
+type memRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemRedis() *memRedis {
+	return &memRedis{data: map[string]string{}}
+}
+
+func (m *memRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	val, ok := m.data[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(val, nil)
+}
+
+func (m *memRedis) Set(ctx context.Context, key string, value interface{}, _ time.Duration) *redis.StatusCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := value.([]byte); ok {
+		m.data[key] = string(b)
+	} else {
+		m.data[key] = fmt.Sprint(value)
+	}
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m *memRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, key := range keys {
+		if _, ok := m.data[key]; ok {
+			delete(m.data, key)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func (m *memRedis) Incr(ctx context.Context, key string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, _ := strconv.ParseInt(m.data[key], 10, 64)
+	n++
+	m.data[key] = strconv.FormatInt(n, 10)
+	return redis.NewIntResult(n, nil)
+}
+
+// scanAndDelete is the previous invalidation strategy: walk the keyspace
+// for every cached list and delete it.
+func (m *memRedis) scanAndDelete(pattern string) {
+	m.mu.Lock()
+	var keys []string
+	for key := range m.data {
+		if ok, _ := path.Match(pattern, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.Unlock()
+	for _, key := range keys {
+		m.Del(context.Background(), key)
+	}
+}
+
+// seedExpenseLists caches lists for users pages each, as GetExpenses would.
+func seedExpenseLists(r *memRedis, users, pages int) {
+	for u := 1; u <= users; u++ {
+		filters := map[string]interface{}{"user_id": uint(u)}
+		version, _ := r.Get(context.Background(), utils.ExpensesCacheVersionKey(uint(u))).Int64()
+		for p := 0; p < pages; p++ {
+			r.Set(context.Background(), utils.ExpensesCacheKey(filters, version, p*20, 20), "[]", 0)
+		}
+	}
+}
+
+// BenchmarkExpenseCacheInvalidation compares the cost of one write's
+// invalidation as the keyspace grows, and reports how many other users'
+// lists each strategy throws away.
+func BenchmarkExpenseCacheInvalidation(b *testing.B) {
+	data, _ := json.Marshal(events.ExpensePayload{UserID: 1})
+	event := events.Event{Type: events.ExpenseUpdated, Data: data}
+
+	for _, users := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("ScanAndDelete/users=%d", users), func(b *testing.B) {
+			r := newMemRedis()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				seedExpenseLists(r, users, 5)
+				b.StartTimer()
+				r.scanAndDelete("expenses:*")
+			}
+			b.ReportMetric(float64((users-1)*5), "lists_lost/op")
+		})
+		b.Run(fmt.Sprintf("Generation/users=%d", users), func(b *testing.B) {
+			r := newMemRedis()
+			seedExpenseLists(r, users, 5)
+			invalidate := services.NewExpenseCacheInvalidator(r)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := invalidate(context.Background(), event); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(0, "lists_lost/op")
+		})
+	}
+}
+
+// BenchmarkGetExpensesCached measures a cache hit, which now costs one
+// extra GET for the version.
+func BenchmarkGetExpensesCached(b *testing.B) {
+	r := newMemRedis()
+	seedExpenseLists(r, 1000, 5)
+	svc := services.NewExpenseService(r, nil, nil, nil, nil, nil)
+	filters := map[string]interface{}{"user_id": uint(500)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := svc.GetExpenses(context.Background(), filters, 0, 20); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRestoreExpense(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoErr     error
+		expectedErr error
+	}{
+		{name: "Restored"},
+		{name: "NotDeleted", repoErr: repository.ErrExpenseNotFound, expectedErr: repository.ErrExpenseNotFound},
+		{name: "ReportSubmittedSinceDeletion", repoErr: repository.ErrReportNotEditable, expectedErr: services.ErrReportLocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			restored := &models.Expense{BaseModel: models.BaseModel{ID: 3}, UserID: 42}
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockRepo.EXPECT().RestoreExpense(gomock.Any(), uint(3)).Return(restored, tt.repoErr)
+
+			svc := services.NewExpenseService(nil, nil, mockRepo, nil, nil, nil)
+			expense, err := svc.RestoreExpense(context.Background(), 3)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && expense.ID != 3 {
+				t.Errorf("expected expense 3, got %+v", expense)
+			}
+		})
+	}
+}
+
+func TestDeleteExpense(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoErr     error
+		expectedErr error
+	}{
+		{name: "Deleted"},
+		{name: "StaleVersion", repoErr: repository.ErrVersionConflict, expectedErr: repository.ErrVersionConflict},
+		{name: "InSubmittedReport", repoErr: repository.ErrReportNotEditable, expectedErr: services.ErrReportLocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockRepo.EXPECT().GetExpenseByID(gomock.Any(), uint(3)).Return(nil, repository.ErrExpenseNotFound).AnyTimes()
+			mockRepo.EXPECT().DeleteExpense(gomock.Any(), uint(3), uint(42), uint(2)).Return(tt.repoErr)
+
+			svc := services.NewExpenseService(nil, nil, mockRepo, nil, nil, nil)
+			if err := svc.DeleteExpense(context.Background(), 3, 42, 2); !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestUpdateExpenseChecksVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoErr     error
+		expectedErr error
+	}{
+		{name: "CurrentVersion"},
+		{name: "StaleVersion", repoErr: repository.ErrVersionConflict, expectedErr: repository.ErrVersionConflict},
+		{name: "InSubmittedReport", repoErr: repository.ErrReportNotEditable, expectedErr: services.ErrReportLocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockRepo.EXPECT().UpdateExpense(gomock.Any(), uint(3), gomock.Any(), uint(42), uint(2)).Return(tt.repoErr)
+
+			svc := services.NewExpenseService(nil, nil, mockRepo, nil, nil, nil)
+			expense := &models.Expense{Amount: 10, Currency: "USD", Category: "meals"}
+			if err := svc.UpdateExpense(context.Background(), 3, expense, 42, 2); !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestPatchExpense(t *testing.T) {
+	amount, usd, empty := 50.0, "USD", ""
+	unchanged := 100.0
+	tests := []struct {
+		name         string
+		userID       uint
+		version      uint
+		patch        services.ExpensePatch
+		mockCurrency func(m *mocks.MockCurrencyConverter)
+		fields       map[string]interface{}
+		patchErr     error
+		expectedErr  error
+	}{
+		{
+			name:   "ClearDescriptionKeepsRate",
+			patch:  services.ExpensePatch{Description: &empty},
+			fields: map[string]interface{}{"description": ""},
+		},
+		{
+			name:  "AmountChangeReconverts",
+			patch: services.ExpensePatch{Amount: &amount},
+			mockCurrency: func(m *mocks.MockCurrencyConverter) {
+				m.EXPECT().Convert(gomock.Any(), 50.0, "EUR", "USD").Return(55.0, 1.1, nil)
+			},
+			fields: map[string]interface{}{"amount": 50.0, "amount_usd": 55.0, "exchange_rate": 1.1},
+		},
+		{
+			name:   "SwitchToUSDNeedsNoRate",
+			patch:  services.ExpensePatch{Currency: &usd},
+			fields: map[string]interface{}{"currency": "USD", "amount_usd": 100.0, "exchange_rate": 1.0},
+		},
+		{
+			name:  "UnchangedValuesAreANoop",
+			patch: services.ExpensePatch{Amount: &unchanged},
+		},
+		{
+			name:        "StaleVersion",
+			version:     1,
+			patch:       services.ExpensePatch{Description: &empty},
+			expectedErr: repository.ErrVersionConflict,
+		},
+		{
+			name:        "OtherOwner",
+			userID:      7,
+			patch:       services.ExpensePatch{Description: &empty},
+			expectedErr: repository.ErrExpenseNotFound,
+		},
+		{
+			name:        "DescriptionInSubmittedReport",
+			patch:       services.ExpensePatch{Description: &empty},
+			fields:      map[string]interface{}{"description": ""},
+			patchErr:    repository.ErrReportNotEditable,
+			expectedErr: services.ErrReportLocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			if tt.userID == 0 {
+				tt.userID = 42
+			}
+			if tt.version == 0 {
+				tt.version = 2
+			}
+			before := &models.Expense{
+				BaseModel: models.BaseModel{ID: 3}, UserID: 42, Amount: 100, AmountUSD: 108, ExchangeRate: 1.08,
+				Currency: "EUR", Category: "meals", Description: "team lunch", Version: 2,
+			}
+			mockRepo := mocks.NewMockExpenseRepository(ctrl)
+			mockRepo.EXPECT().GetExpenseByID(gomock.Any(), uint(3)).Return(before, nil)
+			if tt.fields != nil {
+				mockRepo.EXPECT().PatchExpense(gomock.Any(), uint(3), uint(42), uint(2), tt.fields).Return(tt.patchErr)
+			}
+			if tt.fields != nil && tt.patchErr == nil {
+				mockRepo.EXPECT().GetExpenseByID(gomock.Any(), uint(3)).Return(&models.Expense{BaseModel: models.BaseModel{ID: 3}, UserID: 42, Version: 3}, nil)
+			}
+			mockCurr := mocks.NewMockCurrencyConverter(ctrl)
+			if tt.mockCurrency != nil {
+				tt.mockCurrency(mockCurr)
+			}
+
+			svc := services.NewExpenseService(nil, mockCurr, mockRepo, nil, nil, nil)
+			expense, err := svc.PatchExpense(context.Background(), 3, tt.userID, tt.version, tt.patch)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && tt.fields != nil && expense.Version != 3 {
+				t.Errorf("expected the patched expense, got %+v", expense)
+			}
+		})
+	}
+}
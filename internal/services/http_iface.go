@@ -0,0 +1,8 @@
+package services
+
+import "net/http"
+
+// HTTPDoer sends outbound requests; in production it is an httpclient.Client.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
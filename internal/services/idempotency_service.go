@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// idempotencyLockTimeout is how long a key may stay claimed without a
+// response before it is considered abandoned by a crashed server.
+const idempotencyLockTimeout = 5 * time.Minute
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, record *models.IdempotencyKey) error
+	Release(ctx context.Context, key string)
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencySrv struct {
+	repo  repository.IdempotencyRepository
+	cache *Cache[*models.IdempotencyKey]
+	ttl   time.Duration
+}
+
+// NewIdempotencyService keeps keys for ttl. Postgres holds every key and
+// decides which request claims it; completed responses are also cached in
+// Redis so retries are replayed without a database round trip.
+func NewIdempotencyService(r RedisClient, repo repository.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencySrv{
+		repo:  repo,
+		cache: NewCache[*models.IdempotencyKey]("idempotency", r, CacheOptions{TTL: ttl}),
+		ttl:   ttl,
+	}
+}
+
+// Begin claims key for a request. It returns nil when the caller should run
+// the request, or the stored response when it already ran. It fails with
+// ErrIdempotencyKeyReused when the key was sent with a different request and
+// ErrIdempotencyInProgress while the first request has not finished.
+func (s *idempotencySrv) Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyKey, error) {
+	if record, found, _ := s.cache.Get(ctx, key); found && record != nil {
+		return checkIdempotencyKey(record, requestHash)
+	}
+	now := time.Now().UTC()
+	// A second pass is only needed when an abandoned or expired key is
+	// dropped, or the owner released it between Claim and Get.
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := s.repo.Claim(ctx, &models.IdempotencyKey{
+			Key:         key,
+			RequestHash: requestHash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.ttl),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+		record, err := s.repo.Get(ctx, key)
+		if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record.ExpiresAt.Before(now) || (!record.Completed() && record.CreatedAt.Before(now.Add(-idempotencyLockTimeout))) {
+			if err := s.repo.Delete(ctx, key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return checkIdempotencyKey(record, requestHash)
+	}
+	return nil, ErrIdempotencyInProgress
+}
+
+func checkIdempotencyKey(record *models.IdempotencyKey, requestHash string) (*models.IdempotencyKey, error) {
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !record.Completed() {
+		return nil, ErrIdempotencyInProgress
+	}
+	return record, nil
+}
+
+// Complete stores the response for a claimed key.
+func (s *idempotencySrv) Complete(ctx context.Context, record *models.IdempotencyKey) error {
+	if err := s.repo.Complete(ctx, record.Key, record.StatusCode, record.ContentType, record.ResponseBody); err != nil {
+		return err
+	}
+	s.cache.Set(ctx, record.Key, record)
+	return nil
+}
+
+// Release drops a claimed key without a response, so the request can be
+// retried with it, e.g. after a server error.
+func (s *idempotencySrv) Release(ctx context.Context, key string) {
+	if err := s.repo.Delete(ctx, key); err != nil {
+		log.Printf("failed to release idempotency key %q: %v", key, err)
+	}
+}
+
+// PurgeExpired deletes keys past their expiry. The worker runs it as a
+// scheduled job.
+func (s *idempotencySrv) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.PurgeExpired(ctx, time.Now())
+}
@@ -0,0 +1,180 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdempotencyBegin(t *testing.T) {
+	now := time.Now().UTC()
+	completed := &models.IdempotencyKey{
+		Key:          "k1",
+		RequestHash:  "h1",
+		StatusCode:   201,
+		ContentType:  "application/json",
+		ResponseBody: []byte(`{"message":"ok"}`),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(time.Hour),
+	}
+	completedJSON, _ := json.Marshal(completed)
+	redisMiss := func(r *mocks.MockRedisClient) {
+		r.EXPECT().Get(gomock.Any(), "k1").Return(redis.NewStringResult("", redis.Nil))
+	}
+
+	tests := []struct {
+		name           string
+		hash           string
+		mockRedis      func(r *mocks.MockRedisClient)
+		mockRepo       func(repo *mocks.MockIdempotencyRepository)
+		expectedStatus int
+		expectedErr    error
+	}{
+		{
+			name:      "NewKeyIsClaimed",
+			hash:      "h1",
+			mockRedis: redisMiss,
+			mockRepo: func(repo *mocks.MockIdempotencyRepository) {
+				repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "CachedResponseIsReplayed",
+			hash: "h1",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "k1").Return(redis.NewStringResult(string(completedJSON), nil))
+			},
+			mockRepo:       func(repo *mocks.MockIdempotencyRepository) {},
+			expectedStatus: 201,
+		},
+		{
+			name:      "StoredResponseIsReplayedWhenNotCached",
+			hash:      "h1",
+			mockRedis: redisMiss,
+			mockRepo: func(repo *mocks.MockIdempotencyRepository) {
+				repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(false, nil)
+				repo.EXPECT().Get(gomock.Any(), "k1").Return(completed, nil)
+			},
+			expectedStatus: 201,
+		},
+		{
+			name: "DifferentRequestIsRejected",
+			hash: "h2",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "k1").Return(redis.NewStringResult(string(completedJSON), nil))
+			},
+			mockRepo:    func(repo *mocks.MockIdempotencyRepository) {},
+			expectedErr: services.ErrIdempotencyKeyReused,
+		},
+		{
+			name:      "RunningRequestIsInProgress",
+			hash:      "h1",
+			mockRedis: redisMiss,
+			mockRepo: func(repo *mocks.MockIdempotencyRepository) {
+				repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(false, nil)
+				repo.EXPECT().Get(gomock.Any(), "k1").
+					Return(&models.IdempotencyKey{Key: "k1", RequestHash: "h1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, nil)
+			},
+			expectedErr: services.ErrIdempotencyInProgress,
+		},
+		{
+			name:      "AbandonedClaimIsTakenOver",
+			hash:      "h1",
+			mockRedis: redisMiss,
+			mockRepo: func(repo *mocks.MockIdempotencyRepository) {
+				gomock.InOrder(
+					repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(false, nil),
+					repo.EXPECT().Get(gomock.Any(), "k1").Return(&models.IdempotencyKey{
+						Key: "k1", RequestHash: "h1", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour),
+					}, nil),
+					repo.EXPECT().Delete(gomock.Any(), "k1").Return(nil),
+					repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(true, nil),
+				)
+			},
+		},
+		{
+			name: "RedisDownFallsBackToPostgres",
+			hash: "h1",
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "k1").Return(redis.NewStringResult("", services.ErrRedisUnavailable))
+			},
+			mockRepo: func(repo *mocks.MockIdempotencyRepository) {
+				repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(false, nil)
+				repo.EXPECT().Get(gomock.Any(), "k1").Return(completed, nil)
+			},
+			expectedStatus: 201,
+		},
+		{
+			name:      "DatabaseError",
+			hash:      "h1",
+			mockRedis: redisMiss,
+			mockRepo: func(repo *mocks.MockIdempotencyRepository) {
+				repo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(false, errDBDown)
+			},
+			expectedErr: errDBDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+			tt.mockRedis(mockRedis)
+			tt.mockRepo(mockRepo)
+
+			svc := services.NewIdempotencyService(mockRedis, mockRepo, 24*time.Hour)
+			stored, err := svc.Begin(context.Background(), "k1", tt.hash)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			switch {
+			case tt.expectedStatus == 0 && stored != nil:
+				t.Errorf("expected the request to run, got stored response %+v", stored)
+			case tt.expectedStatus != 0 && (stored == nil || stored.StatusCode != tt.expectedStatus || string(stored.ResponseBody) != `{"message":"ok"}`):
+				t.Errorf("expected stored %d response, got %+v", tt.expectedStatus, stored)
+			}
+		})
+	}
+}
+
+func TestIdempotencyCompleteCachesResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	record := &models.IdempotencyKey{Key: "k1", RequestHash: "h1", StatusCode: 201, ContentType: "application/json", ResponseBody: []byte(`{}`)}
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	mockRepo.EXPECT().Complete(gomock.Any(), "k1", 201, "application/json", []byte(`{}`)).Return(nil)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Set(gomock.Any(), "k1", gomock.Any(), 24*time.Hour).Return(redis.NewStatusResult("OK", nil))
+
+	svc := services.NewIdempotencyService(mockRedis, mockRepo, 24*time.Hour)
+	if err := svc.Complete(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIdempotencyCompleteFailureIsNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	mockRepo.EXPECT().Complete(gomock.Any(), "k1", 201, "", nil).Return(repository.ErrIdempotencyKeyNotFound)
+
+	svc := services.NewIdempotencyService(mocks.NewMockRedisClient(ctrl), mockRepo, 24*time.Hour)
+	err := svc.Complete(context.Background(), &models.IdempotencyKey{Key: "k1", StatusCode: 201})
+	if !errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("expected ErrIdempotencyKeyNotFound, got %v", err)
+	}
+}
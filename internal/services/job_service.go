@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+const (
+	// jobTimeout bounds a handler run. The lease outlasts it so the result
+	// is saved before another worker may claim the job again.
+	jobTimeout        = 5 * time.Minute
+	jobLease          = jobTimeout + time.Minute
+	jobMaxAttempts    = 5
+	jobBaseBackoff    = 10 * time.Second
+	jobMaxBackoff     = time.Hour
+	jobRetention      = 7 * 24 * time.Hour
+	jobPurgeKind      = "jobs.purge"
+	jobPurgeSchedule  = "@daily"
+	defaultJobWorkers = 4
+	// A worker runs jobs.purge daily, so a live one touches the queue at
+	// least that often.
+	jobWorkerIdleAfter  = 25 * time.Hour
+	jobWorkerStallAfter = 10 * time.Minute
+)
+
+var (
+	ErrUnknownJobKind   = errors.New("unknown job kind")
+	ErrJobNotRetryable  = errors.New("only dead jobs can be retried")
+	errJobWorkerTimeout = errors.New("lease expired before the job finished")
+)
+
+// JobHandler runs one job. Returning an error schedules a retry.
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+type JobStats struct {
+	Counts []repository.JobStatusCount `json:"counts"`
+}
+
+// JobService queues background work in the jobs table and runs it in the
+// worker process. Jobs are claimed with SELECT ... FOR UPDATE SKIP LOCKED, so
+// any number of workers can share the queue, and run at least once.
+type JobService interface {
+	Register(kind string, handler JobHandler)
+	Schedule(kind, spec string) error
+	Enqueue(ctx context.Context, kind string, payload interface{}, runAt time.Time) (*models.Job, error)
+	RunDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+	ListJobs(ctx context.Context, status, kind string, offset, limit int) ([]models.Job, error)
+	GetJob(ctx context.Context, id uint) (*models.Job, error)
+	Stats(ctx context.Context) (*JobStats, error)
+	Retry(ctx context.Context, id uint) (*models.Job, error)
+	PurgeFinished(ctx context.Context) (int64, error)
+	WorkerUp(ctx context.Context) (bool, error)
+}
+
+type jobSchedule struct {
+	kind     string
+	schedule *cronSchedule
+	next     time.Time
+}
+
+type jobSrv struct {
+	repo      repository.JobRepository
+	workers   int
+	mu        sync.Mutex
+	handlers  map[string]JobHandler
+	schedules []*jobSchedule
+}
+
+// NewJobService builds the queue. workers bounds how many jobs one process
+// runs at the same time. The jobs table is purged of old finished jobs daily.
+func NewJobService(repo repository.JobRepository, workers int) JobService {
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+	s := &jobSrv{repo: repo, workers: workers, handlers: map[string]JobHandler{}}
+	s.Register(jobPurgeKind, func(ctx context.Context, _ json.RawMessage) error {
+		n, err := s.PurgeFinished(ctx)
+		if err == nil && n > 0 {
+			log.Printf("purged %d finished jobs", n)
+		}
+		return err
+	})
+	if err := s.Schedule(jobPurgeKind, jobPurgeSchedule); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *jobSrv) Register(kind string, handler JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = handler
+}
+
+// Schedule enqueues kind on the cron spec while Run is active. Each slot is
+// keyed by kind and time, so several workers enqueue it only once.
+func (s *jobSrv) Schedule(kind, spec string) error {
+	schedule, err := parseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.handlers[kind]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobKind, kind)
+	}
+	s.schedules = append(s.schedules, &jobSchedule{kind: kind, schedule: schedule, next: schedule.next(time.Now())})
+	return nil
+}
+
+func (s *jobSrv) Enqueue(ctx context.Context, kind string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	return s.enqueue(ctx, kind, payload, runAt, nil)
+}
+
+func (s *jobSrv) enqueue(ctx context.Context, kind string, payload interface{}, runAt time.Time, uniqueKey *string) (*models.Job, error) {
+	raw := []byte("{}")
+	if payload != nil {
+		var err error
+		if raw, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	job := &models.Job{
+		Kind:        kind,
+		Payload:     string(raw),
+		Status:      models.JobPending,
+		MaxAttempts: jobMaxAttempts,
+		RunAt:       runAt,
+		UniqueKey:   uniqueKey,
+	}
+	if err := s.repo.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// RunDue claims up to one job per worker and runs them concurrently. It
+// returns how many jobs it claimed.
+func (s *jobSrv) RunDue(ctx context.Context) (int, error) {
+	claimed, err := s.repo.ClaimDue(ctx, time.Now(), jobLease, s.workers)
+	if err != nil {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	for i := range claimed {
+		wg.Add(1)
+		go func(job *models.Job) {
+			defer wg.Done()
+			s.process(ctx, job)
+		}(&claimed[i])
+	}
+	wg.Wait()
+	return len(claimed), nil
+}
+
+// Run enqueues scheduled jobs and works the queue until ctx is cancelled,
+// polling every interval while the queue is empty.
+func (s *jobSrv) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.enqueueScheduled(ctx, time.Now())
+		for ctx.Err() == nil {
+			n, err := s.RunDue(ctx)
+			if err != nil {
+				log.Printf("job runner: %v", err)
+			}
+			if n < s.workers {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *jobSrv) enqueueScheduled(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sch := range s.schedules {
+		if now.Before(sch.next) {
+			continue
+		}
+		key := fmt.Sprintf("%s@%d", sch.kind, sch.next.Unix())
+		if _, err := s.enqueue(ctx, sch.kind, nil, sch.next, &key); err != nil {
+			log.Printf("failed to enqueue scheduled job %s: %v", sch.kind, err)
+			continue
+		}
+		sch.next = sch.schedule.next(now)
+	}
+}
+
+func (s *jobSrv) process(ctx context.Context, job *models.Job) {
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// The previous worker died holding the job and used up its last attempt.
+		err = errJobWorkerTimeout
+	} else {
+		err = s.execute(ctx, job)
+	}
+
+	if err == nil {
+		now := time.Now()
+		job.Status = models.JobSucceeded
+		job.FinishedAt = &now
+		job.LastError = ""
+	} else {
+		job.LastError = err.Error()
+		if job.Attempts >= job.MaxAttempts || errors.Is(err, ErrUnknownJobKind) {
+			now := time.Now()
+			job.Status = models.JobDead
+			job.FinishedAt = &now
+			log.Printf("job %d (%s) moved to dead letter: %v", job.ID, job.Kind, err)
+		} else {
+			job.Status = models.JobPending
+			job.RunAt = time.Now().Add(exponentialBackoff(job.Attempts, jobBaseBackoff, jobMaxBackoff))
+		}
+	}
+	if err := s.repo.SaveResult(ctx, job); err != nil {
+		log.Printf("failed to save job %d: %v", job.ID, err)
+	}
+}
+
+func (s *jobSrv) execute(ctx context.Context, job *models.Job) (err error) {
+	s.mu.Lock()
+	handler, ok := s.handlers[job.Kind]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobKind, job.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+func (s *jobSrv) ListJobs(ctx context.Context, status, kind string, offset, limit int) ([]models.Job, error) {
+	return s.repo.ListJobs(ctx, status, kind, offset, limit)
+}
+
+func (s *jobSrv) GetJob(ctx context.Context, id uint) (*models.Job, error) {
+	return s.repo.GetJob(ctx, id)
+}
+
+func (s *jobSrv) Stats(ctx context.Context) (*JobStats, error) {
+	counts, err := s.repo.CountByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &JobStats{Counts: counts}, nil
+}
+
+// Retry moves a dead job back into the queue with a fresh set of attempts.
+func (s *jobSrv) Retry(ctx context.Context, id uint) (*models.Job, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobDead {
+		return nil, ErrJobNotRetryable
+	}
+	if err := s.repo.Requeue(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetJob(ctx, id)
+}
+
+func (s *jobSrv) PurgeFinished(ctx context.Context) (int64, error) {
+	return s.repo.PurgeFinished(ctx, time.Now().Add(-jobRetention))
+}
+
+// WorkerUp reports whether a worker process is working the queue: one has
+// run a job within the last day and no due job has waited longer than
+// jobWorkerStallAfter. Scheduled jobs are only enqueued by workers, so
+// without one nothing runs at all.
+func (s *jobSrv) WorkerUp(ctx context.Context) (bool, error) {
+	now := time.Now()
+	activity, err := s.repo.Activity(ctx, now)
+	if err != nil {
+		return false, err
+	}
+	if activity.LastWorkedAt == nil || now.Sub(*activity.LastWorkedAt) > jobWorkerIdleAfter {
+		return false, nil
+	}
+	return activity.OldestDueAt == nil || now.Sub(*activity.OldestDueAt) <= jobWorkerStallAfter, nil
+}
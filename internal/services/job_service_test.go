@@ -0,0 +1,193 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRunDue(t *testing.T) {
+	tests := []struct {
+		name           string
+		kind           string
+		attempts       int
+		handler        services.JobHandler
+		expectedStatus string
+		expectedWait   time.Duration
+	}{
+		{
+			name:           "Succeeds",
+			kind:           "report.reminder",
+			attempts:       1,
+			handler:        func(context.Context, json.RawMessage) error { return nil },
+			expectedStatus: models.JobSucceeded,
+		},
+		{
+			name:           "FailureRetriesWithBackoff",
+			kind:           "report.reminder",
+			attempts:       2,
+			handler:        func(context.Context, json.RawMessage) error { return errors.New("smtp down") },
+			expectedStatus: models.JobPending,
+			expectedWait:   20 * time.Second,
+		},
+		{
+			name:           "PanicIsAFailure",
+			kind:           "report.reminder",
+			attempts:       1,
+			handler:        func(context.Context, json.RawMessage) error { panic("nil map") },
+			expectedStatus: models.JobPending,
+			expectedWait:   10 * time.Second,
+		},
+		{
+			name:           "DeadAfterMaxAttempts",
+			kind:           "report.reminder",
+			attempts:       5,
+			handler:        func(context.Context, json.RawMessage) error { return errors.New("smtp down") },
+			expectedStatus: models.JobDead,
+		},
+		{
+			name:           "UnknownKindIsDead",
+			kind:           "unknown",
+			attempts:       1,
+			expectedStatus: models.JobDead,
+		},
+		{
+			name:           "AbandonedOnLastAttemptIsDead",
+			kind:           "report.reminder",
+			attempts:       6,
+			handler:        func(context.Context, json.RawMessage) error { t.Error("handler should not run"); return nil },
+			expectedStatus: models.JobDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			job := models.Job{
+				BaseModel:   models.BaseModel{ID: 1},
+				Kind:        tt.kind,
+				Payload:     `{"report_id":3}`,
+				Status:      models.JobRunning,
+				Attempts:    tt.attempts,
+				MaxAttempts: 5,
+			}
+			repo := mocks.NewMockJobRepository(ctrl)
+			repo.EXPECT().ClaimDue(gomock.Any(), gomock.Any(), gomock.Any(), 2).Return([]models.Job{job}, nil)
+			var saved *models.Job
+			repo.EXPECT().SaveResult(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, j *models.Job) error {
+					saved = j
+					return nil
+				})
+
+			svc := services.NewJobService(repo, 2)
+			if tt.handler != nil {
+				svc.Register("report.reminder", func(ctx context.Context, payload json.RawMessage) error {
+					if string(payload) != job.Payload {
+						t.Errorf("unexpected payload %s", payload)
+					}
+					return tt.handler(ctx, payload)
+				})
+			}
+
+			start := time.Now()
+			n, err := svc.RunDue(context.Background())
+			if err != nil || n != 1 {
+				t.Fatalf("expected 1 job run, got %d (%v)", n, err)
+			}
+			if saved.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s (%s)", tt.expectedStatus, saved.Status, saved.LastError)
+			}
+			if tt.expectedStatus != models.JobSucceeded && saved.LastError == "" {
+				t.Error("expected last error to be recorded")
+			}
+			if tt.expectedStatus != models.JobPending && saved.FinishedAt == nil {
+				t.Error("expected finished_at to be set")
+			}
+			if tt.expectedWait > 0 {
+				wait := saved.RunAt.Sub(start)
+				if wait < tt.expectedWait || wait > tt.expectedWait+time.Second {
+					t.Errorf("expected retry in %s, got %s", tt.expectedWait, wait)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockJobRepository(ctrl)
+	svc := services.NewJobService(repo, 1)
+
+	repo.EXPECT().GetJob(gomock.Any(), uint(1)).Return(&models.Job{Status: models.JobSucceeded}, nil)
+	if _, err := svc.Retry(context.Background(), 1); !errors.Is(err, services.ErrJobNotRetryable) {
+		t.Fatalf("expected ErrJobNotRetryable, got %v", err)
+	}
+
+	repo.EXPECT().GetJob(gomock.Any(), uint(2)).Return(&models.Job{Status: models.JobDead}, nil)
+	repo.EXPECT().Requeue(gomock.Any(), uint(2), gomock.Any()).Return(nil)
+	repo.EXPECT().GetJob(gomock.Any(), uint(2)).Return(&models.Job{Status: models.JobPending}, nil)
+	job, err := svc.Retry(context.Background(), 2)
+	if err != nil || job.Status != models.JobPending {
+		t.Fatalf("expected requeued job, got %+v (%v)", job, err)
+	}
+}
+
+func TestScheduleRejectsInvalidSpec(t *testing.T) {
+	svc := services.NewJobService(nil, 1)
+	svc.Register("report.reminder", func(context.Context, json.RawMessage) error { return nil })
+
+	for _, spec := range []string{"* * * *", "61 * * * *", "*/0 * * * *", "@every 10ms", "@yearly"} {
+		if err := svc.Schedule("report.reminder", spec); !errors.Is(err, services.ErrInvalidSchedule) {
+			t.Errorf("%q: expected ErrInvalidSchedule, got %v", spec, err)
+		}
+	}
+	if err := svc.Schedule("unknown", "@hourly"); !errors.Is(err, services.ErrUnknownJobKind) {
+		t.Errorf("expected ErrUnknownJobKind, got %v", err)
+	}
+}
+
+func TestWorkerUp(t *testing.T) {
+	ago := func(d time.Duration) *time.Time {
+		at := time.Now().Add(-d)
+		return &at
+	}
+	tests := []struct {
+		name     string
+		activity repository.JobActivity
+		expected bool
+	}{
+		{name: "NoWorkerEverRan", activity: repository.JobActivity{}, expected: false},
+		{name: "Idle", activity: repository.JobActivity{LastWorkedAt: ago(26 * time.Hour)}, expected: false},
+		{name: "Working", activity: repository.JobActivity{LastWorkedAt: ago(time.Hour), OldestDueAt: ago(time.Minute)}, expected: true},
+		{name: "QueueStalled", activity: repository.JobActivity{LastWorkedAt: ago(time.Hour), OldestDueAt: ago(20 * time.Minute)}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockJobRepository(ctrl)
+			activity := tt.activity
+			repo.EXPECT().Activity(gomock.Any(), gomock.Any()).Return(&activity, nil)
+
+			up, err := services.NewJobService(repo, 1).WorkerUp(context.Background())
+			if err != nil || up != tt.expected {
+				t.Errorf("expected %v, got %v (%v)", tt.expected, up, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/notify"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+var ErrUnknownNotificationEvent = errors.New("unknown notification event")
+
+// NotificationEvents are the event types users are notified about.
+var NotificationEvents = []string{
+	events.ReportSubmitted,
+	events.ReportApproved,
+	events.ReportRejected,
+	events.ReportPolicyViolation,
+}
+
+// NotificationChannel delivers a rendered notification to one user.
+type NotificationChannel interface {
+	Name() string
+	Deliver(ctx context.Context, recipient *models.User, notification *models.Notification) error
+}
+
+type EmailSender interface {
+	Send(ctx context.Context, to string, msg notify.Message) error
+}
+
+type NotificationService interface {
+	HandleEvent(ctx context.Context, event events.Event) error
+	List(ctx context.Context, userID uint, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error)
+	MarkRead(ctx context.Context, userID, id uint, read bool) error
+	MarkAllRead(ctx context.Context, userID uint) error
+	GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error)
+	UpdatePreference(ctx context.Context, pref *models.NotificationPreference) error
+}
+
+type notificationSrv struct {
+	repo        repository.NotificationRepository
+	userRepo    repository.UserRepository
+	approverIDs []uint
+	channels    []NotificationChannel
+}
+
+// NewNotificationService builds the service. approverIDs are the users told
+// about submitted reports and policy violations.
+func NewNotificationService(repo repository.NotificationRepository, userRepo repository.UserRepository, approverIDs []uint, channels ...NotificationChannel) NotificationService {
+	return &notificationSrv{
+		repo:        repo,
+		userRepo:    userRepo,
+		approverIDs: approverIDs,
+		channels:    channels,
+	}
+}
+
+// HandleEvent notifies everyone concerned by a report event on every channel
+// they have not switched off. It is meant to be subscribed to the event bus.
+// Each user is sent an event at most once per channel, so when a channel
+// fails and the event is redelivered only that channel is retried.
+func (s *notificationSrv) HandleEvent(ctx context.Context, event events.Event) error {
+	if !notify.HasTemplate(event.Type) {
+		return nil
+	}
+	var report events.ReportPayload
+	if err := event.Decode(&report); err != nil {
+		return err
+	}
+
+	data := notify.TemplateData{Report: report, Actor: s.actorName(ctx, report.ActorID)}
+	var errs []error
+	for _, userID := range s.recipients(event.Type, report) {
+		if err := s.notify(ctx, event.ID, userID, event.Type, data); err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", userID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *notificationSrv) List(ctx context.Context, userID uint, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error) {
+	notifications, err := s.repo.ListForUser(ctx, userID, unreadOnly, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.repo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, unread, nil
+}
+
+func (s *notificationSrv) MarkRead(ctx context.Context, userID, id uint, read bool) error {
+	return s.repo.MarkRead(ctx, userID, id, read)
+}
+
+func (s *notificationSrv) MarkAllRead(ctx context.Context, userID uint) error {
+	return s.repo.MarkAllRead(ctx, userID)
+}
+
+// GetPreferences returns a preference for every notification event, filling
+// in the enabled-by-default ones the user has never changed.
+func (s *notificationSrv) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	stored, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byEvent := map[string]models.NotificationPreference{}
+	for _, p := range stored {
+		byEvent[p.Event] = p
+	}
+	prefs := make([]models.NotificationPreference, 0, len(NotificationEvents))
+	for _, event := range NotificationEvents {
+		p, ok := byEvent[event]
+		if !ok {
+			p = models.NotificationPreference{UserID: userID, Event: event, Email: true, InApp: true}
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, nil
+}
+
+func (s *notificationSrv) UpdatePreference(ctx context.Context, pref *models.NotificationPreference) error {
+	if !notify.HasTemplate(pref.Event) {
+		return ErrUnknownNotificationEvent
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, pref.UserID); err != nil {
+		return err
+	}
+	return s.repo.SavePreference(ctx, pref)
+}
+
+func (s *notificationSrv) recipients(eventType string, report events.ReportPayload) []uint {
+	switch eventType {
+	case events.ReportSubmitted:
+		return s.approversExcept(report.UserID)
+	case events.ReportPolicyViolation:
+		return append([]uint{report.UserID}, s.approversExcept(report.UserID)...)
+	default:
+		return []uint{report.UserID}
+	}
+}
+
+func (s *notificationSrv) approversExcept(userID uint) []uint {
+	out := make([]uint, 0, len(s.approverIDs))
+	for _, id := range s.approverIDs {
+		if id != userID {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func (s *notificationSrv) notify(ctx context.Context, eventID string, userID uint, eventType string, data notify.TemplateData) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	data.Recipient = user
+	msg, err := notify.Render(eventType, data)
+	if err != nil {
+		return err
+	}
+	notification := &models.Notification{
+		UserID: userID,
+		Event:  eventType,
+		Title:  msg.Subject,
+		Body:   msg.Body,
+		Link:   fmt.Sprintf("/api/reports/%d", data.Report.ReportID),
+	}
+	if data.Report.ActorID != 0 {
+		actorID := data.Report.ActorID
+		notification.ActorID = &actorID
+	}
+
+	var errs []error
+	for _, ch := range s.channels {
+		if !channelEnabled(prefs, eventType, ch.Name()) {
+			continue
+		}
+		claimed, err := s.repo.ClaimDelivery(ctx, eventID, userID, ch.Name())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := ch.Deliver(ctx, user, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name(), err))
+			if err := s.repo.ReleaseDelivery(ctx, eventID, userID, ch.Name()); err != nil {
+				log.Printf("failed to release %s notification %s for user %d: %v", ch.Name(), eventID, userID, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *notificationSrv) actorName(ctx context.Context, actorID uint) string {
+	if actorID == 0 {
+		return "Someone"
+	}
+	user, err := s.userRepo.GetUserByID(ctx, actorID)
+	if err != nil {
+		return fmt.Sprintf("user #%d", actorID)
+	}
+	return user.Name
+}
+
+func channelEnabled(prefs []models.NotificationPreference, eventType, channel string) bool {
+	for _, p := range prefs {
+		if p.Event != eventType {
+			continue
+		}
+		switch channel {
+		case models.NotificationChannelEmail:
+			return p.Email
+		case models.NotificationChannelInApp:
+			return p.InApp
+		}
+	}
+	return true
+}
+
+type inAppChannel struct {
+	repo repository.NotificationRepository
+}
+
+// NewInAppChannel stores notifications in Postgres for GET /api/notifications.
+func NewInAppChannel(repo repository.NotificationRepository) NotificationChannel {
+	return &inAppChannel{repo: repo}
+}
+
+func (c *inAppChannel) Name() string { return models.NotificationChannelInApp }
+
+func (c *inAppChannel) Deliver(ctx context.Context, _ *models.User, notification *models.Notification) error {
+	n := *notification
+	return c.repo.Create(ctx, &n)
+}
+
+type emailChannel struct {
+	sender EmailSender
+}
+
+func NewEmailChannel(sender EmailSender) NotificationChannel {
+	return &emailChannel{sender: sender}
+}
+
+func (c *emailChannel) Name() string { return models.NotificationChannelEmail }
+
+func (c *emailChannel) Deliver(ctx context.Context, recipient *models.User, notification *models.Notification) error {
+	return c.sender.Send(ctx, recipient.Email, notify.Message{Subject: notification.Title, Body: notification.Body})
+}
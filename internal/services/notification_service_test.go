@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/notify"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleReportEvent(t *testing.T) {
+	users := map[uint]*models.User{
+		1: {BaseModel: models.BaseModel{ID: 1}, Name: "Ada", Email: "ada@example.com"},
+		2: {BaseModel: models.BaseModel{ID: 2}, Name: "Bola", Email: "bola@example.com"},
+		3: {BaseModel: models.BaseModel{ID: 3}, Name: "Chidi", Email: "chidi@example.com"},
+	}
+
+	tests := []struct {
+		name        string
+		eventType   string
+		payload     events.ReportPayload
+		prefs       map[uint][]models.NotificationPreference
+		expectInApp []uint
+		expectEmail []string
+		expectText  string
+	}{
+		{
+			name:      "SubmittedNotifiesApprovers",
+			eventType: events.ReportSubmitted,
+			payload:   events.ReportPayload{ReportID: 7, UserID: 1, Title: "Lagos trip", Total: 120, ActorID: 1},
+			prefs: map[uint][]models.NotificationPreference{
+				3: {{UserID: 3, Event: events.ReportSubmitted, Email: false, InApp: true}},
+			},
+			expectInApp: []uint{2, 3},
+			expectEmail: []string{"bola@example.com"},
+			expectText:  "Ada submitted the expense report \"Lagos trip\" totalling 120.00 USD",
+		},
+		{
+			name:        "RejectedNotifiesOwner",
+			eventType:   events.ReportRejected,
+			payload:     events.ReportPayload{ReportID: 7, UserID: 1, Title: "Lagos trip", ActorID: 2, Reason: "missing receipts"},
+			expectInApp: []uint{1},
+			expectEmail: []string{"ada@example.com"},
+			expectText:  "Reason: missing receipts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockNotificationRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			mockSender := mocks.NewMockEmailSender(ctrl)
+
+			mockUserRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, id uint) (*models.User, error) {
+					return users[id], nil
+				}).AnyTimes()
+			mockRepo.EXPECT().GetPreferences(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, id uint) ([]models.NotificationPreference, error) {
+					return tt.prefs[id], nil
+				}).AnyTimes()
+
+			mockRepo.EXPECT().ClaimDelivery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+
+			var inApp []uint
+			mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, n *models.Notification) error {
+					if !strings.Contains(n.Body, tt.expectText) {
+						t.Errorf("expected body to contain %q, got %q", tt.expectText, n.Body)
+					}
+					inApp = append(inApp, n.UserID)
+					return nil
+				}).Times(len(tt.expectInApp))
+
+			var emailed []string
+			mockSender.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, to string, _ notify.Message) error {
+					emailed = append(emailed, to)
+					return nil
+				}).Times(len(tt.expectEmail))
+
+			svc := services.NewNotificationService(mockRepo, mockUserRepo, []uint{1, 2, 3},
+				services.NewInAppChannel(mockRepo), services.NewEmailChannel(mockSender))
+
+			event, err := events.New(tt.eventType, tt.payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := svc.HandleEvent(context.Background(), event); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(inApp) != len(tt.expectInApp) || len(emailed) != len(tt.expectEmail) {
+				t.Fatalf("expected in-app %v and email %v, got %v and %v", tt.expectInApp, tt.expectEmail, inApp, emailed)
+			}
+			for i := range tt.expectEmail {
+				if emailed[i] != tt.expectEmail[i] {
+					t.Errorf("expected email to %s, got %s", tt.expectEmail[i], emailed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRedeliveredEventOnlyRetriesFailedChannels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	owner := &models.User{BaseModel: models.BaseModel{ID: 1}, Name: "Ada", Email: "ada@example.com"}
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockSender := mocks.NewMockEmailSender(ctrl)
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Return(owner, nil).AnyTimes()
+	mockRepo.EXPECT().GetPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	claims := map[string]bool{}
+	claimKey := func(eventID string, userID uint, channel string) string {
+		return fmt.Sprintf("%s/%d/%s", eventID, userID, channel)
+	}
+	mockRepo.EXPECT().ClaimDelivery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, eventID string, userID uint, channel string) (bool, error) {
+			key := claimKey(eventID, userID, channel)
+			if claims[key] {
+				return false, nil
+			}
+			claims[key] = true
+			return true, nil
+		}).AnyTimes()
+	mockRepo.EXPECT().ReleaseDelivery(gomock.Any(), gomock.Any(), uint(1), models.NotificationChannelEmail).
+		DoAndReturn(func(_ context.Context, eventID string, userID uint, channel string) error {
+			delete(claims, claimKey(eventID, userID, channel))
+			return nil
+		})
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	gomock.InOrder(
+		mockSender.EXPECT().Send(gomock.Any(), "ada@example.com", gomock.Any()).Return(errors.New("smtp down")),
+		mockSender.EXPECT().Send(gomock.Any(), "ada@example.com", gomock.Any()).Return(nil),
+	)
+
+	svc := services.NewNotificationService(mockRepo, mockUserRepo, nil,
+		services.NewInAppChannel(mockRepo), services.NewEmailChannel(mockSender))
+	event, err := events.New(events.ReportApproved, events.ReportPayload{ReportID: 7, UserID: 1, Title: "Lagos trip", ActorID: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.HandleEvent(context.Background(), event); err == nil {
+		t.Fatal("expected the email failure to be returned so the event is redelivered")
+	}
+	if err := svc.HandleEvent(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error on redelivery: %v", err)
+	}
+	if err := svc.HandleEvent(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error on a further redelivery: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	outboxBatchSize    = 100
+	outboxLease        = time.Minute
+	outboxMaxAttempts  = 10
+	outboxBaseBackoff  = 5 * time.Second
+	outboxMaxBackoff   = 10 * time.Minute
+	outboxRetention    = 7 * 24 * time.Hour
+	outboxStreamMaxLen = 100000
+)
+
+// StreamAdder is the part of the Redis client used to mirror events onto a
+// Redis Stream.
+type StreamAdder interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+}
+
+// OutboxService publishes events through the outbox_events table. Publish
+// only writes the row, inside the caller's transaction when there is one; a
+// dispatcher later hands committed events to the subscribers. Delivery is
+// at least once, so subscribers must tolerate seeing an event twice.
+type OutboxService interface {
+	events.Broker
+	DispatchPending(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+	PurgePublished(ctx context.Context) (int64, error)
+}
+
+type outboxSrv struct {
+	repo       repository.OutboxRepository
+	bus        *events.Bus
+	stream     StreamAdder
+	streamName string
+	wake       chan struct{}
+}
+
+// NewOutboxService builds the outbox. When stream is not nil every event is
+// also appended to the Redis Stream streamName.
+func NewOutboxService(repo repository.OutboxRepository, bus *events.Bus, stream StreamAdder, streamName string) OutboxService {
+	return &outboxSrv{
+		repo:       repo,
+		bus:        bus,
+		stream:     stream,
+		streamName: streamName,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (s *outboxSrv) Subscribe(eventType string, h events.Handler) {
+	s.bus.Subscribe(eventType, h)
+}
+
+func (s *outboxSrv) Publish(ctx context.Context, eventType string, payload interface{}) error {
+	event, err := events.New(eventType, payload)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Add(ctx, event); err != nil {
+		return err
+	}
+	// Dispatch as soon as the event is visible instead of waiting for the
+	// next poll.
+	repository.AfterCommit(ctx, s.nudge)
+	return nil
+}
+
+// DispatchPending delivers a batch of pending events and records the
+// outcome of each. It returns how many events it claimed.
+func (s *outboxSrv) DispatchPending(ctx context.Context) (int, error) {
+	claimed, err := s.repo.ClaimPending(ctx, time.Now(), outboxLease, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range claimed {
+		e := &claimed[i]
+		e.Attempts++
+		if err := s.dispatch(ctx, e); err != nil {
+			e.LastError = err.Error()
+			if e.Attempts >= outboxMaxAttempts {
+				e.Status = models.OutboxFailed
+				log.Printf("outbox event %s (%s) failed permanently: %v", e.EventID, e.EventType, err)
+			} else {
+				e.AvailableAt = time.Now().Add(exponentialBackoff(e.Attempts, outboxBaseBackoff, outboxMaxBackoff))
+			}
+		} else {
+			now := time.Now()
+			e.Status = models.OutboxPublished
+			e.PublishedAt = &now
+			e.LastError = ""
+		}
+		if err := s.repo.SaveResult(ctx, e); err != nil {
+			log.Printf("failed to save outbox event %s: %v", e.EventID, err)
+		}
+	}
+	return len(claimed), nil
+}
+
+// Run dispatches events until ctx is cancelled, polling every interval and
+// immediately after a transaction that published something commits.
+func (s *outboxSrv) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := s.DispatchPending(ctx)
+			if err != nil {
+				log.Printf("outbox dispatcher: %v", err)
+			}
+			if n < outboxBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// PurgePublished deletes events published longer ago than the retention
+// period. The worker runs it as a scheduled job.
+func (s *outboxSrv) PurgePublished(ctx context.Context) (int64, error) {
+	return s.repo.PurgePublished(ctx, time.Now().Add(-outboxRetention))
+}
+
+func (s *outboxSrv) dispatch(ctx context.Context, e *models.OutboxEvent) error {
+	event := events.Event{
+		ID:         e.EventID,
+		Type:       e.EventType,
+		OccurredAt: e.OccurredAt,
+		Data:       json.RawMessage(e.Payload),
+	}
+	if err := s.bus.Deliver(ctx, event); err != nil {
+		return err
+	}
+	if s.stream == nil {
+		return nil
+	}
+	return s.stream.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.streamName,
+		MaxLen: outboxStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"id":          event.ID,
+			"type":        event.Type,
+			"occurred_at": event.OccurredAt.Format(time.RFC3339Nano),
+			"data":        e.Payload,
+		},
+	}).Err()
+}
+
+func (s *outboxSrv) nudge() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDispatchPending(t *testing.T) {
+	tests := []struct {
+		name           string
+		handlerErr     error
+		attempts       int
+		expectedStatus string
+		expectedWait   time.Duration
+	}{
+		{name: "Published", expectedStatus: models.OutboxPublished},
+		{name: "SubscriberFailureRetriesIn5s", handlerErr: errors.New("boom"), expectedStatus: models.OutboxPending, expectedWait: 5 * time.Second},
+		{name: "BackoffDoubles", handlerErr: errors.New("boom"), attempts: 2, expectedStatus: models.OutboxPending, expectedWait: 20 * time.Second},
+		{name: "GivesUpAfterMaxAttempts", handlerErr: errors.New("boom"), attempts: 9, expectedStatus: models.OutboxFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			pending := models.OutboxEvent{
+				ID:         1,
+				EventID:    "evt_1",
+				EventType:  events.ReportApproved,
+				Payload:    `{"report_id":3}`,
+				OccurredAt: time.Now(),
+				Status:     models.OutboxPending,
+				Attempts:   tt.attempts,
+			}
+
+			bus := events.NewBus()
+			var received []events.Event
+			bus.Subscribe(events.ReportApproved, func(_ context.Context, e events.Event) error {
+				received = append(received, e)
+				return tt.handlerErr
+			})
+
+			repo := mocks.NewMockOutboxRepository(ctrl)
+			repo.EXPECT().ClaimPending(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return([]models.OutboxEvent{pending}, nil)
+			var saved *models.OutboxEvent
+			repo.EXPECT().SaveResult(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, e *models.OutboxEvent) error {
+					saved = e
+					return nil
+				})
+
+			svc := services.NewOutboxService(repo, bus, nil, "")
+			start := time.Now()
+			n, err := svc.DispatchPending(context.Background())
+			if err != nil || n != 1 {
+				t.Fatalf("expected 1 event dispatched, got %d (%v)", n, err)
+			}
+
+			if len(received) != 1 || received[0].ID != "evt_1" || string(received[0].Data) != pending.Payload {
+				t.Fatalf("unexpected deliveries %+v", received)
+			}
+			if saved.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, saved.Status)
+			}
+			if saved.Attempts != tt.attempts+1 {
+				t.Errorf("expected %d attempts, got %d", tt.attempts+1, saved.Attempts)
+			}
+			if tt.handlerErr != nil && saved.LastError == "" {
+				t.Error("expected last error to be recorded")
+			}
+			if tt.expectedStatus == models.OutboxPublished && saved.PublishedAt == nil {
+				t.Error("expected published_at to be set")
+			}
+			if tt.expectedWait > 0 {
+				wait := saved.AvailableAt.Sub(start)
+				if wait < tt.expectedWait || wait > tt.expectedWait+time.Second {
+					t.Errorf("expected retry in %s, got %s", tt.expectedWait, wait)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrRedisUnavailable = errors.New("redis unavailable")
+
+// RedisHealth is what the guard learns from the Redis health monitor.
+type RedisHealth struct {
+	// Healthy reports whether the last health check reached Redis.
+	Healthy func() bool
+	// Epoch, when set, namespaces every key, so bumping it retires the
+	// whole cache at once.
+	Epoch func() int64
+	// MarkDown, when set, is called when a delete or counter bump fails, so
+	// the invalidation it carried is covered by the next epoch.
+	MarkDown func()
+}
+
+type guardedRedis struct {
+	redis  RedisClient
+	health RedisHealth
+}
+
+// NewGuardedRedis wraps r so that while health reports Redis down every
+// command fails at once with ErrRedisUnavailable, instead of each request
+// waiting out a connection timeout.
+func NewGuardedRedis(r RedisClient, health RedisHealth) RedisClient {
+	return &guardedRedis{redis: r, health: health}
+}
+
+func (g *guardedRedis) key(key string) string {
+	if g.health.Epoch == nil {
+		return key
+	}
+	return fmt.Sprintf("e%d:%s", g.health.Epoch(), key)
+}
+
+// invalidationFailed reports a dropped delete or counter bump. Entries it
+// should have retired may now be served until the epoch moves on.
+func (g *guardedRedis) invalidationFailed(err error) {
+	if err != nil && err != redis.Nil && g.health.MarkDown != nil {
+		g.health.MarkDown()
+	}
+}
+
+func (g *guardedRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	if !g.health.Healthy() {
+		return redis.NewStringResult("", ErrRedisUnavailable)
+	}
+	return g.redis.Get(ctx, g.key(key))
+}
+
+func (g *guardedRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	if !g.health.Healthy() {
+		return redis.NewStatusResult("", ErrRedisUnavailable)
+	}
+	return g.redis.Set(ctx, g.key(key), value, expiration)
+}
+
+func (g *guardedRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	if !g.health.Healthy() {
+		return redis.NewIntResult(0, ErrRedisUnavailable)
+	}
+	namespaced := make([]string, len(keys))
+	for i, key := range keys {
+		namespaced[i] = g.key(key)
+	}
+	cmd := g.redis.Del(ctx, namespaced...)
+	g.invalidationFailed(cmd.Err())
+	return cmd
+}
+
+func (g *guardedRedis) Incr(ctx context.Context, key string) *redis.IntCmd {
+	if !g.health.Healthy() {
+		return redis.NewIntResult(0, ErrRedisUnavailable)
+	}
+	cmd := g.redis.Incr(ctx, g.key(key))
+	g.invalidationFailed(cmd.Err())
+	return cmd
+}
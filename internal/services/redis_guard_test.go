@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGuardedRedis(t *testing.T) {
+	tests := []struct {
+		name        string
+		healthy     bool
+		mockRedis   func(r *mocks.MockRedisClient)
+		expectedErr error
+	}{
+		{
+			name:    "HealthyPassesThrough",
+			healthy: true,
+			mockRedis: func(r *mocks.MockRedisClient) {
+				r.EXPECT().Get(gomock.Any(), "k").Return(redis.NewStringResult("v", nil))
+				r.EXPECT().Set(gomock.Any(), "k", "v", time.Minute).Return(redis.NewStatusResult("OK", nil))
+				r.EXPECT().Del(gomock.Any(), "k").Return(redis.NewIntResult(1, nil))
+				r.EXPECT().Incr(gomock.Any(), "n").Return(redis.NewIntResult(1, nil))
+			},
+		},
+		{
+			name:        "UnhealthyFailsFast",
+			healthy:     false,
+			mockRedis:   func(r *mocks.MockRedisClient) {},
+			expectedErr: services.ErrRedisUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.mockRedis(mockRedis)
+			r := services.NewGuardedRedis(mockRedis, services.RedisHealth{Healthy: func() bool { return tt.healthy }})
+
+			ctx := context.Background()
+			errs := []error{
+				r.Get(ctx, "k").Err(),
+				r.Set(ctx, "k", "v", time.Minute).Err(),
+				r.Del(ctx, "k").Err(),
+				r.Incr(ctx, "n").Err(),
+			}
+			for i, err := range errs {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("command %d: expected error %v, got %v", i, tt.expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardedRedisNamespacesKeysByEpoch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	epoch := int64(3)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	r := services.NewGuardedRedis(mockRedis, services.RedisHealth{
+		Healthy: func() bool { return true },
+		Epoch:   func() int64 { return epoch },
+	})
+	ctx := context.Background()
+
+	mockRedis.EXPECT().Set(gomock.Any(), "e3:k", "v", time.Minute).Return(redis.NewStatusResult("OK", nil))
+	r.Set(ctx, "k", "v", time.Minute)
+
+	// After a recovery the old entry is out of reach.
+	epoch = 4
+	mockRedis.EXPECT().Get(gomock.Any(), "e4:k").Return(redis.NewStringResult("", redis.Nil))
+	mockRedis.EXPECT().Del(gomock.Any(), "e4:a", "e4:b").Return(redis.NewIntResult(0, nil))
+	mockRedis.EXPECT().Incr(gomock.Any(), "e4:n").Return(redis.NewIntResult(1, nil))
+	if err := r.Get(ctx, "k").Err(); err != redis.Nil {
+		t.Errorf("expected a miss, got %v", err)
+	}
+	r.Del(ctx, "a", "b")
+	r.Incr(ctx, "n")
+}
+
+func TestGuardedRedisMarksDownOnFailedInvalidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	markedDown := 0
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	r := services.NewGuardedRedis(mockRedis, services.RedisHealth{
+		Healthy:  func() bool { return true },
+		MarkDown: func() { markedDown++ },
+	})
+	ctx := context.Background()
+
+	mockRedis.EXPECT().Get(gomock.Any(), "k").Return(redis.NewStringResult("", errors.New("i/o timeout")))
+	mockRedis.EXPECT().Del(gomock.Any(), "k").Return(redis.NewIntResult(0, errors.New("i/o timeout")))
+	mockRedis.EXPECT().Incr(gomock.Any(), "n").Return(redis.NewIntResult(0, errors.New("connection refused")))
+	r.Get(ctx, "k")
+	r.Del(ctx, "k")
+	r.Incr(ctx, "n")
+
+	if markedDown != 2 {
+		t.Errorf("expected the failed delete and bump to mark Redis down, got %d", markedDown)
+	}
+}
@@ -3,22 +3,39 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
 	"github.com/redis/go-redis/v9"
 )
 
 var (
 	ErrInvalidReportState = errors.New("report cannot be submitted in current state")
 	ErrInvalidOwnership   = errors.New("user does not have ownership")
+	ErrReportNotInReview  = errors.New("report is not awaiting review")
+	ErrSelfReview         = errors.New("users cannot review their own reports")
+	ErrReasonRequired     = errors.New("a reason is required to reject a report")
+	ErrReportLocked       = errors.New("only draft or rejected reports can be changed")
 )
 
 type ReportService interface {
 	CreateReport(ctx context.Context, report *models.ExpenseReport) error
+	GetReportByID(ctx context.Context, reportID uint) (*models.ExpenseReport, error)
 	AddExpenseToReport(ctx context.Context, reportID uint, expense *models.Expense) error
-	SubmitReport(ctx context.Context, reportID uint) error
+	SubmitReport(ctx context.Context, reportID, version uint) error
+	ApproveReport(ctx context.Context, reportID, approverID, version uint, reason string) error
+	RejectReport(ctx context.Context, reportID, approverID, version uint, reason string) error
 	GetReportExpenses(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error)
+	StreamReportExpenses(ctx context.Context, reportID uint, fn func(*models.Expense) error) error
+	DeleteReport(ctx context.Context, reportID, version uint) error
+	RestoreReport(ctx context.Context, reportID uint) (*models.ExpenseReport, error)
 }
 
 type reportService struct {
@@ -26,41 +43,310 @@ type reportService struct {
 	expenseRepo repository.ExpenseRepository
 	userRepo    repository.UserRepository
 	redis       *redis.Client
+	audit       AuditLogger
+	publisher   events.Publisher
+	tx          repository.Transactor
+	currency    CurrencyConverter
+	// reportingCurrency is the organization default for users who have not
+	// picked one.
+	reportingCurrency string
 }
 
-func NewReportService(r repository.ReportRepository, e repository.ExpenseRepository, u repository.UserRepository, redis *redis.Client) *reportService {
+// reportStatusEvents maps a target status to the event announcing it.
+var reportStatusEvents = map[string]string{
+	models.ReportStatusSubmitted: events.ReportSubmitted,
+	models.ReportStatusApproved:  events.ReportApproved,
+	models.ReportStatusRejected:  events.ReportRejected,
+}
+
+func NewReportService(r repository.ReportRepository, e repository.ExpenseRepository, u repository.UserRepository, redis *redis.Client, audit AuditLogger, publisher events.Publisher, tx repository.Transactor, currency CurrencyConverter, reportingCurrency string) *reportService {
 	return &reportService{
-		reportRepo:  r,
-		expenseRepo: e,
-		userRepo:    u,
-		redis:       redis,
+		reportRepo:        r,
+		expenseRepo:       e,
+		userRepo:          u,
+		redis:             redis,
+		audit:             audit,
+		publisher:         publisher,
+		tx:                tx,
+		currency:          currency,
+		reportingCurrency: strings.ToUpper(reportingCurrency),
 	}
 }
 
 func (s *reportService) CreateReport(ctx context.Context, report *models.ExpenseReport) error {
-	_, err := s.userRepo.GetUserByID(ctx, report.UserID)
+	user, err := s.userRepo.GetUserByID(ctx, report.UserID)
 	if err != nil {
 		return err
 	}
-	return s.reportRepo.CreateReport(ctx, report)
+	if !user.Active {
+		return ErrUserInactive
+	}
+	report.Currency = s.resolveCurrency(report.Currency, user)
+	if _, err := s.reportingRate(ctx, report.Currency); err != nil {
+		return err
+	}
+	report.History = []models.ReportStatusChange{{
+		ToStatus: models.ReportStatusDraft,
+		ActorID:  actorOrOwner(ctx, report.UserID),
+	}}
+	return withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.reportRepo.CreateReport(ctx, report); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "create", report.ID, report.UserID, nil, report)
+	})
 }
 
-func (s *reportService) AddExpenseToReport(ctx context.Context, reportID uint, expense *models.Expense) error {
+func (s *reportService) GetReportByID(ctx context.Context, reportID uint) (*models.ExpenseReport, error) {
+	report, err := s.reportRepo.GetExpenseReportByID(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	s.applyLiveRate(ctx, report)
+	return report, nil
+}
 
-	return s.reportRepo.AddExpenseToReportWithTotal(ctx, reportID, expense)
+func (s *reportService) AddExpenseToReport(ctx context.Context, reportID uint, expense *models.Expense) error {
+	return withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.reportRepo.AddExpenseToReportWithTotal(ctx, reportID, expense); err != nil {
+			if errors.Is(err, repository.ErrReportNotEditable) {
+				return ErrReportLocked
+			}
+			return err
+		}
+		return s.recordAudit(ctx, "add_expense", reportID, expense.UserID, nil,
+			map[string]interface{}{"expense_id": expense.ID, "amount_usd": expense.AmountUSD})
+	})
 }
 
-func (s *reportService) SubmitReport(ctx context.Context, reportID uint) error {
-	report, err := s.reportRepo.GetExpenseReportByID(ctx, reportID)
+// SubmitReport sends a draft, or a previously rejected report, for review.
+// Like every report change it only applies to the version the caller read.
+func (s *reportService) SubmitReport(ctx context.Context, reportID, version uint) error {
+	report, err := s.versionedReport(ctx, reportID, version)
 	if err != nil {
 		return err
 	}
-	if report.Status != "draft" {
+	if report.Status != models.ReportStatusDraft && report.Status != models.ReportStatusRejected {
 		return ErrInvalidReportState
 	}
-	return s.reportRepo.SubmitReport(ctx, reportID)
+	return s.transition(ctx, report, "submit", models.ReportStatusSubmitted, actorOrOwner(ctx, report.UserID), "")
+}
+
+func (s *reportService) ApproveReport(ctx context.Context, reportID, approverID, version uint, reason string) error {
+	return s.review(ctx, reportID, approverID, version, "approve", models.ReportStatusApproved, reason)
+}
+
+func (s *reportService) RejectReport(ctx context.Context, reportID, approverID, version uint, reason string) error {
+	if reason == "" {
+		return ErrReasonRequired
+	}
+	return s.review(ctx, reportID, approverID, version, "reject", models.ReportStatusRejected, reason)
+}
+
+func (s *reportService) review(ctx context.Context, reportID, approverID, version uint, action, to, reason string) error {
+	report, err := s.versionedReport(ctx, reportID, version)
+	if err != nil {
+		return err
+	}
+	if report.Status != models.ReportStatusSubmitted {
+		return ErrReportNotInReview
+	}
+	if report.UserID == approverID {
+		return ErrSelfReview
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, approverID); err != nil {
+		return err
+	}
+	return s.transition(ctx, report, action, to, &approverID, reason)
+}
+
+func (s *reportService) transition(ctx context.Context, report *models.ExpenseReport, action, to string, actorID *uint, reason string) error {
+	change := &models.ReportStatusChange{
+		ReportID:   report.ID,
+		FromStatus: report.Status,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+	// The reporting rate is frozen on submission, so the totals an approver
+	// signs off on never move afterwards.
+	var rate float64
+	if to == models.ReportStatusSubmitted {
+		var err error
+		if rate, err = s.reportingRate(ctx, report.Currency); err != nil {
+			return err
+		}
+	}
+	err := withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.reportRepo.TransitionStatus(ctx, change, report.Version); err != nil {
+			return err
+		}
+		if rate != 0 {
+			if err := s.reportRepo.FreezeReportingRate(ctx, report.ID, rate, time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+		err := s.recordAudit(ctx, action, report.ID, *actorID,
+			map[string]interface{}{"status": change.FromStatus},
+			map[string]interface{}{"status": to, "reason": reason})
+		if err != nil {
+			return err
+		}
+		eventType, ok := reportStatusEvents[to]
+		if !ok || s.publisher == nil {
+			return nil
+		}
+		return s.publisher.Publish(ctx, eventType, events.ReportPayload{
+			ReportID: report.ID,
+			UserID:   report.UserID,
+			Title:    report.Title,
+			Status:   to,
+			Total:    report.Total,
+			ActorID:  *actorID,
+			Reason:   reason,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrReportStatusConflict) {
+			return ErrInvalidReportState
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *reportService) GetReportExpenses(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error) {
-	return s.reportRepo.GetReportExpenses(ctx, userID, offset, limit)
+	reports, err := s.reportRepo.GetReportExpenses(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reports {
+		s.applyLiveRate(ctx, &reports[i])
+	}
+	return reports, nil
+}
+
+func (s *reportService) StreamReportExpenses(ctx context.Context, reportID uint, fn func(*models.Expense) error) error {
+	return s.reportRepo.StreamReportExpenses(ctx, reportID, fn)
+}
+
+// DeleteReport soft-deletes a report that has not been sent for review, or
+// was rejected. Submitted and approved reports are finance records and stay.
+func (s *reportService) DeleteReport(ctx context.Context, reportID, version uint) error {
+	report, err := s.versionedReport(ctx, reportID, version)
+	if err != nil {
+		return err
+	}
+	if report.Status != models.ReportStatusDraft && report.Status != models.ReportStatusRejected {
+		return ErrReportLocked
+	}
+	return withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.reportRepo.DeleteReport(ctx, reportID, version); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "delete", reportID, *actorOrOwner(ctx, report.UserID), report, nil)
+	})
+}
+
+// versionedReport loads the report and checks it is still at version, so a
+// change based on a stale read fails before any other validation. The
+// repository checks the version again when writing.
+func (s *reportService) versionedReport(ctx context.Context, reportID, version uint) (*models.ExpenseReport, error) {
+	report, err := s.reportRepo.GetExpenseReportByID(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Version != version {
+		return nil, repository.ErrVersionConflict
+	}
+	return report, nil
+}
+
+// RestoreReport brings back a soft-deleted report.
+func (s *reportService) RestoreReport(ctx context.Context, reportID uint) (*models.ExpenseReport, error) {
+	var report *models.ExpenseReport
+	err := withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.reportRepo.RestoreReport(ctx, reportID); err != nil {
+			return err
+		}
+		var err error
+		if report, err = s.GetReportByID(ctx, reportID); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "restore", reportID, *actorOrOwner(ctx, report.UserID), nil, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// recordAudit appends the change to the audit chain inside the write
+// transaction.
+func (s *reportService) recordAudit(ctx context.Context, action string, id, userID uint, before, after interface{}) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(ctx, AuditEntry{
+		Action:     action,
+		EntityType: AuditEntityReport,
+		EntityID:   id,
+		ActorID:    userID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// actorOrOwner prefers the actor identified on the request and falls back to
+// the owner of the record being changed.
+func actorOrOwner(ctx context.Context, ownerID uint) *uint {
+	if actor := utils.ActorIDFromContext(ctx); actor != 0 {
+		return &actor
+	}
+	return &ownerID
+}
+
+// resolveCurrency picks the report's reporting currency: the one requested,
+// else the owner's, else the organization default, else USD.
+func (s *reportService) resolveCurrency(requested string, owner *models.User) string {
+	for _, c := range []string{requested, owner.ReportingCurrency, s.reportingCurrency} {
+		if c != "" {
+			return strings.ToUpper(c)
+		}
+	}
+	return "USD"
+}
+
+// reportingRate returns the current rate from USD to currency.
+func (s *reportService) reportingRate(ctx context.Context, currency string) (float64, error) {
+	if currency == "" || strings.EqualFold(currency, "USD") {
+		return 1, nil
+	}
+	if s.currency == nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	_, rate, err := s.currency.Convert(ctx, 1, "USD", currency)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedCurrency) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: %v", ErrCurrencyConversionFailed, err)
+	}
+	return rate, nil
+}
+
+// applyLiveRate fills in the reporting total of a report whose rate is not
+// frozen yet. A failed lookup leaves the USD total as the only total.
+func (s *reportService) applyLiveRate(ctx context.Context, report *models.ExpenseReport) {
+	if report.RatesFrozen() {
+		return
+	}
+	rate, err := s.reportingRate(ctx, report.Currency)
+	if err != nil {
+		log.Printf("failed to get %s rate for report %d: %v", report.Currency, report.ID, err)
+		report.ExchangeRate, report.ReportingTotal = 0, 0
+		return
+	}
+	report.ExchangeRate = rate
+	report.ReportingTotal = math.Round(report.Total*rate*100) / 100
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
 	"github.com/onunkwor/flypro-assestment-v2/internal/services"
 	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
 	"go.uber.org/mock/gomock"
@@ -23,13 +25,22 @@ func TestCreateReport(t *testing.T) {
 			name:   "Success",
 			report: &models.ExpenseReport{UserID: 1, Title: "Trip"},
 			mockUser: func(repo *mocks.MockUserRepository) {
-				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{BaseModel: models.BaseModel{ID: 1}}, nil)
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{BaseModel: models.BaseModel{ID: 1}, Active: true}, nil)
 			},
 			mockReport: func(repo *mocks.MockReportRepository) {
 				repo.EXPECT().CreateReport(gomock.Any(), gomock.Any()).Return(nil)
 			},
 			expectedErr: nil,
 		},
+		{
+			name:   "UserDeactivated",
+			report: &models.ExpenseReport{UserID: 3, Title: "Trip"},
+			mockUser: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(3)).Return(&models.User{BaseModel: models.BaseModel{ID: 3}}, nil)
+			},
+			mockReport:  func(repo *mocks.MockReportRepository) {},
+			expectedErr: services.ErrUserInactive,
+		},
 		{
 			name:   "UserNotFound",
 			report: &models.ExpenseReport{UserID: 2, Title: "Trip"},
@@ -52,7 +63,7 @@ func TestCreateReport(t *testing.T) {
 			tt.mockUser(mockUserRepo)
 			tt.mockReport(mockReportRepo)
 
-			service := services.NewReportService(mockReportRepo, nil, mockUserRepo, nil)
+			service := services.NewReportService(mockReportRepo, nil, mockUserRepo, nil, nil, nil, nil, nil, "")
 
 			err := service.CreateReport(context.Background(), tt.report)
 			if (tt.expectedErr != nil && (err == nil || err.Error() != tt.expectedErr.Error())) ||
@@ -89,6 +100,15 @@ func TestAddExpenseToReport(t *testing.T) {
 			},
 			expectedErr: errors.New("db error"),
 		},
+		{
+			name:     "SubmittedReport",
+			reportID: 1,
+			expense:  &models.Expense{BaseModel: models.BaseModel{ID: 3}, UserID: 1, AmountUSD: 20},
+			mockReport: func(repo *mocks.MockReportRepository) {
+				repo.EXPECT().AddExpenseToReportWithTotal(gomock.Any(), uint(1), gomock.Any()).Return(repository.ErrReportNotEditable)
+			},
+			expectedErr: services.ErrReportLocked,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -96,7 +116,7 @@ func TestAddExpenseToReport(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockReportRepo := mocks.NewMockReportRepository(ctrl)
-			service := services.NewReportService(mockReportRepo, nil, nil, nil)
+			service := services.NewReportService(mockReportRepo, nil, nil, nil, nil, nil, nil, nil, "")
 
 			tt.mockReport(mockReportRepo)
 
@@ -120,12 +140,58 @@ func TestSubmitReport(t *testing.T) {
 			name:     "Success",
 			reportID: 1,
 			mockReport: func(repo *mocks.MockReportRepository) {
-				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 1}, Status: "draft"}
+				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 1}, Version: 1, Status: "draft"}
 				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).Return(report, nil)
-				repo.EXPECT().SubmitReport(gomock.Any(), uint(1)).Return(nil)
+				repo.EXPECT().TransitionStatus(gomock.Any(), &models.ReportStatusChange{
+					ReportID:   1,
+					FromStatus: "draft",
+					ToStatus:   "submitted",
+					ActorID:    new(uint),
+				}, uint(1)).Return(nil)
+				repo.EXPECT().FreezeReportingRate(gomock.Any(), uint(1), 1.0, gomock.Any()).Return(nil)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:     "ResubmitAfterRejection",
+			reportID: 4,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 4}, UserID: 5, Version: 1, Status: "rejected"}
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(4)).Return(report, nil)
+				repo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), uint(1)).Return(nil)
+				repo.EXPECT().FreezeReportingRate(gomock.Any(), uint(4), 1.0, gomock.Any()).Return(nil)
 			},
 			expectedErr: nil,
 		},
+		{
+			name:     "ConcurrentTransition",
+			reportID: 5,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 5}, Version: 1, Status: "draft"}
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(5)).Return(report, nil)
+				repo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), uint(1)).Return(repository.ErrReportStatusConflict)
+			},
+			expectedErr: services.ErrInvalidReportState,
+		},
+		{
+			name:     "StaleVersion",
+			reportID: 6,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 6}, Version: 2, Status: "draft"}
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(6)).Return(report, nil)
+			},
+			expectedErr: repository.ErrVersionConflict,
+		},
+		{
+			name:     "ChangedBeforeWrite",
+			reportID: 7,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 7}, Version: 1, Status: "draft"}
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(7)).Return(report, nil)
+				repo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), uint(1)).Return(repository.ErrVersionConflict)
+			},
+			expectedErr: repository.ErrVersionConflict,
+		},
 		{
 			name:     "ReportNotFound",
 			reportID: 2,
@@ -138,7 +204,7 @@ func TestSubmitReport(t *testing.T) {
 			name:     "InvalidStatus",
 			reportID: 3,
 			mockReport: func(repo *mocks.MockReportRepository) {
-				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 3}, Status: "submitted"}
+				report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 3}, Version: 1, Status: "submitted"}
 				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(3)).Return(report, nil)
 			},
 			expectedErr: services.ErrInvalidReportState,
@@ -151,11 +217,11 @@ func TestSubmitReport(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockReportRepo := mocks.NewMockReportRepository(ctrl)
-			service := services.NewReportService(mockReportRepo, nil, nil, nil)
+			service := services.NewReportService(mockReportRepo, nil, nil, nil, nil, nil, nil, nil, "")
 
 			tt.mockReport(mockReportRepo)
 
-			err := service.SubmitReport(context.Background(), tt.reportID)
+			err := service.SubmitReport(context.Background(), tt.reportID, 1)
 			if (tt.expectedErr != nil && (err == nil || err.Error() != tt.expectedErr.Error())) ||
 				(tt.expectedErr == nil && err != nil) {
 				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
@@ -164,6 +230,112 @@ func TestSubmitReport(t *testing.T) {
 	}
 }
 
+func TestReviewReport(t *testing.T) {
+	submitted := func() *models.ExpenseReport {
+		return &models.ExpenseReport{BaseModel: models.BaseModel{ID: 1}, UserID: 1, Version: 1, Status: "submitted"}
+	}
+
+	tests := []struct {
+		name        string
+		approve     bool
+		approverID  uint
+		reason      string
+		mockReport  func(repo *mocks.MockReportRepository)
+		mockUser    func(repo *mocks.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name:       "Approve",
+			approve:    true,
+			approverID: 2,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).Return(submitted(), nil)
+				repo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), uint(1)).
+					DoAndReturn(func(_ context.Context, change *models.ReportStatusChange, _ uint) error {
+						if change.FromStatus != "submitted" || change.ToStatus != "approved" || *change.ActorID != 2 {
+							t.Errorf("unexpected change %+v", change)
+						}
+						return nil
+					})
+			},
+			mockUser: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(2)).Return(&models.User{BaseModel: models.BaseModel{ID: 2}}, nil)
+			},
+		},
+		{
+			name:       "RejectWithReason",
+			approverID: 2,
+			reason:     "missing receipts",
+			mockReport: func(repo *mocks.MockReportRepository) {
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).Return(submitted(), nil)
+				repo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), uint(1)).
+					DoAndReturn(func(_ context.Context, change *models.ReportStatusChange, _ uint) error {
+						if change.ToStatus != "rejected" || change.Reason != "missing receipts" {
+							t.Errorf("unexpected change %+v", change)
+						}
+						return nil
+					})
+			},
+			mockUser: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(2)).Return(&models.User{BaseModel: models.BaseModel{ID: 2}}, nil)
+			},
+		},
+		{
+			name:        "RejectWithoutReason",
+			approverID:  2,
+			mockReport:  func(repo *mocks.MockReportRepository) {},
+			mockUser:    func(repo *mocks.MockUserRepository) {},
+			expectedErr: services.ErrReasonRequired,
+		},
+		{
+			name:       "SelfApproval",
+			approve:    true,
+			approverID: 1,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).Return(submitted(), nil)
+			},
+			mockUser:    func(repo *mocks.MockUserRepository) {},
+			expectedErr: services.ErrSelfReview,
+		},
+		{
+			name:       "NotSubmitted",
+			approve:    true,
+			approverID: 2,
+			mockReport: func(repo *mocks.MockReportRepository) {
+				report := submitted()
+				report.Status = "draft"
+				repo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).Return(report, nil)
+			},
+			mockUser:    func(repo *mocks.MockUserRepository) {},
+			expectedErr: services.ErrReportNotInReview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockReportRepo := mocks.NewMockReportRepository(ctrl)
+			mockUserRepo := mocks.NewMockUserRepository(ctrl)
+			tt.mockReport(mockReportRepo)
+			tt.mockUser(mockUserRepo)
+
+			service := services.NewReportService(mockReportRepo, nil, mockUserRepo, nil, nil, nil, nil, nil, "")
+
+			var err error
+			if tt.approve {
+				err = service.ApproveReport(context.Background(), 1, tt.approverID, 1, tt.reason)
+			} else {
+				err = service.RejectReport(context.Background(), 1, tt.approverID, 1, tt.reason)
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
 func TestGetReportExpenses(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -207,7 +379,7 @@ func TestGetReportExpenses(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockReportRepo := mocks.NewMockReportRepository(ctrl)
-			service := services.NewReportService(mockReportRepo, nil, nil, nil)
+			service := services.NewReportService(mockReportRepo, nil, nil, nil, nil, nil, nil, nil, "")
 
 			tt.mockReport(mockReportRepo)
 
@@ -223,3 +395,138 @@ func TestGetReportExpenses(t *testing.T) {
 		})
 	}
 }
+
+func TestReportingCurrency(t *testing.T) {
+	t.Run("ResolvesCurrency", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			request  string
+			user     string
+			expected string
+		}{
+			{name: "Requested", request: "gbp", user: "EUR", expected: "GBP"},
+			{name: "UserPreference", user: "EUR", expected: "EUR"},
+			{name: "OrganizationDefault", expected: "NGN"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockReportRepo := mocks.NewMockReportRepository(ctrl)
+				mockUserRepo := mocks.NewMockUserRepository(ctrl)
+				mockCurr := mocks.NewMockCurrencyConverter(ctrl)
+				mockUserRepo.EXPECT().GetUserByID(gomock.Any(), uint(1)).
+					Return(&models.User{BaseModel: models.BaseModel{ID: 1}, ReportingCurrency: tt.user, Active: true}, nil)
+				mockCurr.EXPECT().Convert(gomock.Any(), 1.0, "USD", tt.expected).Return(2.0, 2.0, nil)
+				mockReportRepo.EXPECT().CreateReport(gomock.Any(), gomock.Any()).Return(nil)
+
+				service := services.NewReportService(mockReportRepo, nil, mockUserRepo, nil, nil, nil, nil, mockCurr, "ngn")
+				report := &models.ExpenseReport{UserID: 1, Title: "Trip", Currency: tt.request}
+				if err := service.CreateReport(context.Background(), report); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if report.Currency != tt.expected {
+					t.Errorf("expected currency %s, got %s", tt.expected, report.Currency)
+				}
+			})
+		}
+	})
+
+	t.Run("RejectsUnsupportedCurrency", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockCurr := mocks.NewMockCurrencyConverter(ctrl)
+		mockUserRepo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{Active: true}, nil)
+		mockCurr.EXPECT().Convert(gomock.Any(), 1.0, "USD", "XYZ").Return(0.0, 0.0, services.ErrUnsupportedCurrency)
+
+		service := services.NewReportService(nil, nil, mockUserRepo, nil, nil, nil, nil, mockCurr, "")
+		err := service.CreateReport(context.Background(), &models.ExpenseReport{UserID: 1, Currency: "XYZ"})
+		if !errors.Is(err, services.ErrUnsupportedCurrency) {
+			t.Fatalf("expected ErrUnsupportedCurrency, got %v", err)
+		}
+	})
+
+	t.Run("FreezesRateOnSubmit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReportRepo := mocks.NewMockReportRepository(ctrl)
+		mockCurr := mocks.NewMockCurrencyConverter(ctrl)
+		report := &models.ExpenseReport{BaseModel: models.BaseModel{ID: 1}, UserID: 1, Version: 1, Status: "draft", Total: 100, Currency: "EUR"}
+		mockReportRepo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).Return(report, nil)
+		mockCurr.EXPECT().Convert(gomock.Any(), 1.0, "USD", "EUR").Return(0.8, 0.8, nil)
+		mockReportRepo.EXPECT().TransitionStatus(gomock.Any(), gomock.Any(), uint(1)).Return(nil)
+		mockReportRepo.EXPECT().FreezeReportingRate(gomock.Any(), uint(1), 0.8, gomock.Any()).Return(nil)
+
+		service := services.NewReportService(mockReportRepo, nil, nil, nil, nil, nil, nil, mockCurr, "")
+		if err := service.SubmitReport(context.Background(), 1, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("LiveRateUntilFrozen", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		frozenAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+		mockReportRepo := mocks.NewMockReportRepository(ctrl)
+		mockCurr := mocks.NewMockCurrencyConverter(ctrl)
+		mockReportRepo.EXPECT().GetReportExpenses(gomock.Any(), uint(1), 0, 10).Return([]models.ExpenseReport{
+			{BaseModel: models.BaseModel{ID: 1}, Status: "draft", Total: 100, Currency: "EUR"},
+			{BaseModel: models.BaseModel{ID: 2}, Status: "approved", Total: 100, Currency: "EUR",
+				ExchangeRate: 0.9, ReportingTotal: 90, RatesFrozenAt: &frozenAt},
+		}, nil)
+		mockCurr.EXPECT().Convert(gomock.Any(), 1.0, "USD", "EUR").Return(0.8, 0.8, nil).Times(1)
+
+		service := services.NewReportService(mockReportRepo, nil, nil, nil, nil, nil, nil, mockCurr, "")
+		reports, err := service.GetReportExpenses(context.Background(), 1, 0, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reports[0].ReportingTotal != 80 || reports[0].ExchangeRate != 0.8 {
+			t.Errorf("expected live total 80 at 0.8, got %v at %v", reports[0].ReportingTotal, reports[0].ExchangeRate)
+		}
+		if reports[1].ReportingTotal != 90 || reports[1].ExchangeRate != 0.9 {
+			t.Errorf("expected frozen total 90 at 0.9, got %v at %v", reports[1].ReportingTotal, reports[1].ExchangeRate)
+		}
+	})
+}
+
+func TestDeleteReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		version     uint
+		expectedErr error
+	}{
+		{name: "Draft", status: models.ReportStatusDraft},
+		{name: "Rejected", status: models.ReportStatusRejected},
+		{name: "Submitted", status: models.ReportStatusSubmitted, expectedErr: services.ErrReportLocked},
+		{name: "Approved", status: models.ReportStatusApproved, expectedErr: services.ErrReportLocked},
+		{name: "StaleVersion", status: models.ReportStatusDraft, version: 2, expectedErr: repository.ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockReportRepo := mocks.NewMockReportRepository(ctrl)
+			mockReportRepo.EXPECT().GetExpenseReportByID(gomock.Any(), uint(1)).
+				Return(&models.ExpenseReport{BaseModel: models.BaseModel{ID: 1}, UserID: 7, Version: 1, Status: tt.status}, nil)
+			if tt.expectedErr == nil {
+				mockReportRepo.EXPECT().DeleteReport(gomock.Any(), uint(1), uint(1)).Return(nil)
+			}
+
+			if tt.version == 0 {
+				tt.version = 1
+			}
+			service := services.NewReportService(mockReportRepo, nil, nil, nil, nil, nil, nil, nil, "")
+			if err := service.DeleteReport(context.Background(), 1, tt.version); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
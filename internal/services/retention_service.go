@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+const defaultRetention = 90 * 24 * time.Hour
+
+type PurgeResult struct {
+	Expenses int64 `json:"expenses"`
+	Reports  int64 `json:"reports"`
+}
+
+// RetentionService permanently removes soft-deleted expenses and reports
+// once they have been deleted for longer than the retention period. The
+// worker runs it as a scheduled job.
+type RetentionService interface {
+	PurgeDeleted(ctx context.Context) (*PurgeResult, error)
+}
+
+type retentionSrv struct {
+	expenses  repository.ExpenseRepository
+	reports   repository.ReportRepository
+	retention time.Duration
+}
+
+func NewRetentionService(expenses repository.ExpenseRepository, reports repository.ReportRepository, retention time.Duration) RetentionService {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &retentionSrv{expenses: expenses, reports: reports, retention: retention}
+}
+
+func (s *retentionSrv) PurgeDeleted(ctx context.Context) (*PurgeResult, error) {
+	cutoff := time.Now().Add(-s.retention)
+	reports, err := s.reports.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenses.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return &PurgeResult{Expenses: expenses, Reports: reports}, nil
+}
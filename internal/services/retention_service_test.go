@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPurgeDeleted(t *testing.T) {
+	retention := 30 * 24 * time.Hour
+	cutoff := gomock.Cond(func(x any) bool {
+		before, ok := x.(time.Time)
+		want := time.Now().Add(-retention)
+		return ok && before.After(want.Add(-time.Minute)) && !before.After(want)
+	})
+	tests := []struct {
+		name        string
+		mockRepos   func(expenses *mocks.MockExpenseRepository, reports *mocks.MockReportRepository)
+		expected    services.PurgeResult
+		expectedErr error
+	}{
+		{
+			name: "PurgesBoth",
+			mockRepos: func(expenses *mocks.MockExpenseRepository, reports *mocks.MockReportRepository) {
+				reports.EXPECT().PurgeDeleted(gomock.Any(), cutoff).Return(int64(2), nil)
+				expenses.EXPECT().PurgeDeleted(gomock.Any(), cutoff).Return(int64(5), nil)
+			},
+			expected: services.PurgeResult{Expenses: 5, Reports: 2},
+		},
+		{
+			name: "StopsOnError",
+			mockRepos: func(expenses *mocks.MockExpenseRepository, reports *mocks.MockReportRepository) {
+				reports.EXPECT().PurgeDeleted(gomock.Any(), cutoff).Return(int64(0), errors.New("db down"))
+			},
+			expectedErr: errors.New("db down"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			expenses := mocks.NewMockExpenseRepository(ctrl)
+			reports := mocks.NewMockReportRepository(ctrl)
+			tt.mockRepos(expenses, reports)
+
+			result, err := services.NewRetentionService(expenses, reports, retention).PurgeDeleted(context.Background())
+			if (err != nil) != (tt.expectedErr != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && *result != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *result)
+			}
+		})
+	}
+}
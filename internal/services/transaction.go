@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+)
+
+// withinTransaction runs fn in a transaction when tx is set and directly
+// otherwise, which keeps services usable without a database in tests.
+func withinTransaction(ctx context.Context, tx repository.Transactor, fn func(ctx context.Context) error) error {
+	if tx == nil {
+		return fn(ctx)
+	}
+	return tx.WithinTransaction(ctx, fn)
+}
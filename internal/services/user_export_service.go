@@ -0,0 +1,129 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"path"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/export"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
+)
+
+const userExportPageSize = 100
+
+type UserExportService interface {
+	Export(ctx context.Context, userID uint, w io.Writer) error
+}
+
+type userExportSrv struct {
+	users    repository.UserRepository
+	expenses repository.ExpenseRepository
+	reports  repository.ReportRepository
+	receipts export.ReceiptOpener
+}
+
+func NewUserExportService(users repository.UserRepository, expenses repository.ExpenseRepository, reports repository.ReportRepository, receipts export.ReceiptOpener) UserExportService {
+	return &userExportSrv{users: users, expenses: expenses, reports: reports, receipts: receipts}
+}
+
+// Export writes a ZIP archive of everything held about the user to w:
+// profile.json, expenses.json, reports.json and the receipt files under
+// receipts/. Soft-deleted users, expenses and reports are still held, so they
+// are exported too. Nothing is written when the user does not exist, so
+// callers can still answer with an error.
+func (s *userExportSrv) Export(ctx context.Context, userID uint, w io.Writer) error {
+	user, err := s.users.GetUserByIDUnscoped(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	var expenses []models.Expense
+	err = s.expenses.StreamExpensesUnscoped(ctx, map[string]interface{}{"user_id": userID}, func(e *models.Expense) error {
+		expenses = append(expenses, *e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var reports []models.ExpenseReport
+	for offset := 0; ; offset += userExportPageSize {
+		page, err := s.reports.GetReportExpensesUnscoped(ctx, userID, offset, userExportPageSize)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, page...)
+		if len(page) < userExportPageSize {
+			break
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeZipJSON(zw, "profile.json", user); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "expenses.json", expenses); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "reports.json", reports); err != nil {
+		return err
+	}
+	if err := s.writeReceipts(zw, expenses); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeReceipts copies each distinct receipt into the archive. Receipts
+// missing from the store are skipped rather than failing the export.
+func (s *userExportSrv) writeReceipts(zw *zip.Writer, expenses []models.Expense) error {
+	if s.receipts == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, e := range expenses {
+		if e.Receipt == "" || seen[e.Receipt] {
+			continue
+		}
+		seen[e.Receipt] = true
+		if err := s.writeReceipt(zw, e.Receipt); err != nil {
+			if errors.Is(err, storage.ErrReceiptNotFound) {
+				log.Printf("user export: receipt %q of expense %d not found", e.Receipt, e.ID)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *userExportSrv) writeReceipt(zw *zip.Writer, name string) error {
+	r, err := s.receipts.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	f, err := zw.Create(path.Join("receipts", path.Base(name)))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/storage"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type memReceipts map[string]string
+
+func (m memReceipts) Open(name string) (io.ReadCloser, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, storage.ErrReceiptNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func TestUserExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().GetUserByIDUnscoped(gomock.Any(), uint(7)).
+		Return(&models.User{BaseModel: models.BaseModel{ID: 7}, Name: "Ada", Email: "ada@example.com"}, nil)
+	expenseRepo := mocks.NewMockExpenseRepository(ctrl)
+	expenseRepo.EXPECT().StreamExpensesUnscoped(gomock.Any(), map[string]interface{}{"user_id": uint(7)}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ map[string]interface{}, fn func(*models.Expense) error) error {
+			for _, e := range []models.Expense{
+				{BaseModel: models.BaseModel{ID: 1}, UserID: 7, Receipt: "a.pdf"},
+				{BaseModel: models.BaseModel{ID: 2}, UserID: 7, Receipt: "a.pdf"},
+				{BaseModel: models.BaseModel{ID: 3}, UserID: 7, Receipt: "missing.png"},
+			} {
+				if err := fn(&e); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	reportRepo := mocks.NewMockReportRepository(ctrl)
+	reportRepo.EXPECT().GetReportExpensesUnscoped(gomock.Any(), uint(7), 0, 100).
+		Return([]models.ExpenseReport{{BaseModel: models.BaseModel{ID: 4}, UserID: 7, Title: "Trip"}}, nil)
+
+	var buf bytes.Buffer
+	svc := services.NewUserExportService(userRepo, expenseRepo, reportRepo, memReceipts{"a.pdf": "%PDF"})
+	if err := svc.Export(context.Background(), 7, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, _ := f.Open()
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected profile, expenses, reports and one receipt, got %v", len(files))
+	}
+	var profile models.User
+	if err := json.Unmarshal([]byte(files["profile.json"]), &profile); err != nil || profile.Email != "ada@example.com" {
+		t.Errorf("unexpected profile %q (%v)", files["profile.json"], err)
+	}
+	var expenses []models.Expense
+	if err := json.Unmarshal([]byte(files["expenses.json"]), &expenses); err != nil || len(expenses) != 3 {
+		t.Errorf("expected 3 expenses, got %q (%v)", files["expenses.json"], err)
+	}
+	var reports []models.ExpenseReport
+	if err := json.Unmarshal([]byte(files["reports.json"]), &reports); err != nil || len(reports) != 1 {
+		t.Errorf("expected 1 report, got %q (%v)", files["reports.json"], err)
+	}
+	if files["receipts/a.pdf"] != "%PDF" {
+		t.Errorf("expected the receipt in the archive, got %q", files["receipts/a.pdf"])
+	}
+}
+
+func TestUserExportUnknownUserWritesNothing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().GetUserByIDUnscoped(gomock.Any(), uint(7)).Return(nil, repository.ErrUserNotFound)
+
+	var buf bytes.Buffer
+	svc := services.NewUserExportService(userRepo, nil, nil, nil)
+	if err := svc.Export(context.Background(), 7, &buf); !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %d bytes", buf.Len())
+	}
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -10,24 +9,51 @@ import (
 
 	"github.com/onunkwor/flypro-assestment-v2/internal/models"
 	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
-	"github.com/redis/go-redis/v9"
 )
 
 var ErrEmailAlreadyExists = errors.New("service: email already exists")
 var ErrUserNotFound = errors.New("service: user not found")
+var ErrUserInactive = errors.New("service: user is deactivated")
+
+// ErrCachePurgeFailed means an erasure was saved but cached copies of the
+// user could not be purged. Erasing again is safe and retries the purge.
+var ErrCachePurgeFailed = errors.New("service: cached user data could not be purged")
 
 type UserService interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	UpdateUser(ctx context.Context, id uint, update UserUpdate) (*models.User, error)
+	SetActive(ctx context.Context, id uint, active bool) (*models.User, error)
+	DeleteUser(ctx context.Context, id uint) error
+	EraseUser(ctx context.Context, id uint) error
+	ListUsers(ctx context.Context, search string, active *bool, offset, limit int) ([]models.User, error)
+}
+
+// UserUpdate holds the fields to change; nil fields are left as they are.
+type UserUpdate struct {
+	Name              *string
+	Email             *string
+	ReportingCurrency *string
 }
 
 type userSrv struct {
-	repo  repository.UserRepository
-	redis RedisClient
+	repo          repository.UserRepository
+	notifications repository.NotificationRepository
+	comments      repository.CommentRepository
+	redis         RedisClient
+	cache         *Cache[*models.User]
+	audit         AuditLogger
+	tx            repository.Transactor
 }
 
-func NewUserService(redis RedisClient, repo repository.UserRepository) UserService {
-	return &userSrv{repo: repo, redis: redis}
+func NewUserService(redis RedisClient, repo repository.UserRepository, notifications repository.NotificationRepository, comments repository.CommentRepository, audit AuditLogger, tx repository.Transactor) UserService {
+	cache := NewCache[*models.User]("users", redis, CacheOptions{
+		TTL:         time.Hour,
+		Jitter:      0.1,
+		NotFound:    ErrUserNotFound,
+		NegativeTTL: time.Minute,
+	})
+	return &userSrv{repo: repo, notifications: notifications, comments: comments, redis: redis, cache: cache, audit: audit, tx: tx}
 }
 
 func (s *userSrv) CreateUser(ctx context.Context, user *models.User) error {
@@ -38,39 +64,196 @@ func (s *userSrv) CreateUser(ctx context.Context, user *models.User) error {
 	if existing != nil {
 		return ErrEmailAlreadyExists
 	}
-	return s.repo.CreateUser(ctx, user)
+	err = withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "create", user.ID, nil, user)
+	})
+	if err != nil {
+		return err
+	}
+	// Drop a cached "not found" for the new ID.
+	s.cache.Delete(ctx, userCacheKey(user.ID))
+	return nil
 }
 
 func (s *userSrv) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
-	key := fmt.Sprintf("user:%d", id)
-	if s.redis != nil {
-		val, err := s.redis.Get(ctx, key).Result()
-		if err == nil {
-			var user models.User
-			if unmarshalErr := json.Unmarshal([]byte(val), &user); unmarshalErr == nil {
-				return &user, nil
-			} else {
-				log.Printf("failed to unmarshal user from cache: %v (key=%s)", unmarshalErr, key)
-				_ = s.redis.Del(ctx, key).Err()
-			}
-		} else if err != redis.Nil {
+	return s.cache.GetOrLoad(ctx, userCacheKey(id), func(ctx context.Context) (*models.User, error) {
+		user, err := s.repo.GetUserByID(ctx, id)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return user, err
+	})
+}
+
+func (s *userSrv) UpdateUser(ctx context.Context, id uint, update UserUpdate) (*models.User, error) {
+	before, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if update.Name != nil {
+		fields["name"] = *update.Name
+	}
+	if update.Email != nil && *update.Email != before.Email {
+		existing, err := s.repo.FindByEmail(ctx, *update.Email)
+		if err != nil && err != repository.ErrUserNotFound {
 			return nil, err
 		}
+		if existing != nil {
+			return nil, ErrEmailAlreadyExists
+		}
+		fields["email"] = *update.Email
+	}
+	if update.ReportingCurrency != nil {
+		fields["reporting_currency"] = *update.ReportingCurrency
 	}
-	user, err := s.repo.GetUserByID(ctx, id)
+	if len(fields) == 0 {
+		return before, nil
+	}
+	return s.write(ctx, "update", id, before, fields)
+}
+
+// SetActive deactivates or reactivates a user. Deactivated users keep their
+// data and still show up in lists.
+func (s *userSrv) SetActive(ctx context.Context, id uint, active bool) (*models.User, error) {
+	before, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.Active == active {
+		return before, nil
+	}
+	action := "deactivate"
+	if active {
+		action = "reactivate"
+	}
+	return s.write(ctx, action, id, before, map[string]interface{}{"active": active})
+}
+
+// DeleteUser soft-deletes a user. Their expenses and reports are kept.
+func (s *userSrv) DeleteUser(ctx context.Context, id uint) error {
+	before, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.DeleteUser(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, "delete", id, before, nil)
+	})
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
-			return nil, ErrUserNotFound
+			return ErrUserNotFound
 		}
-		return nil, err
+		return err
 	}
-	bytes, err := json.Marshal(user)
+	s.invalidate(ctx, id)
+	return nil
+}
+
+// EraseUser anonymizes the user's personal data on request. Expenses and
+// reports are kept for accounting, but no longer point at anyone
+// identifiable. In the same transaction their notifications are deleted,
+// their name is scrubbed from notifications about their actions and their
+// comments are blanked. The cached profile and expense lists that embed it
+// are purged. When Redis cannot be reached the erasure fails with
+// ErrCachePurgeFailed rather than leave a cached copy behind; erasing a user
+// twice is harmless, so callers retry.
+func (s *userSrv) EraseUser(ctx context.Context, id uint) error {
+	err := withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		user, err := s.repo.GetUserByIDUnscoped(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.notifications.EraseUser(ctx, id, user.Name); err != nil {
+			return err
+		}
+		if err := s.comments.EraseUser(ctx, id, user.Email); err != nil {
+			return err
+		}
+		if err := s.repo.AnonymizeUser(ctx, id, time.Now().UTC()); err != nil {
+			return err
+		}
+		// The event records that an erasure happened, not what was erased.
+		return s.recordAudit(ctx, "erase", id, nil, nil)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if err := s.purge(ctx, id); err != nil {
+		return fmt.Errorf("%w: %v", ErrCachePurgeFailed, err)
+	}
+	return nil
+}
+
+func (s *userSrv) ListUsers(ctx context.Context, search string, active *bool, offset, limit int) ([]models.User, error) {
+	return s.repo.ListUsers(ctx, search, active, offset, limit)
+}
+
+func (s *userSrv) write(ctx context.Context, action string, id uint, before *models.User, fields map[string]interface{}) (*models.User, error) {
+	var after *models.User
+	err := withinTransaction(ctx, s.tx, func(ctx context.Context) error {
+		if err := s.repo.UpdateUser(ctx, id, fields); err != nil {
+			return err
+		}
+		var err error
+		if after, err = s.repo.GetUserByID(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, action, id, before, after)
+	})
 	if err != nil {
-		log.Printf("failed to marshal user for cache: %v (key=%s)", err, key)
-	} else if s.redis != nil {
-		if err := s.redis.Set(ctx, key, bytes, time.Hour).Err(); err != nil {
-			log.Printf("failed to set user in cache: %v (key=%s)", err, key)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
 		}
+		return nil, err
 	}
-	return user, nil
+	s.invalidate(ctx, id)
+	return after, nil
+}
+
+// invalidate drops the cached user and the expense lists that embed them.
+// Entries it misses while Redis is down expire with their TTL.
+func (s *userSrv) invalidate(ctx context.Context, id uint) {
+	if err := s.purge(ctx, id); err != nil && !errors.Is(err, ErrRedisUnavailable) {
+		log.Printf("failed to invalidate cache for user %d: %v", id, err)
+	}
+}
+
+// purge is invalidate for callers that must know it reached Redis.
+func (s *userSrv) purge(ctx context.Context, id uint) error {
+	if err := s.cache.Purge(ctx, userCacheKey(id)); err != nil {
+		return err
+	}
+	if s.redis == nil {
+		return nil
+	}
+	return bumpExpensesCacheVersion(ctx, s.redis, id)
+}
+
+// recordAudit appends the change to the audit chain inside the write
+// transaction.
+func (s *userSrv) recordAudit(ctx context.Context, action string, id uint, before, after interface{}) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(ctx, AuditEntry{
+		Action:     action,
+		EntityType: AuditEntityUser,
+		EntityID:   id,
+		ActorID:    id,
+		Before:     before,
+		After:      after,
+	})
+}
+
+func userCacheKey(id uint) string {
+	return fmt.Sprintf("user:%d", id)
 }
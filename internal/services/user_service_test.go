@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,7 +65,7 @@ func TestCreateUser(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mocks.NewMockUserRepository(ctrl)
-			svc := services.NewUserService(nil, mockRepo)
+			svc := services.NewUserService(nil, mockRepo, nil, nil, nil, nil)
 
 			tt.mockSetUp(mockRepo)
 
@@ -117,7 +118,7 @@ func TestGetUserByID(t *testing.T) {
 					Get(gomock.Any(), "user:1").
 					Return(redis.NewStringResult("", redis.Nil))
 				mockRedis.EXPECT().
-					Set(gomock.Any(), "user:1", gomock.Any(), time.Hour).
+					Set(gomock.Any(), "user:1", gomock.Any(), ttlWithin(time.Hour, 0.1)).
 					Return(redis.NewStatusResult("", nil))
 				return mockRedis
 			},
@@ -137,6 +138,9 @@ func TestGetUserByID(t *testing.T) {
 				mockRedis.EXPECT().
 					Get(gomock.Any(), "user:1").
 					Return(redis.NewStringResult("", redis.Nil))
+				mockRedis.EXPECT().
+					Set(gomock.Any(), "user:1", gomock.Any(), ttlWithin(time.Minute, 0.1)).
+					Return(redis.NewStatusResult("", nil))
 				return mockRedis
 			},
 			expectedUser: nil,
@@ -155,11 +159,50 @@ func TestGetUserByID(t *testing.T) {
 				mockRedis.EXPECT().
 					Get(gomock.Any(), "user:1").
 					Return(redis.NewStringResult("", redis.Nil))
+				mockRedis.EXPECT().
+					Set(gomock.Any(), "user:1", gomock.Any(), ttlWithin(time.Minute, 0.1)).
+					Return(redis.NewStatusResult("", nil))
 				return mockRedis
 			},
 			expectedUser: nil,
 			expectedErr:  services.ErrUserNotFound,
 		},
+		{
+			name:   "NotFoundCached",
+			userID: 1,
+			mockSetUp: func(repo *mocks.MockUserRepository) {
+			},
+			mockRedisSetup: func(redisCtrl *gomock.Controller) *mocks.MockRedisClient {
+				mockRedis := mocks.NewMockRedisClient(redisCtrl)
+				mockRedis.EXPECT().
+					Get(gomock.Any(), "user:1").
+					Return(redis.NewStringResult("\x00notfound", nil))
+				return mockRedis
+			},
+			expectedUser: nil,
+			expectedErr:  services.ErrUserNotFound,
+		},
+		{
+			name:   "RedisDown_FallsBackToDB",
+			userID: 1,
+			mockSetUp: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().
+					GetUserByID(gomock.Any(), uint(1)).
+					Return(user, nil)
+			},
+			mockRedisSetup: func(redisCtrl *gomock.Controller) *mocks.MockRedisClient {
+				mockRedis := mocks.NewMockRedisClient(redisCtrl)
+				mockRedis.EXPECT().
+					Get(gomock.Any(), "user:1").
+					Return(redis.NewStringResult("", errors.New("connection refused")))
+				mockRedis.EXPECT().
+					Set(gomock.Any(), "user:1", gomock.Any(), gomock.Any()).
+					Return(redis.NewStatusResult("", errors.New("connection refused")))
+				return mockRedis
+			},
+			expectedUser: user,
+			expectedErr:  nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -168,7 +211,7 @@ func TestGetUserByID(t *testing.T) {
 
 			mockRepo := mocks.NewMockUserRepository(ctrl)
 			mockRedis := tt.mockRedisSetup(ctrl)
-			svc := services.NewUserService(mockRedis, mockRepo)
+			svc := services.NewUserService(mockRedis, mockRepo, nil, nil, nil, nil)
 
 			tt.mockSetUp(mockRepo)
 
@@ -187,3 +230,230 @@ func TestGetUserByID(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateUser(t *testing.T) {
+	name := "Renamed"
+	taken := "taken@example.com"
+	current := &models.User{BaseModel: models.BaseModel{ID: 1}, Email: "test@example.com", Name: "Test User", Active: true}
+	tests := []struct {
+		name        string
+		update      services.UserUpdate
+		mockSetUp   func(repo *mocks.MockUserRepository)
+		expectWrite bool
+		expectedErr error
+	}{
+		{
+			name:   "Success",
+			update: services.UserUpdate{Name: &name},
+			mockSetUp: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(current, nil)
+				repo.EXPECT().UpdateUser(gomock.Any(), uint(1), map[string]interface{}{"name": "Renamed"}).Return(nil)
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{BaseModel: current.BaseModel, Email: current.Email, Name: name}, nil)
+			},
+			expectWrite: true,
+		},
+		{
+			name:   "EmailTaken",
+			update: services.UserUpdate{Email: &taken},
+			mockSetUp: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(current, nil)
+				repo.EXPECT().FindByEmail(gomock.Any(), taken).Return(&models.User{Email: taken}, nil)
+			},
+			expectedErr: services.ErrEmailAlreadyExists,
+		},
+		{
+			name:   "NotFound",
+			update: services.UserUpdate{Name: &name},
+			mockSetUp: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(nil, repository.ErrUserNotFound)
+			},
+			expectedErr: services.ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			mockRedis.EXPECT().Get(gomock.Any(), "user:1").Return(redis.NewStringResult("", redis.Nil))
+			mockRedis.EXPECT().Set(gomock.Any(), "user:1", gomock.Any(), gomock.Any()).Return(redis.NewStatusResult("OK", nil)).AnyTimes()
+			if tt.expectWrite {
+				mockRedis.EXPECT().Del(gomock.Any(), "user:1").Return(redis.NewIntResult(1, nil))
+				mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:all").Return(redis.NewIntResult(1, nil))
+				mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:user:1").Return(redis.NewIntResult(1, nil))
+			}
+			tt.mockSetUp(mockRepo)
+
+			svc := services.NewUserService(mockRedis, mockRepo, nil, nil, nil, nil)
+			user, err := svc.UpdateUser(context.Background(), 1, tt.update)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && user.Name != name {
+				t.Errorf("expected name %q, got %q", name, user.Name)
+			}
+		})
+	}
+}
+
+func TestSetActive(t *testing.T) {
+	tests := []struct {
+		name        string
+		active      bool
+		current     bool
+		expectWrite bool
+	}{
+		{name: "Deactivate", active: false, current: true, expectWrite: true},
+		{name: "Reactivate", active: true, current: false, expectWrite: true},
+		{name: "AlreadyActive", active: true, current: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			user := &models.User{BaseModel: models.BaseModel{ID: 1}, Active: tt.current}
+			mockRepo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(user, nil)
+			if tt.expectWrite {
+				mockRepo.EXPECT().UpdateUser(gomock.Any(), uint(1), map[string]interface{}{"active": tt.active}).Return(nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), uint(1)).Return(&models.User{BaseModel: user.BaseModel, Active: tt.active}, nil)
+			}
+
+			svc := services.NewUserService(nil, mockRepo, nil, nil, nil, nil)
+			got, err := svc.SetActive(context.Background(), 1, tt.active)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Active != tt.active {
+				t.Errorf("expected active %v, got %v", tt.active, got.Active)
+			}
+		})
+	}
+}
+
+func TestDeleteUserInvalidatesCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockRepo.EXPECT().DeleteUser(gomock.Any(), uint(1)).Return(nil)
+	userJSON, _ := json.Marshal(&models.User{BaseModel: models.BaseModel{ID: 1}, Active: true})
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Get(gomock.Any(), "user:1").Return(redis.NewStringResult(string(userJSON), nil))
+	mockRedis.EXPECT().Del(gomock.Any(), "user:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:all").Return(redis.NewIntResult(1, nil))
+	mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:user:1").Return(redis.NewIntResult(1, nil))
+
+	svc := services.NewUserService(mockRedis, mockRepo, nil, nil, nil, nil)
+	if err := svc.DeleteUser(context.Background(), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoErr     error
+		scrubErr    error
+		redisErr    error
+		expectedErr error
+	}{
+		{name: "AnonymizesAndPurgesCache"},
+		{name: "NotFound", repoErr: repository.ErrUserNotFound, expectedErr: services.ErrUserNotFound},
+		{name: "ScrubFailureRollsBack", scrubErr: errDBDown, expectedErr: errDBDown},
+		{name: "RedisUnavailable", redisErr: services.ErrRedisUnavailable, expectedErr: services.ErrCachePurgeFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			user := &models.User{BaseModel: models.BaseModel{ID: 1}, Name: "Ada Obi", Email: "ada@example.com"}
+			notifications := []models.Notification{
+				{UserID: 1, Title: `Report "Lagos trip" rejected`, Body: "Hi Ada Obi,\n\nYour expense report was rejected by Bola."},
+				{UserID: 2, ActorID: &user.ID, Title: "Report submitted", Body: "Hi Bola,\n\nAda Obi submitted the expense report."},
+				{UserID: 2, Title: "Report submitted", Body: "Hi Bola,\n\nAda Obi submitted an older report."},
+			}
+			comments := []models.Comment{
+				{AuthorID: 1, Body: "Receipts are in my inbox"},
+				{AuthorID: 2, Body: "@ada@example.com please attach the receipt"},
+			}
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockRepo.EXPECT().GetUserByIDUnscoped(gomock.Any(), uint(1)).Return(user, tt.repoErr)
+			mockNotifications := mocks.NewMockNotificationRepository(ctrl)
+			mockComments := mocks.NewMockCommentRepository(ctrl)
+			if tt.repoErr == nil {
+				mockNotifications.EXPECT().EraseUser(gomock.Any(), uint(1), "Ada Obi").
+					DoAndReturn(func(_ context.Context, userID uint, name string) error {
+						if tt.scrubErr != nil {
+							return tt.scrubErr
+						}
+						kept := notifications[:0]
+						for _, n := range notifications {
+							if n.UserID == userID {
+								continue
+							}
+							if n.ActorID == nil || *n.ActorID == userID {
+								n.Title = strings.ReplaceAll(n.Title, name, repository.ErasedUserName)
+								n.Body = strings.ReplaceAll(n.Body, name, repository.ErasedUserName)
+							}
+							kept = append(kept, n)
+						}
+						notifications = kept
+						return nil
+					})
+			}
+			if tt.repoErr == nil && tt.scrubErr == nil {
+				mockComments.EXPECT().EraseUser(gomock.Any(), uint(1), "ada@example.com").
+					DoAndReturn(func(_ context.Context, userID uint, email string) error {
+						for i := range comments {
+							if comments[i].AuthorID == userID {
+								comments[i].Body = repository.ErasedCommentBody
+							}
+							comments[i].Body = strings.ReplaceAll(comments[i].Body, email, repository.ErasedEmail(userID))
+						}
+						return nil
+					})
+				mockRepo.EXPECT().AnonymizeUser(gomock.Any(), uint(1), gomock.Any()).Return(nil)
+			}
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			if tt.redisErr != nil {
+				mockRedis.EXPECT().Del(gomock.Any(), "user:1").Return(redis.NewIntResult(0, tt.redisErr))
+			} else if tt.expectedErr == nil {
+				mockRedis.EXPECT().Del(gomock.Any(), "user:1").Return(redis.NewIntResult(1, nil))
+				mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:all").Return(redis.NewIntResult(1, nil))
+				mockRedis.EXPECT().Incr(gomock.Any(), "expenses:version:user:1").Return(redis.NewIntResult(1, nil))
+			}
+
+			tx := &recordingTransactor{}
+			svc := services.NewUserService(mockRedis, mockRepo, mockNotifications, mockComments, nil, tx)
+			err := svc.EraseUser(context.Background(), 1)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tx.committed != (tt.repoErr == nil && tt.scrubErr == nil) {
+				t.Errorf("unexpected commit %v", tx.committed)
+			}
+			if !tx.committed {
+				return
+			}
+			if len(notifications) != 2 {
+				t.Errorf("expected the user's own notification to be deleted, got %+v", notifications)
+			}
+			for _, n := range notifications {
+				if strings.Contains(n.Title+n.Body, "Ada") || strings.Contains(n.Title+n.Body, user.Email) {
+					t.Errorf("expected no personal data in notifications, got %+v", n)
+				}
+			}
+			for _, c := range comments {
+				if strings.Contains(c.Body, user.Email) || strings.Contains(c.Body, "inbox") {
+					t.Errorf("expected no personal data in comments, got %q", c.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/events"
+	"github.com/onunkwor/flypro-assestment-v2/internal/httpclient"
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+)
+
+const (
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	webhookLease       = 2 * time.Minute
+	webhookBatchSize   = 20
+	webhookTimeout     = 10 * time.Second
+)
+
+var (
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrInvalidWebhookEvent = errors.New("unknown webhook event type")
+	ErrPrivateWebhookURL   = fmt.Errorf("%w: it must resolve to a public address", ErrInvalidWebhookURL)
+
+	errSubscriptionInactive = errors.New("subscription is inactive")
+)
+
+// WebhookEvents are the event types a subscription can ask for.
+var WebhookEvents = []string{
+	events.ExpenseCreated,
+	events.ExpenseUpdated,
+	events.ExpenseDeleted,
+	events.ExpenseRestored,
+	events.ReportSubmitted,
+	events.ReportApproved,
+	events.ReportRejected,
+	events.ReportPolicyViolation,
+}
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, subscriptionID uint, offset, limit int) ([]models.WebhookDelivery, error)
+	Replay(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error)
+	HandleEvent(ctx context.Context, event events.Event) error
+	DeliverDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type webhookSrv struct {
+	repo   repository.WebhookRepository
+	client HTTPDoer
+}
+
+func NewWebhookService(repo repository.WebhookRepository, client HTTPDoer) WebhookService {
+	return &webhookSrv{repo: repo, client: client}
+}
+
+// CreateSubscription validates the subscription and generates a signing
+// secret when none was supplied.
+func (s *webhookSrv) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+		return err
+	}
+	for _, eventType := range sub.EventTypes {
+		if !isWebhookEvent(eventType) {
+			return fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, eventType)
+		}
+	}
+	if sub.Secret == "" {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return err
+		}
+		sub.Secret = "whsec_" + hex.EncodeToString(b[:])
+	}
+	sub.Active = true
+	return s.repo.CreateSubscription(ctx, sub)
+}
+
+func (s *webhookSrv) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+// DeleteSubscription deactivates the subscription rather than removing it,
+// so its delivery log is kept. Its pending deliveries are marked failed.
+func (s *webhookSrv) DeleteSubscription(ctx context.Context, id uint) error {
+	return s.repo.DeactivateSubscription(ctx, id)
+}
+
+// checkPublicHost rejects hosts that are, or resolve to, loopback, private
+// or link-local addresses. The webhook HTTP client checks again at dial
+// time, since DNS answers can change after the subscription is created.
+func checkPublicHost(ctx context.Context, host string) error {
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return fmt.Errorf("%w: %s does not resolve", ErrInvalidWebhookURL, host)
+		}
+	}
+	for _, addr := range addrs {
+		if !httpclient.IsPublicAddr(addr) {
+			return ErrPrivateWebhookURL
+		}
+	}
+	return nil
+}
+
+func (s *webhookSrv) ListDeliveries(ctx context.Context, subscriptionID uint, offset, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, offset, limit)
+}
+
+// Replay queues a fresh copy of an earlier delivery. The original entry is
+// left untouched so the delivery log stays complete.
+func (s *webhookSrv) Replay(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	replay := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		ReplayOf:       &original.ID,
+	}
+	deliveries := []models.WebhookDelivery{replay}
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// HandleEvent queues a delivery for every active subscription interested in
+// the event. It is meant to be subscribed to the event bus.
+func (s *webhookSrv) HandleEvent(ctx context.Context, event events.Event) error {
+	subs, err := s.repo.ListSubscriptionsForEvent(ctx, event.Type)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+	payload, err := eventJSON(event)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]models.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// DeliverDue sends every delivery whose next attempt is due and records the
+// outcome. It returns how many deliveries were attempted.
+func (s *webhookSrv) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range deliveries {
+		d := &deliveries[i]
+		s.attempt(ctx, d)
+		if err := s.repo.SaveDeliveryResult(ctx, d); err != nil {
+			log.Printf("failed to save webhook delivery %d: %v", d.ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// Run polls for due deliveries until ctx is cancelled.
+func (s *webhookSrv) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := s.DeliverDue(ctx)
+			if err != nil {
+				log.Printf("webhook worker: %v", err)
+			}
+			if n < webhookBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *webhookSrv) attempt(ctx context.Context, d *models.WebhookDelivery) {
+	d.Attempts++
+	status, err := s.send(ctx, d)
+	d.ResponseStatus = status
+	if err == nil {
+		now := time.Now()
+		d.Status = models.WebhookDeliverySucceeded
+		d.DeliveredAt = &now
+		d.LastError = ""
+		return
+	}
+
+	d.LastError = err.Error()
+	// A deactivated subscription will not come back, so retrying is pointless.
+	if errors.Is(err, errSubscriptionInactive) || d.Attempts >= webhookMaxAttempts {
+		d.Status = models.WebhookDeliveryFailed
+		return
+	}
+	d.Status = models.WebhookDeliveryPending
+	d.NextAttemptAt = time.Now().Add(exponentialBackoff(d.Attempts, webhookBaseBackoff, webhookMaxBackoff))
+}
+
+func (s *webhookSrv) send(ctx context.Context, d *models.WebhookDelivery) (int, error) {
+	if d.Subscription == nil || !d.Subscription.Active {
+		return 0, errSubscriptionInactive
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	body := []byte(d.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FlyPro-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(d.Subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook signs "<timestamp>.<body>" so a captured request cannot be
+// replayed with a different timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	return utils.SignHMAC(secret, append([]byte(timestamp+"."), body...))
+}
+
+func isWebhookEvent(eventType string) bool {
+	for _, e := range WebhookEvents {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func eventJSON(event events.Event) (string, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,151 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onunkwor/flypro-assestment-v2/internal/models"
+	"github.com/onunkwor/flypro-assestment-v2/internal/services"
+	"github.com/onunkwor/flypro-assestment-v2/internal/utils"
+	"github.com/onunkwor/flypro-assestment-v2/tests/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDeliverDue(t *testing.T) {
+	const secret = "whsec_test"
+	payload := `{"id":"evt_1","type":"report.approved","data":{"report_id":3}}`
+
+	tests := []struct {
+		name           string
+		responseStatus int
+		attempts       int
+		inactive       bool
+		expectedStatus string
+		expectedWait   time.Duration
+	}{
+		{name: "Delivered", responseStatus: http.StatusNoContent, expectedStatus: models.WebhookDeliverySucceeded},
+		{name: "FirstFailureRetriesIn30s", responseStatus: http.StatusBadGateway, expectedStatus: models.WebhookDeliveryPending, expectedWait: 30 * time.Second},
+		{name: "BackoffDoubles", responseStatus: http.StatusTooManyRequests, attempts: 3, expectedStatus: models.WebhookDeliveryPending, expectedWait: 4 * time.Minute},
+		{name: "GivesUpAfterMaxAttempts", responseStatus: http.StatusInternalServerError, attempts: 7, expectedStatus: models.WebhookDeliveryFailed},
+		{name: "InactiveSubscriptionFailsAtOnce", inactive: true, expectedStatus: models.WebhookDeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				signed := append([]byte(r.Header.Get("X-Webhook-Timestamp")+"."), body...)
+				if !utils.VerifyHMAC(secret, signed, r.Header.Get("X-Webhook-Signature")) {
+					t.Errorf("invalid signature %q", r.Header.Get("X-Webhook-Signature"))
+				}
+				if r.Header.Get("X-Webhook-Event") != "report.approved" || string(body) != payload {
+					t.Errorf("unexpected request %s %s", r.Header.Get("X-Webhook-Event"), body)
+				}
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer server.Close()
+
+			delivery := models.WebhookDelivery{
+				BaseModel:      models.BaseModel{ID: 1},
+				SubscriptionID: 2,
+				EventType:      "report.approved",
+				Payload:        payload,
+				Attempts:       tt.attempts,
+				Subscription:   &models.WebhookSubscription{URL: server.URL, Secret: secret, Active: !tt.inactive},
+			}
+
+			mockRepo := mocks.NewMockWebhookRepository(ctrl)
+			mockRepo.EXPECT().ClaimDueDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return([]models.WebhookDelivery{delivery}, nil)
+			var saved *models.WebhookDelivery
+			mockRepo.EXPECT().SaveDeliveryResult(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, d *models.WebhookDelivery) error {
+					saved = d
+					return nil
+				})
+
+			svc := services.NewWebhookService(mockRepo, server.Client())
+
+			start := time.Now()
+			n, err := svc.DeliverDue(context.Background())
+			if err != nil || n != 1 {
+				t.Fatalf("expected 1 delivery, got %d (%v)", n, err)
+			}
+			if saved.Status != tt.expectedStatus || saved.Attempts != tt.attempts+1 || saved.ResponseStatus != tt.responseStatus {
+				t.Fatalf("unexpected result %+v", saved)
+			}
+			if tt.expectedWait > 0 {
+				wait := saved.NextAttemptAt.Sub(start)
+				if wait < tt.expectedWait || wait > tt.expectedWait+time.Second {
+					t.Errorf("expected next attempt in %v, got %v", tt.expectedWait, wait)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionRejectsPrivateTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "Loopback", url: "http://127.0.0.1:8080/hook"},
+		{name: "Localhost", url: "http://localhost/hook"},
+		{name: "Private", url: "https://10.1.2.3/hook"},
+		{name: "Metadata", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "IPv6Loopback", url: "http://[::1]/hook"},
+		{name: "MappedIPv4", url: "http://[::ffff:192.168.0.1]/hook"},
+		{name: "Unspecified", url: "http://0.0.0.0/hook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := services.NewWebhookService(mocks.NewMockWebhookRepository(ctrl), http.DefaultClient)
+			err := service.CreateSubscription(context.Background(), &models.WebhookSubscription{URL: tt.url})
+			if !errors.Is(err, services.ErrInvalidWebhookURL) {
+				t.Fatalf("expected ErrInvalidWebhookURL, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionAcceptsPublicTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	repo.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+
+	service := services.NewWebhookService(repo, http.DefaultClient)
+	sub := &models.WebhookSubscription{URL: "https://93.184.215.14/hook", EventTypes: []string{"report.approved"}}
+	if err := service.CreateSubscription(context.Background(), sub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sub.Active || sub.Secret == "" {
+		t.Errorf("expected an active subscription with a secret, got %+v", sub)
+	}
+}
+
+func TestDeleteSubscriptionDeactivates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	repo.EXPECT().DeactivateSubscription(gomock.Any(), uint(4)).Return(nil)
+
+	service := services.NewWebhookService(repo, http.DefaultClient)
+	if err := service.DeleteSubscription(context.Background(), 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrReceiptNotFound    = errors.New("receipt not found")
+	ErrUnsupportedReceipt = errors.New("receipt must be a JPEG, PNG, GIF or PDF file")
+	receiptExtensions     = map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "application/pdf": ".pdf"}
+	contentAddressedName  = regexp.MustCompile(`^([0-9a-f]{64})\.(jpg|png|gif|pdf)$`)
+)
+
+// ReceiptStore keeps receipt files on the local filesystem. Expense.Receipt
+// holds the file name relative to the store directory. Uploaded files are
+// content addressed, so the name is the SHA-256 of the file plus extension
+// and identical receipts always end up with the same name.
+type ReceiptStore struct {
+	dir string
+}
+
+func NewReceiptStore(dir string) *ReceiptStore {
+	return &ReceiptStore{dir: dir}
+}
+
+func (s *ReceiptStore) Open(name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrReceiptNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// Save stores the receipt and returns its name and content hash.
+func (s *ReceiptStore) Save(r io.Reader) (string, string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", "", err
+	}
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var head [512]byte
+	n, err := io.ReadFull(r, head[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", err
+	}
+	ext, ok := receiptExtensions[http.DetectContentType(head[:n])]
+	if !ok {
+		return "", "", ErrUnsupportedReceipt
+	}
+
+	h := sha256.New()
+	w := io.MultiWriter(tmp, h)
+	if _, err := w.Write(head[:n]); err != nil {
+		return "", "", err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return "", "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	name := hash + ext
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return "", "", err
+	}
+	return name, hash, nil
+}
+
+// HashFromName returns the content hash encoded in a stored receipt name, or
+// an empty string when the receipt was not uploaded through the store.
+func HashFromName(name string) string {
+	m := contentAddressedName.FindStringSubmatch(strings.TrimSpace(name))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// path resolves name inside the store directory, rejecting anything that
+// would escape it.
+func (s *ReceiptStore) path(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || filepath.IsAbs(name) || strings.Contains(name, "..") {
+		return "", ErrReceiptNotFound
+	}
+	return filepath.Join(s.dir, filepath.Clean(name)), nil
+}
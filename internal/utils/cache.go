@@ -8,8 +8,19 @@ import (
 	"strings"
 )
 
-func ExpensesCacheKey(filters map[string]interface{}, offset, limit int) string {
+// ExpensesCacheVersionKey holds the generation counter for one user's
+// expense lists. userID 0 is the counter for lists not filtered by user.
+func ExpensesCacheVersionKey(userID uint) string {
+	if userID == 0 {
+		return "expenses:version:all"
+	}
+	return fmt.Sprintf("expenses:version:user:%d", userID)
+}
 
+// ExpensesCacheKey names a cached expense list. The key embeds the
+// generation of the list's scope, so bumping the version orphans every list
+// cached under the old one and they simply expire.
+func ExpensesCacheKey(filters map[string]interface{}, version int64, offset, limit int) string {
 	var parts []string
 	for k, v := range filters {
 		parts = append(parts, fmt.Sprintf("%s=%v", k, v))
@@ -19,5 +30,9 @@ func ExpensesCacheKey(filters map[string]interface{}, offset, limit int) string
 	rawKey := fmt.Sprintf("expenses:%s:offset=%d:limit=%d", strings.Join(parts, ":"), offset, limit)
 
 	h := sha1.Sum([]byte(rawKey))
-	return "expenses:" + hex.EncodeToString(h[:])
+	scope := "all"
+	if userID, ok := filters["user_id"].(uint); ok && userID != 0 {
+		scope = fmt.Sprintf("user:%d", userID)
+	}
+	return fmt.Sprintf("expenses:%s:v%d:%s", scope, version, hex.EncodeToString(h[:]))
 }
@@ -75,3 +75,24 @@ func ForbiddenResponse(c *gin.Context, message string) {
 		"message": message,
 	})
 }
+
+func PreconditionRequiredResponse(c *gin.Context, message string) {
+	c.JSON(http.StatusPreconditionRequired, gin.H{
+		"error":   "precondition_required",
+		"message": message,
+	})
+}
+
+func PreconditionFailedResponse(c *gin.Context, message string) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "precondition_failed",
+		"message": message,
+	})
+}
+
+func ServiceUnavailableResponse(c *gin.Context, message string) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":   "service_unavailable",
+		"message": message,
+	})
+}
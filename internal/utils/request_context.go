@@ -0,0 +1,30 @@
+package utils
+
+import "context"
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	actorIDKey   contextKey = "actor_id"
+)
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithActorID(ctx context.Context, actorID uint) context.Context {
+	return context.WithValue(ctx, actorIDKey, actorID)
+}
+
+// ActorIDFromContext returns the ID of the user performing the request, or
+// 0 when the caller did not identify itself.
+func ActorIDFromContext(ctx context.Context) uint {
+	id, _ := ctx.Value(actorIDKey).(uint)
+	return id
+}
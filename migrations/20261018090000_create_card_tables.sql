@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE corporate_cards (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    card_token VARCHAR(64) UNIQUE NOT NULL,
+    last4 VARCHAR(4),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE card_transactions (
+    id SERIAL PRIMARY KEY,
+    external_id VARCHAR(100) UNIQUE NOT NULL,
+    card_id INT NOT NULL REFERENCES corporate_cards(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(12,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    merchant VARCHAR(255),
+    category VARCHAR(50),
+    transacted_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) DEFAULT 'unmatched',
+    expense_id INT REFERENCES expenses(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_corporate_cards_user_id ON corporate_cards (user_id);
+CREATE INDEX IF NOT EXISTS idx_card_transactions_user_status ON card_transactions (user_id, status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_transactions_expense_id ON card_transactions (expense_id) WHERE expense_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE card_transactions;
+DROP TABLE corporate_cards;
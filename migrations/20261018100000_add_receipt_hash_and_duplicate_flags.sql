@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE expenses
+ADD COLUMN receipt_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_receipt_hash ON expenses (user_id, receipt_hash);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_amount ON expenses (user_id, currency, amount);
+
+CREATE TABLE expense_duplicate_flags (
+    id SERIAL PRIMARY KEY,
+    expense_id INT NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    duplicate_of_id INT NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    reasons JSONB NOT NULL DEFAULT '[]',
+    score NUMERIC(4,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (expense_id, duplicate_of_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_duplicate_flags_expense_id ON expense_duplicate_flags (expense_id);
+
+-- +goose Down
+DROP TABLE expense_duplicate_flags;
+DROP INDEX IF EXISTS idx_expenses_user_amount;
+DROP INDEX IF EXISTS idx_expenses_user_receipt_hash;
+ALTER TABLE expenses
+DROP COLUMN receipt_hash;
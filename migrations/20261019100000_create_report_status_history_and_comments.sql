@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE report_status_history (
+    id SERIAL PRIMARY KEY,
+    report_id INT NOT NULL REFERENCES expense_reports(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL DEFAULT '',
+    to_status VARCHAR(20) NOT NULL,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_status_history_report_id ON report_status_history (report_id);
+
+-- Seed history for reports created before transitions were recorded.
+INSERT INTO report_status_history (report_id, from_status, to_status, actor_id, created_at)
+SELECT id, '', 'draft', user_id, created_at FROM expense_reports;
+
+INSERT INTO report_status_history (report_id, from_status, to_status, actor_id, created_at)
+SELECT id, 'draft', status, user_id, updated_at FROM expense_reports WHERE status <> 'draft';
+
+CREATE TABLE comments (
+    id SERIAL PRIMARY KEY,
+    report_id INT REFERENCES expense_reports(id) ON DELETE CASCADE,
+    expense_id INT REFERENCES expenses(id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    mentions JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CHECK ((report_id IS NULL) <> (expense_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_report_id ON comments (report_id);
+CREATE INDEX IF NOT EXISTS idx_comments_expense_id ON comments (expense_id);
+
+-- +goose Down
+DROP TABLE comments;
+DROP TABLE report_status_history;
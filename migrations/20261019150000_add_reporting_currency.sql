@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN reporting_currency VARCHAR(3);
+
+ALTER TABLE expense_reports
+ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+ADD COLUMN exchange_rate FLOAT,
+ADD COLUMN reporting_total NUMERIC(12,2),
+ADD COLUMN rates_frozen_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE expense_reports
+DROP COLUMN rates_frozen_at,
+DROP COLUMN reporting_total,
+DROP COLUMN exchange_rate,
+DROP COLUMN currency;
+
+ALTER TABLE users
+DROP COLUMN reporting_currency;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Deleted users keep their row so their expenses and reports survive; only
+-- live users need a unique email. The full unique index from
+-- 20250829162739 would still reject a deleted user's email.
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX idx_users_email_live ON users (email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_users_email_live;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+ALTER TABLE users
+DROP COLUMN deleted_at,
+DROP COLUMN active;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE expenses ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE expense_reports ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_expenses_deleted_at ON expenses (deleted_at);
+CREATE INDEX idx_expense_reports_deleted_at ON expense_reports (deleted_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_expense_reports_deleted_at;
+DROP INDEX IF EXISTS idx_expenses_deleted_at;
+
+ALTER TABLE expense_reports DROP COLUMN deleted_at;
+ALTER TABLE expenses DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN erased_at;
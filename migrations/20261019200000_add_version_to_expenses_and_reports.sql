@@ -0,0 +1,9 @@
+-- +goose Up
+-- Bumped on every write, so clients can send the version they read in
+-- If-Match and have a concurrent change rejected instead of overwritten.
+ALTER TABLE expenses ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE expense_reports ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE expense_reports DROP COLUMN version;
+ALTER TABLE expenses DROP COLUMN version;
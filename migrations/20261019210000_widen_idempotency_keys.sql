@@ -0,0 +1,7 @@
+-- +goose Up
+-- Stored keys are prefixed with the actor ID.
+ALTER TABLE idempotency_keys ALTER COLUMN key TYPE VARCHAR(300);
+
+-- +goose Down
+DELETE FROM idempotency_keys WHERE length(key) > 255;
+ALTER TABLE idempotency_keys ALTER COLUMN key TYPE VARCHAR(255);
@@ -0,0 +1,13 @@
+-- +goose Up
+-- One row per event, user and channel already sent, so redelivered outbox
+-- events do not notify anyone twice. Rows go when the outbox event is purged.
+CREATE TABLE notification_deliveries (
+    event_id VARCHAR(64) NOT NULL REFERENCES outbox_events(event_id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id, channel)
+);
+
+-- +goose Down
+DROP TABLE notification_deliveries;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- The user whose action a notification describes, so erasing them can scrub
+-- their name from other users' notifications. Rows written before this
+-- column existed keep a NULL actor.
+ALTER TABLE notifications ADD COLUMN actor_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_actor ON notifications (actor_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notifications_actor;
+ALTER TABLE notifications DROP COLUMN actor_id;
@@ -0,0 +1,175 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/card_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/card_repository.go -destination=tests/mocks/mock_card_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/onunkwor/flypro-assestment-v2/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCardRepository is a mock of CardRepository interface.
+type MockCardRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCardRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCardRepositoryMockRecorder is the mock recorder for MockCardRepository.
+type MockCardRepositoryMockRecorder struct {
+	mock *MockCardRepository
+}
+
+// NewMockCardRepository creates a new mock instance.
+func NewMockCardRepository(ctrl *gomock.Controller) *MockCardRepository {
+	mock := &MockCardRepository{ctrl: ctrl}
+	mock.recorder = &MockCardRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCardRepository) EXPECT() *MockCardRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateCard mocks base method.
+func (m *MockCardRepository) CreateCard(ctx context.Context, card *models.CorporateCard) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCard", ctx, card)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCard indicates an expected call of CreateCard.
+func (mr *MockCardRepositoryMockRecorder) CreateCard(ctx, card any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCard", reflect.TypeOf((*MockCardRepository)(nil).CreateCard), ctx, card)
+}
+
+// CreateTransaction mocks base method.
+func (m *MockCardRepository) CreateTransaction(ctx context.Context, txn *models.CardTransaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransaction", ctx, txn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTransaction indicates an expected call of CreateTransaction.
+func (mr *MockCardRepositoryMockRecorder) CreateTransaction(ctx, txn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockCardRepository)(nil).CreateTransaction), ctx, txn)
+}
+
+// FindMatchCandidates mocks base method.
+func (m *MockCardRepository) FindMatchCandidates(ctx context.Context, userID uint, amount float64, currency string, from, to time.Time) ([]models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindMatchCandidates", ctx, userID, amount, currency, from, to)
+	ret0, _ := ret[0].([]models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindMatchCandidates indicates an expected call of FindMatchCandidates.
+func (mr *MockCardRepositoryMockRecorder) FindMatchCandidates(ctx, userID, amount, currency, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMatchCandidates", reflect.TypeOf((*MockCardRepository)(nil).FindMatchCandidates), ctx, userID, amount, currency, from, to)
+}
+
+// GetCardByToken mocks base method.
+func (m *MockCardRepository) GetCardByToken(ctx context.Context, token string) (*models.CorporateCard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCardByToken", ctx, token)
+	ret0, _ := ret[0].(*models.CorporateCard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCardByToken indicates an expected call of GetCardByToken.
+func (mr *MockCardRepositoryMockRecorder) GetCardByToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardByToken", reflect.TypeOf((*MockCardRepository)(nil).GetCardByToken), ctx, token)
+}
+
+// GetTransactionByExternalID mocks base method.
+func (m *MockCardRepository) GetTransactionByExternalID(ctx context.Context, externalID string) (*models.CardTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionByExternalID", ctx, externalID)
+	ret0, _ := ret[0].(*models.CardTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionByExternalID indicates an expected call of GetTransactionByExternalID.
+func (mr *MockCardRepositoryMockRecorder) GetTransactionByExternalID(ctx, externalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionByExternalID", reflect.TypeOf((*MockCardRepository)(nil).GetTransactionByExternalID), ctx, externalID)
+}
+
+// GetTransactionByID mocks base method.
+func (m *MockCardRepository) GetTransactionByID(ctx context.Context, id uint) (*models.CardTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionByID", ctx, id)
+	ret0, _ := ret[0].(*models.CardTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionByID indicates an expected call of GetTransactionByID.
+func (mr *MockCardRepositoryMockRecorder) GetTransactionByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionByID", reflect.TypeOf((*MockCardRepository)(nil).GetTransactionByID), ctx, id)
+}
+
+// LinkExpense mocks base method.
+func (m *MockCardRepository) LinkExpense(ctx context.Context, txnID, expenseID uint, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkExpense", ctx, txnID, expenseID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkExpense indicates an expected call of LinkExpense.
+func (mr *MockCardRepositoryMockRecorder) LinkExpense(ctx, txnID, expenseID, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkExpense", reflect.TypeOf((*MockCardRepository)(nil).LinkExpense), ctx, txnID, expenseID, status)
+}
+
+// ListExpensesMissingReceipts mocks base method.
+func (m *MockCardRepository) ListExpensesMissingReceipts(ctx context.Context, userID uint) ([]models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpensesMissingReceipts", ctx, userID)
+	ret0, _ := ret[0].([]models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpensesMissingReceipts indicates an expected call of ListExpensesMissingReceipts.
+func (mr *MockCardRepositoryMockRecorder) ListExpensesMissingReceipts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpensesMissingReceipts", reflect.TypeOf((*MockCardRepository)(nil).ListExpensesMissingReceipts), ctx, userID)
+}
+
+// ListTransactions mocks base method.
+func (m *MockCardRepository) ListTransactions(ctx context.Context, userID uint, status string, offset, limit int) ([]models.CardTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", ctx, userID, status, offset, limit)
+	ret0, _ := ret[0].([]models.CardTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockCardRepositoryMockRecorder) ListTransactions(ctx, userID, status, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockCardRepository)(nil).ListTransactions), ctx, userID, status, offset, limit)
+}
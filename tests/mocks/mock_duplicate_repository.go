@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/duplicate_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/duplicate_repository.go -destination=tests/mocks/mock_duplicate_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/onunkwor/flypro-assestment-v2/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDuplicateRepository is a mock of DuplicateRepository interface.
+type MockDuplicateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDuplicateRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDuplicateRepositoryMockRecorder is the mock recorder for MockDuplicateRepository.
+type MockDuplicateRepositoryMockRecorder struct {
+	mock *MockDuplicateRepository
+}
+
+// NewMockDuplicateRepository creates a new mock instance.
+func NewMockDuplicateRepository(ctrl *gomock.Controller) *MockDuplicateRepository {
+	mock := &MockDuplicateRepository{ctrl: ctrl}
+	mock.recorder = &MockDuplicateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDuplicateRepository) EXPECT() *MockDuplicateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindCandidates mocks base method.
+func (m *MockDuplicateRepository) FindCandidates(ctx context.Context, expense *models.Expense, from, to time.Time) ([]models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCandidates", ctx, expense, from, to)
+	ret0, _ := ret[0].([]models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCandidates indicates an expected call of FindCandidates.
+func (mr *MockDuplicateRepositoryMockRecorder) FindCandidates(ctx, expense, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCandidates", reflect.TypeOf((*MockDuplicateRepository)(nil).FindCandidates), ctx, expense, from, to)
+}
+
+// ListFlagsForReport mocks base method.
+func (m *MockDuplicateRepository) ListFlagsForReport(ctx context.Context, reportID uint) ([]models.DuplicateFlag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFlagsForReport", ctx, reportID)
+	ret0, _ := ret[0].([]models.DuplicateFlag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFlagsForReport indicates an expected call of ListFlagsForReport.
+func (mr *MockDuplicateRepositoryMockRecorder) ListFlagsForReport(ctx, reportID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFlagsForReport", reflect.TypeOf((*MockDuplicateRepository)(nil).ListFlagsForReport), ctx, reportID)
+}
+
+// SaveFlags mocks base method.
+func (m *MockDuplicateRepository) SaveFlags(ctx context.Context, flags []models.DuplicateFlag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFlags", ctx, flags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFlags indicates an expected call of SaveFlags.
+func (mr *MockDuplicateRepositoryMockRecorder) SaveFlags(ctx, flags any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFlags", reflect.TypeOf((*MockDuplicateRepository)(nil).SaveFlags), ctx, flags)
+}
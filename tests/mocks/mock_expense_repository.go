@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/onunkwor/flypro-assestment-v2/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -55,18 +56,32 @@ func (mr *MockExpenseRepositoryMockRecorder) Create(ctx, expense any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockExpenseRepository)(nil).Create), ctx, expense)
 }
 
+// CreateBatch mocks base method.
+func (m *MockExpenseRepository) CreateBatch(ctx context.Context, expenses []*models.Expense) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, expenses)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockExpenseRepositoryMockRecorder) CreateBatch(ctx, expenses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockExpenseRepository)(nil).CreateBatch), ctx, expenses)
+}
+
 // DeleteExpense mocks base method.
-func (m *MockExpenseRepository) DeleteExpense(ctx context.Context, id, userId uint) error {
+func (m *MockExpenseRepository) DeleteExpense(ctx context.Context, id, userId, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteExpense", ctx, id, userId)
+	ret := m.ctrl.Call(m, "DeleteExpense", ctx, id, userId, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteExpense indicates an expected call of DeleteExpense.
-func (mr *MockExpenseRepositoryMockRecorder) DeleteExpense(ctx, id, userId any) *gomock.Call {
+func (mr *MockExpenseRepositoryMockRecorder) DeleteExpense(ctx, id, userId, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpense", reflect.TypeOf((*MockExpenseRepository)(nil).DeleteExpense), ctx, id, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpense", reflect.TypeOf((*MockExpenseRepository)(nil).DeleteExpense), ctx, id, userId, version)
 }
 
 // GetExpenseByID mocks base method.
@@ -99,16 +114,88 @@ func (mr *MockExpenseRepositoryMockRecorder) GetExpenses(ctx, filters, offset, l
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenses", reflect.TypeOf((*MockExpenseRepository)(nil).GetExpenses), ctx, filters, offset, limit)
 }
 
+// PatchExpense mocks base method.
+func (m *MockExpenseRepository) PatchExpense(ctx context.Context, id, userId, version uint, fields map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchExpense", ctx, id, userId, version, fields)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchExpense indicates an expected call of PatchExpense.
+func (mr *MockExpenseRepositoryMockRecorder) PatchExpense(ctx, id, userId, version, fields any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchExpense", reflect.TypeOf((*MockExpenseRepository)(nil).PatchExpense), ctx, id, userId, version, fields)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockExpenseRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockExpenseRepositoryMockRecorder) PurgeDeleted(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockExpenseRepository)(nil).PurgeDeleted), ctx, before)
+}
+
+// RestoreExpense mocks base method.
+func (m *MockExpenseRepository) RestoreExpense(ctx context.Context, id uint) (*models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreExpense", ctx, id)
+	ret0, _ := ret[0].(*models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreExpense indicates an expected call of RestoreExpense.
+func (mr *MockExpenseRepositoryMockRecorder) RestoreExpense(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreExpense", reflect.TypeOf((*MockExpenseRepository)(nil).RestoreExpense), ctx, id)
+}
+
+// StreamExpenses mocks base method.
+func (m *MockExpenseRepository) StreamExpenses(ctx context.Context, filters map[string]any, fn func(*models.Expense) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamExpenses", ctx, filters, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamExpenses indicates an expected call of StreamExpenses.
+func (mr *MockExpenseRepositoryMockRecorder) StreamExpenses(ctx, filters, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamExpenses", reflect.TypeOf((*MockExpenseRepository)(nil).StreamExpenses), ctx, filters, fn)
+}
+
+// StreamExpensesUnscoped mocks base method.
+func (m *MockExpenseRepository) StreamExpensesUnscoped(ctx context.Context, filters map[string]any, fn func(*models.Expense) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamExpensesUnscoped", ctx, filters, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamExpensesUnscoped indicates an expected call of StreamExpensesUnscoped.
+func (mr *MockExpenseRepositoryMockRecorder) StreamExpensesUnscoped(ctx, filters, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamExpensesUnscoped", reflect.TypeOf((*MockExpenseRepository)(nil).StreamExpensesUnscoped), ctx, filters, fn)
+}
+
 // UpdateExpense mocks base method.
-func (m *MockExpenseRepository) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId uint) error {
+func (m *MockExpenseRepository) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateExpense", ctx, id, expense, userId)
+	ret := m.ctrl.Call(m, "UpdateExpense", ctx, id, expense, userId, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateExpense indicates an expected call of UpdateExpense.
-func (mr *MockExpenseRepositoryMockRecorder) UpdateExpense(ctx, id, expense, userId any) *gomock.Call {
+func (mr *MockExpenseRepositoryMockRecorder) UpdateExpense(ctx, id, expense, userId, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpense", reflect.TypeOf((*MockExpenseRepository)(nil).UpdateExpense), ctx, id, expense, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpense", reflect.TypeOf((*MockExpenseRepository)(nil).UpdateExpense), ctx, id, expense, userId, version)
 }
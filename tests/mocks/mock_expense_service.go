@@ -0,0 +1,174 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/expense_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/services/expense_service.go -destination=tests/mocks/mock_expense_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/onunkwor/flypro-assestment-v2/internal/models"
+	services "github.com/onunkwor/flypro-assestment-v2/internal/services"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockExpenseService is a mock of ExpenseService interface.
+type MockExpenseService struct {
+	ctrl     *gomock.Controller
+	recorder *MockExpenseServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockExpenseServiceMockRecorder is the mock recorder for MockExpenseService.
+type MockExpenseServiceMockRecorder struct {
+	mock *MockExpenseService
+}
+
+// NewMockExpenseService creates a new mock instance.
+func NewMockExpenseService(ctrl *gomock.Controller) *MockExpenseService {
+	mock := &MockExpenseService{ctrl: ctrl}
+	mock.recorder = &MockExpenseServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExpenseService) EXPECT() *MockExpenseServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateExpense mocks base method.
+func (m *MockExpenseService) CreateExpense(ctx context.Context, expense *models.Expense) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExpense", ctx, expense)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateExpense indicates an expected call of CreateExpense.
+func (mr *MockExpenseServiceMockRecorder) CreateExpense(ctx, expense any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExpense", reflect.TypeOf((*MockExpenseService)(nil).CreateExpense), ctx, expense)
+}
+
+// DeleteExpense mocks base method.
+func (m *MockExpenseService) DeleteExpense(ctx context.Context, id, userId, version uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpense", ctx, id, userId, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpense indicates an expected call of DeleteExpense.
+func (mr *MockExpenseServiceMockRecorder) DeleteExpense(ctx, id, userId, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpense", reflect.TypeOf((*MockExpenseService)(nil).DeleteExpense), ctx, id, userId, version)
+}
+
+// GetExpenseByID mocks base method.
+func (m *MockExpenseService) GetExpenseByID(ctx context.Context, id uint) (*models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpenseByID", ctx, id)
+	ret0, _ := ret[0].(*models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpenseByID indicates an expected call of GetExpenseByID.
+func (mr *MockExpenseServiceMockRecorder) GetExpenseByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenseByID", reflect.TypeOf((*MockExpenseService)(nil).GetExpenseByID), ctx, id)
+}
+
+// GetExpenses mocks base method.
+func (m *MockExpenseService) GetExpenses(ctx context.Context, filters map[string]any, offset, limit int) ([]models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpenses", ctx, filters, offset, limit)
+	ret0, _ := ret[0].([]models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpenses indicates an expected call of GetExpenses.
+func (mr *MockExpenseServiceMockRecorder) GetExpenses(ctx, filters, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenses", reflect.TypeOf((*MockExpenseService)(nil).GetExpenses), ctx, filters, offset, limit)
+}
+
+// ImportExpenses mocks base method.
+func (m *MockExpenseService) ImportExpenses(ctx context.Context, expenses []*models.Expense, dryRun bool) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportExpenses", ctx, expenses, dryRun)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportExpenses indicates an expected call of ImportExpenses.
+func (mr *MockExpenseServiceMockRecorder) ImportExpenses(ctx, expenses, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportExpenses", reflect.TypeOf((*MockExpenseService)(nil).ImportExpenses), ctx, expenses, dryRun)
+}
+
+// PatchExpense mocks base method.
+func (m *MockExpenseService) PatchExpense(ctx context.Context, id, userId, version uint, patch services.ExpensePatch) (*models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchExpense", ctx, id, userId, version, patch)
+	ret0, _ := ret[0].(*models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchExpense indicates an expected call of PatchExpense.
+func (mr *MockExpenseServiceMockRecorder) PatchExpense(ctx, id, userId, version, patch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchExpense", reflect.TypeOf((*MockExpenseService)(nil).PatchExpense), ctx, id, userId, version, patch)
+}
+
+// RestoreExpense mocks base method.
+func (m *MockExpenseService) RestoreExpense(ctx context.Context, id uint) (*models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreExpense", ctx, id)
+	ret0, _ := ret[0].(*models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreExpense indicates an expected call of RestoreExpense.
+func (mr *MockExpenseServiceMockRecorder) RestoreExpense(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreExpense", reflect.TypeOf((*MockExpenseService)(nil).RestoreExpense), ctx, id)
+}
+
+// StreamExpenses mocks base method.
+func (m *MockExpenseService) StreamExpenses(ctx context.Context, filters map[string]any, fn func(*models.Expense) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamExpenses", ctx, filters, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamExpenses indicates an expected call of StreamExpenses.
+func (mr *MockExpenseServiceMockRecorder) StreamExpenses(ctx, filters, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamExpenses", reflect.TypeOf((*MockExpenseService)(nil).StreamExpenses), ctx, filters, fn)
+}
+
+// UpdateExpense mocks base method.
+func (m *MockExpenseService) UpdateExpense(ctx context.Context, id uint, expense *models.Expense, userId, version uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExpense", ctx, id, expense, userId, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateExpense indicates an expected call of UpdateExpense.
+func (mr *MockExpenseServiceMockRecorder) UpdateExpense(ctx, id, expense, userId, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpense", reflect.TypeOf((*MockExpenseService)(nil).UpdateExpense), ctx, id, expense, userId, version)
+}
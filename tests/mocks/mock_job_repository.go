@@ -0,0 +1,176 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/job_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/job_repository.go -destination=tests/mocks/mock_job_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/onunkwor/flypro-assestment-v2/internal/models"
+	repository "github.com/onunkwor/flypro-assestment-v2/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJobRepository is a mock of JobRepository interface.
+type MockJobRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockJobRepositoryMockRecorder is the mock recorder for MockJobRepository.
+type MockJobRepositoryMockRecorder struct {
+	mock *MockJobRepository
+}
+
+// NewMockJobRepository creates a new mock instance.
+func NewMockJobRepository(ctrl *gomock.Controller) *MockJobRepository {
+	mock := &MockJobRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRepository) EXPECT() *MockJobRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Activity mocks base method.
+func (m *MockJobRepository) Activity(ctx context.Context, now time.Time) (*repository.JobActivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Activity", ctx, now)
+	ret0, _ := ret[0].(*repository.JobActivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Activity indicates an expected call of Activity.
+func (mr *MockJobRepositoryMockRecorder) Activity(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Activity", reflect.TypeOf((*MockJobRepository)(nil).Activity), ctx, now)
+}
+
+// ClaimDue mocks base method.
+func (m *MockJobRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDue", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDue indicates an expected call of ClaimDue.
+func (mr *MockJobRepositoryMockRecorder) ClaimDue(ctx, now, lease, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDue", reflect.TypeOf((*MockJobRepository)(nil).ClaimDue), ctx, now, lease, limit)
+}
+
+// CountByStatus mocks base method.
+func (m *MockJobRepository) CountByStatus(ctx context.Context) ([]repository.JobStatusCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", ctx)
+	ret0, _ := ret[0].([]repository.JobStatusCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockJobRepositoryMockRecorder) CountByStatus(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockJobRepository)(nil).CountByStatus), ctx)
+}
+
+// Enqueue mocks base method.
+func (m *MockJobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockJobRepositoryMockRecorder) Enqueue(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockJobRepository)(nil).Enqueue), ctx, job)
+}
+
+// GetJob mocks base method.
+func (m *MockJobRepository) GetJob(ctx context.Context, id uint) (*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockJobRepositoryMockRecorder) GetJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockJobRepository)(nil).GetJob), ctx, id)
+}
+
+// ListJobs mocks base method.
+func (m *MockJobRepository) ListJobs(ctx context.Context, status, kind string, offset, limit int) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobs", ctx, status, kind, offset, limit)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobs indicates an expected call of ListJobs.
+func (mr *MockJobRepositoryMockRecorder) ListJobs(ctx, status, kind, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobs", reflect.TypeOf((*MockJobRepository)(nil).ListJobs), ctx, status, kind, offset, limit)
+}
+
+// PurgeFinished mocks base method.
+func (m *MockJobRepository) PurgeFinished(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeFinished", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeFinished indicates an expected call of PurgeFinished.
+func (mr *MockJobRepositoryMockRecorder) PurgeFinished(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeFinished", reflect.TypeOf((*MockJobRepository)(nil).PurgeFinished), ctx, before)
+}
+
+// Requeue mocks base method.
+func (m *MockJobRepository) Requeue(ctx context.Context, id uint, runAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", ctx, id, runAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockJobRepositoryMockRecorder) Requeue(ctx, id, runAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockJobRepository)(nil).Requeue), ctx, id, runAt)
+}
+
+// SaveResult mocks base method.
+func (m *MockJobRepository) SaveResult(ctx context.Context, job *models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveResult", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveResult indicates an expected call of SaveResult.
+func (mr *MockJobRepositoryMockRecorder) SaveResult(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveResult", reflect.TypeOf((*MockJobRepository)(nil).SaveResult), ctx, job)
+}
@@ -75,18 +75,18 @@ func (mr *MockRedisClientMockRecorder) Get(ctx, key any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRedisClient)(nil).Get), ctx, key)
 }
 
-// Scan mocks base method.
-func (m *MockRedisClient) Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd {
+// Incr mocks base method.
+func (m *MockRedisClient) Incr(ctx context.Context, key string) *redis.IntCmd {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Scan", ctx, cursor, match, count)
-	ret0, _ := ret[0].(*redis.ScanCmd)
+	ret := m.ctrl.Call(m, "Incr", ctx, key)
+	ret0, _ := ret[0].(*redis.IntCmd)
 	return ret0
 }
 
-// Scan indicates an expected call of Scan.
-func (mr *MockRedisClientMockRecorder) Scan(ctx, cursor, match, count any) *gomock.Call {
+// Incr indicates an expected call of Incr.
+func (mr *MockRedisClientMockRecorder) Incr(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockRedisClient)(nil).Scan), ctx, cursor, match, count)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockRedisClient)(nil).Incr), ctx, key)
 }
 
 // Set mocks base method.
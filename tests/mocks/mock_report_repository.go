@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/onunkwor/flypro-assestment-v2/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -69,6 +70,34 @@ func (mr *MockReportRepositoryMockRecorder) CreateReport(ctx, report any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockReportRepository)(nil).CreateReport), ctx, report)
 }
 
+// DeleteReport mocks base method.
+func (m *MockReportRepository) DeleteReport(ctx context.Context, id, version uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReport", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteReport indicates an expected call of DeleteReport.
+func (mr *MockReportRepositoryMockRecorder) DeleteReport(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReport", reflect.TypeOf((*MockReportRepository)(nil).DeleteReport), ctx, id, version)
+}
+
+// FreezeReportingRate mocks base method.
+func (m *MockReportRepository) FreezeReportingRate(ctx context.Context, reportID uint, rate float64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeReportingRate", ctx, reportID, rate, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FreezeReportingRate indicates an expected call of FreezeReportingRate.
+func (mr *MockReportRepositoryMockRecorder) FreezeReportingRate(ctx, reportID, rate, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeReportingRate", reflect.TypeOf((*MockReportRepository)(nil).FreezeReportingRate), ctx, reportID, rate, at)
+}
+
 // GetExpenseReportByID mocks base method.
 func (m *MockReportRepository) GetExpenseReportByID(ctx context.Context, id uint) (*models.ExpenseReport, error) {
 	m.ctrl.T.Helper()
@@ -99,16 +128,74 @@ func (mr *MockReportRepositoryMockRecorder) GetReportExpenses(ctx, userID, offse
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReportExpenses", reflect.TypeOf((*MockReportRepository)(nil).GetReportExpenses), ctx, userID, offset, limit)
 }
 
-// SubmitReport mocks base method.
-func (m *MockReportRepository) SubmitReport(ctx context.Context, reportID uint) error {
+// GetReportExpensesUnscoped mocks base method.
+func (m *MockReportRepository) GetReportExpensesUnscoped(ctx context.Context, userID uint, offset, limit int) ([]models.ExpenseReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReportExpensesUnscoped", ctx, userID, offset, limit)
+	ret0, _ := ret[0].([]models.ExpenseReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReportExpensesUnscoped indicates an expected call of GetReportExpensesUnscoped.
+func (mr *MockReportRepositoryMockRecorder) GetReportExpensesUnscoped(ctx, userID, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReportExpensesUnscoped", reflect.TypeOf((*MockReportRepository)(nil).GetReportExpensesUnscoped), ctx, userID, offset, limit)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockReportRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockReportRepositoryMockRecorder) PurgeDeleted(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockReportRepository)(nil).PurgeDeleted), ctx, before)
+}
+
+// RestoreReport mocks base method.
+func (m *MockReportRepository) RestoreReport(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreReport", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreReport indicates an expected call of RestoreReport.
+func (mr *MockReportRepositoryMockRecorder) RestoreReport(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreReport", reflect.TypeOf((*MockReportRepository)(nil).RestoreReport), ctx, id)
+}
+
+// StreamReportExpenses mocks base method.
+func (m *MockReportRepository) StreamReportExpenses(ctx context.Context, reportID uint, fn func(*models.Expense) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamReportExpenses", ctx, reportID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamReportExpenses indicates an expected call of StreamReportExpenses.
+func (mr *MockReportRepositoryMockRecorder) StreamReportExpenses(ctx, reportID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamReportExpenses", reflect.TypeOf((*MockReportRepository)(nil).StreamReportExpenses), ctx, reportID, fn)
+}
+
+// TransitionStatus mocks base method.
+func (m *MockReportRepository) TransitionStatus(ctx context.Context, change *models.ReportStatusChange, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SubmitReport", ctx, reportID)
+	ret := m.ctrl.Call(m, "TransitionStatus", ctx, change, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SubmitReport indicates an expected call of SubmitReport.
-func (mr *MockReportRepositoryMockRecorder) SubmitReport(ctx, reportID any) *gomock.Call {
+// TransitionStatus indicates an expected call of TransitionStatus.
+func (mr *MockReportRepositoryMockRecorder) TransitionStatus(ctx, change, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitReport", reflect.TypeOf((*MockReportRepository)(nil).SubmitReport), ctx, reportID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionStatus", reflect.TypeOf((*MockReportRepository)(nil).TransitionStatus), ctx, change, version)
 }